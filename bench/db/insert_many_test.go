@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestInsertManyByRowid verifies InsertMany batches the rows of a table keyed only by its rowid,
+// and matches each row SQLite returns to the struct it was inserted from.
+func TestInsertManyByRowid(t *testing.T) {
+	db := sqlx.MustOpen("sqlite3", ":memory:")
+	db.SetMaxOpenConns(1) // each connection to :memory: is a separate database
+	t.Cleanup(func() { db.Close() })
+	schema, err := os.ReadFile("../schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.MustExec(string(schema))
+	db.MustExec(`INSERT INTO events (id, name) VALUES (100, 'existing')`)
+	ctx := context.Background()
+
+	rows := []*Event{}
+	for i := range 5 {
+		rows = append(rows, &Event{Name: fmt.Sprintf("event %d", i)})
+	}
+	if err := EventInsertMany(ctx, db, rows); err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range rows {
+		got, err := EventGetByID(ctx, db, x.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != x.Name || x.ID.Int64 <= 100 {
+			t.Errorf("row %d is %q, want %q after the existing row", x.ID.Int64, got.Name, x.Name)
+		}
+	}
+}
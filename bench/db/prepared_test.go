@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestQueriesSkipDynamicUpdates verifies Queries does not cache the statements Update and
+// UpdateFields build from the fields they write, which would grow with every combination.
+func TestQueriesSkipDynamicUpdates(t *testing.T) {
+	conn := sqlx.MustOpen("sqlite3", ":memory:")
+	conn.SetMaxOpenConns(1) // each connection to :memory: is a separate database
+	t.Cleanup(func() { conn.Close() })
+	schema, err := os.ReadFile("../schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.MustExec(string(schema))
+	ctx := context.Background()
+	q := NewQueries(conn)
+	t.Cleanup(func() { q.Close() })
+
+	u := &User{Email: "a@example.com", Name: "A"}
+	if err := u.Insert(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	named := len(q.named)
+	u.Name = "B"
+	if err := u.Update(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	u.Karma = 2
+	if err := u.Update(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	if err := UserUpdateFields(ctx, q, u.ID, UserSetBio(sql.NullString{String: "bio", Valid: true})); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.named) != named || len(q.stmts) != 0 {
+		t.Errorf("cached %d named and %d positional statements after updates, want %d and 0", len(q.named), len(q.stmts), named)
+	}
+}
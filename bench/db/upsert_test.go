@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestUpsertKeepsDefaultNow verifies upserting an existing row leaves a column that defaults to the
+// current time as it was, rather than setting it to the time of the upsert.
+func TestUpsertKeepsDefaultNow(t *testing.T) {
+	db := sqlx.MustOpen("sqlite3", ":memory:")
+	db.SetMaxOpenConns(1) // each connection to :memory: is a separate database
+	t.Cleanup(func() { db.Close() })
+	schema, err := os.ReadFile("../schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.MustExec(string(schema))
+	ctx := context.Background()
+
+	v := &Visit{Path: "/", HitCount: 1}
+	if err := v.Upsert(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	// Backdate the row, so a second upsert in the same second can't hide a change.
+	db.MustExec(`UPDATE visits SET first_seen = '2000-01-01 00:00:00' WHERE path = '/'`)
+	v = &Visit{Path: "/", HitCount: 2}
+	if err := v.Upsert(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if v.HitCount != 2 {
+		t.Errorf("hit_count = %d, want 2", v.HitCount)
+	}
+	if v.FirstSeen.Year() != 2000 {
+		t.Errorf("first_seen = %v, want it kept from the first upsert", v.FirstSeen)
+	}
+}
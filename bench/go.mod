@@ -0,0 +1,9 @@
+module github.com/joshsziegler/squirrel/bench
+
+go 1.25.0
+
+require (
+	github.com/ansel1/merry/v2 v2.2.1
+	github.com/jmoiron/sqlx v1.4.0
+	github.com/mattn/go-sqlite3 v1.14.52
+)
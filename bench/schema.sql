@@ -0,0 +1,27 @@
+-- Schema for the scan benchmarks: a mix of the column types squirrel generates.
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	bio TEXT,
+	karma INTEGER NOT NULL DEFAULT 0,
+	score REAL,
+	admin BOOL NOT NULL DEFAULT FALSE,
+	avatar BLOB,
+	seen_at DATETIME,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Keyed by a value the caller chooses, so rows are written with Upsert.
+CREATE TABLE visits (
+	path TEXT NOT NULL PRIMARY KEY,
+	hit_count INTEGER NOT NULL DEFAULT 0,
+	first_seen DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Keyed only by its rowid, so InsertMany matches the rows it returns by rowid.
+CREATE TABLE events (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL
+);
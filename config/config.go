@@ -4,6 +4,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -22,11 +23,237 @@ type Config struct {
 	// CtxOnly emits only context-aware DB methods (e.g. ExecContext) when true.
 	// Defaults to true when omitted from the config file.
 	CtxOnly bool `yaml:"ctx_only"`
+	// Runtime is the database package the generated Go is written against (default: sqlx).
+	Runtime Runtime `yaml:"runtime"`
+	// Errors is how the generated Go wraps the errors it returns, naming the operation and the key
+	// values of the row involved (default: merry with the sqlx runtime, stdlib with database/sql).
+	Errors ErrorStrategy `yaml:"errors"`
 	// Acronyms maps a lowercase SQL word to the Go form that should be kept
 	// verbatim (and not singularized) when converting SQL names to Go names,
 	// e.g. {aws: AWS, dns: DNS, oauth: OAuth}. These merge with and override
 	// squirrel's built-in defaults (ID, CPU, GPU, URL, IP, ...).
 	Acronyms map[string]string `yaml:"acronyms"`
+	// SoftDelete names a nullable timestamp column (e.g. deleted_at) that marks a row as deleted
+	// instead of removing it. Tables with this column get a Delete that sets it, plus Restore and
+	// HardDelete, and their getters skip soft-deleted rows. Empty (the default) disables soft deletes.
+	SoftDelete string `yaml:"soft_delete"`
+	// Version names a NOT NULL integer column (e.g. version) used for optimistic concurrency. Writes
+	// to tables with this column only succeed if it still holds the value that was read, and increment
+	// it. A column can also be marked with a "squirrel:version" comment. Empty (the default) disables it.
+	Version string `yaml:"version"`
+	// Timestamps names the columns the database stamps with the current time, and how it formats them.
+	Timestamps Timestamps `yaml:"timestamps"`
+	// Queries lists files (or globs, e.g. queries/*.sql) of annotated SQL statements to compile to
+	// typed Go functions, such as "-- name: ListActiveUsers :many" followed by a SELECT.
+	Queries []string `yaml:"queries"`
+	// Stores generates a <Table>Store interface per table holding its getters and write methods, with
+	// a <Table>DBStore implementing it with the database, and an in-memory <Table>FakeStore for tests.
+	Stores bool `yaml:"stores"`
+	// Fixtures generates a New<Table>Fixture per table for tests, which fills the NOT NULL columns of a
+	// row with values the schema accepts, and whose InsertFixture inserts the rows it references first.
+	Fixtures bool `yaml:"fixtures"`
+	// Tests writes a round-trip test of the generated Go next to Dest (see TestsDest), which creates
+	// the schema in an in-memory SQLite database and inserts, reads, updates, upserts, and deletes a
+	// row of every table.
+	Tests bool `yaml:"tests"`
+	// TestDriver is the import path of the SQLite driver the generated tests open databases with
+	// (default: github.com/mattn/go-sqlite3). It must be one of TestDrivers.
+	TestDriver string `yaml:"test_driver"`
+	// Tables holds per-table overrides of the settings above, keyed by SQL table name.
+	Tables map[string]TableConfig `yaml:"tables"`
+}
+
+// TableConfig holds the settings that can be overridden for a single table. Fields omitted from the
+// config file are nil and fall back to the global setting.
+type TableConfig struct {
+	// SoftDelete overrides Config.SoftDelete for this table; set it to "" to disable soft deletes.
+	SoftDelete *string `yaml:"soft_delete"`
+	// Version overrides Config.Version for this table; set it to "" to disable the version column.
+	Version *string `yaml:"version"`
+	// Timestamps overrides fields of Config.Timestamps for this table.
+	Timestamps TableTimestamps `yaml:"timestamps"`
+}
+
+// Runtime is the database package the generated Go is written against.
+type Runtime string
+
+const (
+	// Sqlx generates against github.com/jmoiron/sqlx, binding struct fields with named parameters
+	// (e.g. :email), and wraps errors with github.com/ansel1/merry/v2.
+	Sqlx Runtime = "sqlx"
+	// DatabaseSQL generates against the standard library's *sql.DB, *sql.Tx, and *sql.Conn, with
+	// positional ? parameters, and wraps errors with fmt.Errorf. The generated Go imports neither
+	// sqlx nor merry.
+	DatabaseSQL Runtime = "database/sql"
+)
+
+// validate returns an error if r is not a known runtime. Empty is allowed, and treated as Sqlx.
+func (r Runtime) validate() error {
+	switch r {
+	case "", Sqlx, DatabaseSQL:
+		return nil
+	default:
+		return fmt.Errorf("config: 'runtime' must be sqlx or database/sql, not %q", r)
+	}
+}
+
+// ErrorStrategy is how the generated Go wraps the errors it returns. Each names the operation (e.g.
+// users.Update) and the key values of the row involved (e.g. id=1).
+type ErrorStrategy string
+
+const (
+	// ErrorsMerry wraps errors with github.com/ansel1/merry/v2, which also records a stack trace.
+	ErrorsMerry ErrorStrategy = "merry"
+	// ErrorsStdlib wraps errors with fmt.Errorf and %w (e.g. "users.Update (id=1): ...").
+	ErrorsStdlib ErrorStrategy = "stdlib"
+	// ErrorsHook passes errors to a wrapErr function written by hand in the generated package:
+	// func wrapErr(err error, op string, keys ...any) error, where keys alternate each key column's
+	// name and value (e.g. "id", 1).
+	ErrorsHook ErrorStrategy = "hook"
+)
+
+// validate returns an error if s is not a known strategy. Empty is allowed, and uses the runtime's
+// default.
+func (s ErrorStrategy) validate() error {
+	switch s {
+	case "", ErrorsMerry, ErrorsStdlib, ErrorsHook:
+		return nil
+	default:
+		return fmt.Errorf("config: 'errors' must be merry, stdlib, or hook, not %q", s)
+	}
+}
+
+// ErrorWrapping returns the configured error strategy, or the runtime's default if none is set:
+// merry with sqlx, and stdlib with database/sql, which should not need merry.
+func (c *Config) ErrorWrapping() ErrorStrategy {
+	switch {
+	case c.Errors != "":
+		return c.Errors
+	case c.Runtime == DatabaseSQL:
+		return ErrorsStdlib
+	default:
+		return ErrorsMerry
+	}
+}
+
+// TestDrivers maps the import paths of the SQLite drivers the generated tests can use to the names
+// they register with database/sql.
+var TestDrivers = map[string]string{
+	"github.com/mattn/go-sqlite3":          "sqlite3",
+	"modernc.org/sqlite":                   "sqlite",
+	"github.com/ncruces/go-sqlite3/driver": "sqlite3",
+}
+
+// TestDriverName returns the database/sql name of the configured TestDriver.
+func (c *Config) TestDriverName() string {
+	return TestDrivers[c.TestDriver]
+}
+
+// TestsDest returns the path to write the round-trip test to when Tests is set: Dest with a
+// _roundtrip_test.go suffix (e.g. db/db_roundtrip_test.go for db/db.go).
+func (c *Config) TestsDest() string {
+	return strings.TrimSuffix(c.Dest, ".go") + "_roundtrip_test.go"
+}
+
+// TimestampFormat is how the database writes the current time to a timestamp column.
+type TimestampFormat string
+
+const (
+	// ISO is UTC text such as "2024-01-02 15:04:05", as written by datetime('now') or CURRENT_TIMESTAMP.
+	ISO TimestampFormat = "iso"
+	// UnixEpoch is the integer number of seconds since 1970-01-01. Requires SQLite 3.38.0 or later.
+	UnixEpoch TimestampFormat = "unixepoch"
+	// Subsec is ISO text with milliseconds, such as "2024-01-02 15:04:05.678". Requires SQLite 3.42.0
+	// or later.
+	Subsec TimestampFormat = "subsec"
+)
+
+// Expr returns the SQLite expression for the current time in this format. Empty or unknown formats
+// use ISO.
+func (f TimestampFormat) Expr() string {
+	switch f {
+	case UnixEpoch:
+		return "unixepoch()"
+	case Subsec:
+		return "datetime('now', 'subsec')"
+	default:
+		return "datetime('now')"
+	}
+}
+
+// Timestamps names the columns the database maintains with the current time. An empty name means the
+// table has no such column.
+type Timestamps struct {
+	// Created is set once when the row is inserted, and never updated (default: created_at).
+	Created string `yaml:"created"`
+	// Updated is set when the row is inserted and again on every update (default: updated_at).
+	Updated string `yaml:"updated"`
+	// Deleted is left NULL on insert, and only set when the row is deleted (default: deleted_at).
+	// Set SoftDelete to the same column to have Delete set it instead of removing the row.
+	Deleted string `yaml:"deleted"`
+	// Format is how the current time is written (default: iso).
+	Format TimestampFormat `yaml:"format"`
+}
+
+// TableTimestamps holds the Timestamps fields that can be overridden for a single table. Fields
+// omitted from the config file are nil and fall back to the global setting.
+type TableTimestamps struct {
+	Created *string          `yaml:"created"`
+	Updated *string          `yaml:"updated"`
+	Deleted *string          `yaml:"deleted"`
+	Format  *TimestampFormat `yaml:"format"`
+}
+
+// SoftDeleteColumn returns the soft-delete column configured for the named table, or "" if it has
+// none.
+func (c *Config) SoftDeleteColumn(table string) string {
+	if tc, ok := c.Tables[table]; ok && tc.SoftDelete != nil {
+		return *tc.SoftDelete
+	}
+	return c.SoftDelete
+}
+
+// VersionColumn returns the version column configured for the named table, or "" if it has none.
+func (c *Config) VersionColumn(table string) string {
+	if tc, ok := c.Tables[table]; ok && tc.Version != nil {
+		return *tc.Version
+	}
+	return c.Version
+}
+
+// TimestampColumns returns the timestamp settings for the named table, which are the global
+// Timestamps with any of the table's overrides applied.
+func (c *Config) TimestampColumns(table string) Timestamps {
+	ts := c.Timestamps
+	tc := c.Tables[table].Timestamps
+	if tc.Created != nil {
+		ts.Created = *tc.Created
+	}
+	if tc.Updated != nil {
+		ts.Updated = *tc.Updated
+	}
+	if tc.Deleted != nil {
+		ts.Deleted = *tc.Deleted
+	}
+	if tc.Format != nil {
+		ts.Format = *tc.Format
+	}
+	return ts
+}
+
+// Default returns the settings used for anything a config file leaves out.
+func Default() Config {
+	return Config{
+		CtxOnly:    true,
+		Runtime:    Sqlx,
+		TestDriver: "github.com/mattn/go-sqlite3",
+		Timestamps: Timestamps{
+			Created: "created_at",
+			Updated: "updated_at",
+			Deleted: "deleted_at",
+			Format:  ISO,
+		},
+	}
 }
 
 // Load reads and parses the YAML config file at path. Defaults are applied
@@ -38,7 +265,7 @@ func Load(path string) (*Config, error) {
 	}
 	// Set defaults before unmarshaling. yaml.v3 leaves fields untouched when
 	// their key is absent, so these survive unless the file overrides them.
-	cfg := Config{CtxOnly: true}
+	cfg := Default()
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("config: %w", err)
 	}
@@ -56,5 +283,35 @@ func (c *Config) Validate() error {
 	if c.Package == "" {
 		return fmt.Errorf("config: 'package' is required")
 	}
+	if err := c.Runtime.validate(); err != nil {
+		return err
+	}
+	if err := c.Errors.validate(); err != nil {
+		return err
+	}
+	if _, ok := TestDrivers[c.TestDriver]; c.Tests && !ok {
+		return fmt.Errorf("config: 'test_driver' must be github.com/mattn/go-sqlite3, modernc.org/sqlite, or github.com/ncruces/go-sqlite3/driver, not %q", c.TestDriver)
+	}
+	if err := c.Timestamps.Format.validate(); err != nil {
+		return err
+	}
+	for name, tc := range c.Tables {
+		if tc.Timestamps.Format == nil {
+			continue
+		}
+		if err := tc.Timestamps.Format.validate(); err != nil {
+			return fmt.Errorf("%w (table %s)", err, name)
+		}
+	}
 	return nil
 }
+
+// validate returns an error if f is not a known format. Empty is allowed, and treated as ISO.
+func (f TimestampFormat) validate() error {
+	switch f {
+	case "", ISO, UnixEpoch, Subsec:
+		return nil
+	default:
+		return fmt.Errorf("config: 'timestamps.format' must be iso, unixepoch, or subsec, not %q", f)
+	}
+}
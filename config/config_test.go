@@ -63,6 +63,44 @@ ctx_only: false
 	assert.False(t, cfg.CtxOnly, "ctx_only: false should be honored")
 }
 
+func TestLoad_Runtime(t *testing.T) {
+	path := writeTemp(t, `
+schema: schema.sql
+dest: db.go
+package: db
+`)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Sqlx, cfg.Runtime, "runtime should default to sqlx when omitted")
+
+	path = writeTemp(t, `
+schema: schema.sql
+dest: db.go
+package: db
+runtime: database/sql
+`)
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, DatabaseSQL, cfg.Runtime)
+}
+
+func TestErrorWrapping(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want ErrorStrategy
+	}{
+		{"sqlx defaults to merry", Config{Runtime: Sqlx}, ErrorsMerry},
+		{"database/sql defaults to stdlib", Config{Runtime: DatabaseSQL}, ErrorsStdlib},
+		{"configured", Config{Runtime: DatabaseSQL, Errors: ErrorsHook}, ErrorsHook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.ErrorWrapping())
+		})
+	}
+}
+
 func TestLoad_MissingFile(t *testing.T) {
 	_, err := Load(filepath.Join(t.TempDir(), "does-not-exist.yaml"))
 	assert.Error(t, err)
@@ -78,6 +116,13 @@ func TestValidate(t *testing.T) {
 		{"missing schema", Config{Dest: "db.go", Package: "db"}, true},
 		{"missing dest", Config{Schema: "s.sql", Package: "db"}, true},
 		{"missing package", Config{Schema: "s.sql", Dest: "db.go"}, true},
+		{"database/sql runtime", Config{Schema: "s.sql", Dest: "db.go", Package: "db", Runtime: DatabaseSQL}, false},
+		{"hook errors", Config{Schema: "s.sql", Dest: "db.go", Package: "db", Errors: ErrorsHook}, false},
+		{"unknown errors", Config{Schema: "s.sql", Dest: "db.go", Package: "db", Errors: "pkg/errors"}, true},
+		{"unknown runtime", Config{Schema: "s.sql", Dest: "db.go", Package: "db", Runtime: "gorm"}, true},
+		{"unknown timestamp format", Config{Schema: "s.sql", Dest: "db.go", Package: "db", Timestamps: Timestamps{Format: "epoch"}}, true},
+		{"tests with modernc", Config{Schema: "s.sql", Dest: "db.go", Package: "db", Tests: true, TestDriver: "modernc.org/sqlite"}, false},
+		{"tests with unknown driver", Config{Schema: "s.sql", Dest: "db.go", Package: "db", Tests: true, TestDriver: "example.com/sqlite"}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -90,3 +135,60 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad_SoftDeleteWithTableOverrides(t *testing.T) {
+	path := writeTemp(t, `
+schema: schema.sql
+dest: db.go
+package: db
+soft_delete: deleted_at
+tables:
+  audit_logs:
+    soft_delete: ""
+  sessions:
+    soft_delete: revoked_at
+`)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "deleted_at", cfg.SoftDeleteColumn("users"), "tables without an override use the global setting")
+	assert.Equal(t, "", cfg.SoftDeleteColumn("audit_logs"), "an empty override disables soft deletes")
+	assert.Equal(t, "revoked_at", cfg.SoftDeleteColumn("sessions"))
+}
+
+func TestLoad_VersionWithTableOverrides(t *testing.T) {
+	path := writeTemp(t, `
+schema: schema.sql
+dest: db.go
+package: db
+tables:
+  accounts:
+    version: revision
+`)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.VersionColumn("users"), "version columns are off by default")
+	assert.Equal(t, "revision", cfg.VersionColumn("accounts"))
+}
+
+func TestLoad_TimestampsWithTableOverrides(t *testing.T) {
+	path := writeTemp(t, `
+schema: schema.sql
+dest: db.go
+package: db
+timestamps:
+  updated: modified_at
+tables:
+  events:
+    timestamps:
+      created: ""
+      format: unixepoch
+`)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, Timestamps{Created: "created_at", Updated: "modified_at", Deleted: "deleted_at", Format: ISO},
+		cfg.TimestampColumns("users"), "omitted fields keep their defaults")
+	assert.Equal(t, Timestamps{Created: "", Updated: "modified_at", Deleted: "deleted_at", Format: UnixEpoch},
+		cfg.TimestampColumns("events"))
+	assert.Equal(t, "unixepoch()", cfg.TimestampColumns("events").Format.Expr())
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// DiffSchemas parses the schema files at oldPath and newPath and returns how the new one differs.
+func DiffSchemas(oldPath, newPath string) (*parser.SchemaDiff, error) {
+	oldTables, err := ParseFile(oldPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", oldPath, err)
+	}
+	newTables, err := ParseFile(newPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", newPath, err)
+	}
+	return parser.Diff(oldTables, newTables), nil
+}
+
+// diffCommand runs "squirrel diff [-goose path] old.sql new.sql", which prints how the new schema
+// differs from the old one and the SQL migrating a database from one to the other, and writes a
+// goose migration to path if it is given.
+func diffCommand(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	goosePath := flags.String("goose", "", "Path to write a goose migration with Up and Down sections to")
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s diff [-goose path] old.sql new.sql\n\n", os.Args[0])
+		flags.PrintDefaults()
+		fmt.Println("")
+		fmt.Println("Prints the tables, columns, constraints, and indexes that differ between the schemas, and then")
+		fmt.Println("the SQLite migration from old.sql to new.sql. A table or column that was renamed can be marked")
+		fmt.Println("with a comment such as \"-- squirrel:renamed_from old_name\" in new.sql.")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	d, err := DiffSchemas(flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if d.Empty() {
+		fmt.Println("The schemas do not differ.")
+		return nil
+	}
+	fmt.Print(d.String())
+	fmt.Println("")
+	migration, err := d.Migration()
+	if err != nil {
+		return err
+	}
+	fmt.Print(migration)
+	if *goosePath != "" {
+		goose, err := d.Goose()
+		if err != nil {
+			return err
+		}
+		return writeFile(*goosePath, func(f *os.File) { fmt.Fprint(f, goose) })
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joshsziegler/squirrel/docs"
+)
+
+// docsCommand runs "squirrel docs [-format markdown|html] [-config path] [schema.sql]", which
+// prints a data dictionary of the schema, read from schema.sql if it is given or else from the
+// config's schema.
+func docsCommand(args []string) error {
+	flags := flag.NewFlagSet("docs", flag.ExitOnError)
+	format := flags.String("format", "markdown", "Output format: markdown or html")
+	configPath := flags.String("config", "squirrel.yaml", "Path to the YAML config file, if it exists, whose acronyms and ignore_tables are used, and whose schema is used if schema.sql is not given")
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s docs [-format markdown|html] [-config path] [schema.sql]\n\n", os.Args[0])
+		flags.PrintDefaults()
+		fmt.Println("")
+		fmt.Println("Prints a data dictionary of the tables in schema.sql, or in the config's schema if it is not")
+		fmt.Println("given, with their comments, columns, constraints, indexes, foreign keys, and Go types.")
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	tables, ignore, err := loadSchema(*configPath, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "markdown":
+		fmt.Print(docs.Markdown(tables, ignore))
+	case "html":
+		fmt.Print(docs.HTML(tables, ignore))
+	default:
+		return fmt.Errorf("docs: format must be markdown or html, not %q", *format)
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+// Package docs writes a data dictionary of parsed tables, as Markdown or HTML: for each table, its
+// comment, generated Go type, columns, constraints, indexes, and the foreign keys to and from it.
+package docs
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// span is a run of text in a cell, written as code if code is true, and linked to the table with
+// the anchor href if it is not "".
+type span struct {
+	text string
+	code bool
+	href string
+}
+
+// cell is the spans of a table cell or paragraph, separated by ", " if sep is true.
+type cell struct {
+	spans []span
+	sep   bool
+}
+
+// grid is a titled table of a section of a table's entry, left out if it has no rows.
+type grid struct {
+	title  string
+	header []string
+	rows   [][]cell
+}
+
+// entry is a table's part of the data dictionary.
+type entry struct {
+	table  *parser.Table
+	anchor string
+	about  []cell // about holds the paragraphs before the grids.
+	grids  []grid
+}
+
+// Markdown returns the data dictionary of the tables as GitHub-flavored Markdown, with a list of
+// the tables and a section for each. Tables in ignore have no generated Go type, so none is listed.
+func Markdown(tables []*parser.Table, ignore []string) string {
+	var b strings.Builder
+	b.WriteString("# Data dictionary\n\n")
+	entries := entries(tables, ignore)
+	for _, e := range entries {
+		fmt.Fprintf(&b, "- [%s](#%s)%s\n", markdownText(e.table.SQLName()), e.anchor, summary(e.table, " - "))
+	}
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n## %s\n", markdownText(e.table.SQLName()))
+		for _, p := range e.about {
+			b.WriteString("\n" + markdownCell(p, false) + "\n")
+		}
+		for _, g := range e.grids {
+			if len(g.rows) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n**%s**\n\n", g.title)
+			b.WriteString("| " + strings.Join(g.header, " | ") + " |\n")
+			b.WriteString("|" + strings.Repeat(" --- |", len(g.header)) + "\n")
+			for _, row := range g.rows {
+				cells := []string{}
+				for _, c := range row {
+					cells = append(cells, markdownCell(c, true))
+				}
+				b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// HTML returns the data dictionary of the tables as a standalone HTML page, with a list of the
+// tables and a section for each. Tables in ignore have no generated Go type, so none is listed.
+func HTML(tables []*parser.Table, ignore []string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Data dictionary</title>\n")
+	b.WriteString("<style>\n")
+	b.WriteString("body { font-family: sans-serif; margin: 2em; }\n")
+	b.WriteString("table { border-collapse: collapse; margin-bottom: 1em; }\n")
+	b.WriteString("th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; vertical-align: top; }\n")
+	b.WriteString("th { background: #eee; }\n")
+	b.WriteString("</style>\n</head>\n<body>\n<h1>Data dictionary</h1>\n<ul>\n")
+	entries := entries(tables, ignore)
+	for _, e := range entries {
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a>%s</li>\n", e.anchor, html.EscapeString(e.table.SQLName()),
+			html.EscapeString(summary(e.table, " - ")))
+	}
+	b.WriteString("</ul>\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "<section id=\"%s\">\n<h2>%s</h2>\n", e.anchor, html.EscapeString(e.table.SQLName()))
+		for _, p := range e.about {
+			b.WriteString("<p>" + htmlCell(p) + "</p>\n")
+		}
+		for _, g := range e.grids {
+			if len(g.rows) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "<h3>%s</h3>\n<table>\n<tr>", g.title)
+			for _, h := range g.header {
+				b.WriteString("<th>" + h + "</th>")
+			}
+			b.WriteString("</tr>\n")
+			for _, row := range g.rows {
+				b.WriteString("<tr>")
+				for _, c := range row {
+					b.WriteString("<td>" + htmlCell(c) + "</td>")
+				}
+				b.WriteString("</tr>\n")
+			}
+			b.WriteString("</table>\n")
+		}
+		b.WriteString("</section>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// entries returns the data dictionary entry of each table, in order.
+func entries(tables []*parser.Table, ignore []string) []entry {
+	anchors := map[*parser.Table]string{}
+	for _, t := range tables {
+		anchors[t] = anchor(t.SQLName())
+	}
+	// href returns the anchor of the named table, or "" if it is not one of tables.
+	href := func(name string) string {
+		if t := parser.FindTable(tables, name); t != nil {
+			return anchors[t]
+		}
+		return ""
+	}
+	// link returns the cell naming the table's columns, linked to the table.
+	link := func(table string, columns []string) cell {
+		return cell{spans: []span{{text: table, href: href(table)}, {text: " (" + strings.Join(columns, ", ") + ")"}}}
+	}
+
+	entries := []entry{}
+	for _, t := range tables {
+		e := entry{table: t, anchor: anchors[t]}
+		if t.Comment != "" {
+			e.about = append(e.about, text(t.Comment))
+		}
+		if !slices.Contains(ignore, t.SQLName()) {
+			e.about = append(e.about, cell{spans: []span{{text: "Go type: "}, {text: t.GoName(), code: true}}})
+		}
+
+		columns := grid{title: "Columns", header: []string{"Column", "Type", "Nullable", "Default", "Keys", "Go field", "Comment"}}
+		for i := range t.Columns {
+			c := &t.Columns[i]
+			nullable := "no"
+			if c.Nullable {
+				nullable = "yes"
+			}
+			keys := cell{sep: true}
+			if c.PrimaryKey || c.CompositePrimaryKey {
+				keys.spans = append(keys.spans, span{text: "PK"})
+			}
+			if t.SingleColumnUnique(c.SQLName()) {
+				keys.spans = append(keys.spans, span{text: "UNIQUE"})
+			}
+			for _, fk := range t.ForeignKeys {
+				if j := slices.Index(fk.LocalColumns, c.SQLName()); j >= 0 {
+					keys.spans = append(keys.spans, span{text: "FK " + fk.Table + "." + fk.Columns[j], href: href(fk.Table)})
+				}
+			}
+			goField := cell{}
+			if !slices.Contains(ignore, t.SQLName()) {
+				goField = code(c.GoName() + " " + c.GetGoType())
+			}
+			columns.rows = append(columns.rows, []cell{
+				code(c.SQLName()), text(c.Type.SQL()), text(nullable), code(c.DefaultSQL()), keys, goField, text(c.Comment),
+			})
+		}
+
+		constraints := grid{title: "Constraints", header: []string{"Name", "Kind", "Definition"}}
+		if pks := t.PrimaryKeys(); len(pks) > 0 {
+			cols := []string{}
+			for _, c := range pks {
+				cols = append(cols, c.SQLName())
+			}
+			constraints.rows = append(constraints.rows, []cell{code(t.PrimaryKeyName), text("PRIMARY KEY"), code("(" + strings.Join(cols, ", ") + ")")})
+		}
+		for _, uc := range t.UniqueConstraints {
+			constraints.rows = append(constraints.rows, []cell{code(uc.Name), text("UNIQUE"), code("(" + strings.Join(uc.Columns, ", ") + ")")})
+		}
+		for _, cc := range t.CheckConstraints {
+			constraints.rows = append(constraints.rows, []cell{code(cc.Name), text("CHECK"), code("(" + cc.Expr + ")")})
+		}
+
+		indexes := grid{title: "Indexes", header: []string{"Name", "Unique", "Columns", "Where"}}
+		for _, idx := range t.Indexes {
+			unique := "no"
+			if idx.Unique {
+				unique = "yes"
+			}
+			indexes.rows = append(indexes.rows, []cell{code(idx.Name), text(unique), code(strings.Join(idx.Columns, ", ")), code(idx.Where)})
+		}
+
+		references := grid{title: "References", header: []string{"Name", "Columns", "References", "On delete", "On update"}}
+		for _, fk := range t.ForeignKeys {
+			references.rows = append(references.rows, []cell{
+				code(fk.Name), code(strings.Join(fk.LocalColumns, ", ")), link(fk.Table, fk.Columns),
+				text(fk.OnDelete.String()), text(fk.OnUpdate.String()),
+			})
+		}
+
+		referencedBy := grid{title: "Referenced by", header: []string{"Name", "Table", "Columns", "On delete", "On update"}}
+		for _, child := range tables {
+			for _, fk := range child.ForeignKeys {
+				if strings.EqualFold(fk.Table, t.SQLName()) {
+					referencedBy.rows = append(referencedBy.rows, []cell{
+						code(fk.Name), link(child.SQLName(), fk.LocalColumns), code(strings.Join(fk.Columns, ", ")),
+						text(fk.OnDelete.String()), text(fk.OnUpdate.String()),
+					})
+				}
+			}
+		}
+
+		e.grids = []grid{columns, constraints, indexes, references, referencedBy}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// text returns a cell of plain text, or an empty cell if s is "".
+func text(s string) cell {
+	if s == "" {
+		return cell{}
+	}
+	return cell{spans: []span{{text: s}}}
+}
+
+// code returns a cell of code, or an empty cell if s is "".
+func code(s string) cell {
+	if s == "" {
+		return cell{}
+	}
+	return cell{spans: []span{{text: s, code: true}}}
+}
+
+// summary returns the first line of the table's comment after prefix, or "" if it has none.
+func summary(t *parser.Table, prefix string) string {
+	if t.Comment == "" {
+		return ""
+	}
+	line, _, _ := strings.Cut(t.Comment, "\n")
+	return prefix + line
+}
+
+// markdownCell returns c as Markdown, escaped for a table cell if inTable is true.
+func markdownCell(c cell, inTable bool) string {
+	parts := []string{}
+	for _, s := range c.spans {
+		text := s.text
+		switch {
+		case s.code:
+			if strings.Contains(text, "`") {
+				text = "`` " + text + " ``"
+			} else {
+				text = "`" + text + "`"
+			}
+		default:
+			text = markdownText(text)
+		}
+		if s.href != "" {
+			text = "[" + text + "](#" + s.href + ")"
+		}
+		parts = append(parts, text)
+	}
+	sep := ""
+	if c.sep {
+		sep = ", "
+	}
+	s := strings.Join(parts, sep)
+	if inTable {
+		s = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+	}
+	return s
+}
+
+// markdownText returns s with the characters Markdown would format escaped.
+func markdownText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;").Replace(s)
+}
+
+// htmlCell returns c as escaped HTML.
+func htmlCell(c cell) string {
+	parts := []string{}
+	for _, s := range c.spans {
+		text := strings.ReplaceAll(html.EscapeString(s.text), "\n", "<br>")
+		if s.code {
+			text = "<code>" + text + "</code>"
+		}
+		if s.href != "" {
+			text = "<a href=\"#" + s.href + "\">" + text + "</a>"
+		}
+		parts = append(parts, text)
+	}
+	sep := ""
+	if c.sep {
+		sep = ", "
+	}
+	return strings.Join(parts, sep)
+}
+
+// anchor returns the ID GitHub gives a heading of name: lower case, without punctuation other than
+// - and _, and with spaces replaced by -.
+func anchor(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-', r == '_', unicode.IsLetter(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
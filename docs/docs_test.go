@@ -0,0 +1,118 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+const schema = `
+CREATE TABLE users ( -- People who can sign in.
+	id INTEGER PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE -- Their login, e.g. "a|b@example.com".
+);
+CREATE TABLE orders ( -- Orders placed by users.
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	status TEXT NOT NULL DEFAULT 'new',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT orders_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+	CONSTRAINT orders_status CHECK (status IN ('new', 'paid'))
+);
+CREATE INDEX orders_open ON orders (user_id) WHERE status = 'new';`
+
+func parse(t *testing.T) []*parser.Table {
+	tables, err := parser.Parse(schema)
+	require.NoError(t, err)
+	return tables
+}
+
+func TestMarkdown(t *testing.T) {
+	assert.Equal(t, "# Data dictionary\n"+`
+- [users](#users) - People who can sign in.
+- [orders](#orders) - Orders placed by users.
+
+## users
+
+People who can sign in.
+
+Go type: `+"`User`"+`
+
+**Columns**
+
+| Column | Type | Nullable | Default | Keys | Go field | Comment |
+| --- | --- | --- | --- | --- | --- | --- |
+| `+"`id`"+` | INTEGER | yes |  | PK | `+"`ID sql.NullInt64`"+` |  |
+| `+"`email`"+` | TEXT | no |  | UNIQUE | `+"`Email string`"+` | Their login, e.g. "a\|b@example.com". |
+
+**Constraints**
+
+| Name | Kind | Definition |
+| --- | --- | --- |
+|  | PRIMARY KEY | `+"`(id)`"+` |
+|  | UNIQUE | `+"`(email)`"+` |
+
+**Referenced by**
+
+| Name | Table | Columns | On delete | On update |
+| --- | --- | --- | --- | --- |
+| `+"`orders_user`"+` | [orders](#orders) (user\_id) | `+"`id`"+` | CASCADE | NO ACTION |
+
+## orders
+
+Orders placed by users.
+
+**Columns**
+
+| Column | Type | Nullable | Default | Keys | Go field | Comment |
+| --- | --- | --- | --- | --- | --- | --- |
+| `+"`id`"+` | INTEGER | yes |  | PK |  |  |
+| `+"`user_id`"+` | INTEGER | no |  | [FK users.id](#users) |  |  |
+| `+"`status`"+` | TEXT | no | `+"`'new'`"+` |  |  |  |
+| `+"`created_at`"+` | DATETIME | no | `+"`CURRENT_TIMESTAMP`"+` |  |  |  |
+
+**Constraints**
+
+| Name | Kind | Definition |
+| --- | --- | --- |
+|  | PRIMARY KEY | `+"`(id)`"+` |
+| `+"`orders_status`"+` | CHECK | `+"`(status IN ( 'new' , 'paid' ))`"+` |
+
+**Indexes**
+
+| Name | Unique | Columns | Where |
+| --- | --- | --- | --- |
+| `+"`orders_open`"+` | no | `+"`user_id`"+` | `+"`status = 'new'`"+` |
+
+**References**
+
+| Name | Columns | References | On delete | On update |
+| --- | --- | --- | --- | --- |
+| `+"`orders_user`"+` | `+"`user_id`"+` | [users](#users) (id) | CASCADE | NO ACTION |
+`, Markdown(parse(t), []string{"orders"}))
+}
+
+func TestHTML(t *testing.T) {
+	got := HTML(parse(t), nil)
+	assert.Contains(t, got, `<li><a href="#orders">orders</a> - Orders placed by users.</li>`)
+	assert.Contains(t, got, "<section id=\"users\">\n<h2>users</h2>\n<p>People who can sign in.</p>\n<p>Go type: <code>User</code></p>\n")
+	assert.Contains(t, got, `<td>Their login, e.g. &#34;a|b@example.com&#34;.</td>`, "comments are escaped")
+	assert.Contains(t, got, `<td><a href="#users">FK users.id</a></td>`, "outbound foreign keys link to their table")
+	assert.Contains(t, got, `<td><a href="#orders">orders</a> (user_id)</td>`, "inbound foreign keys link to their table")
+	assert.Contains(t, got, `<td><code>orders_status</code></td><td>CHECK</td><td><code>(status IN ( &#39;new&#39; , &#39;paid&#39; ))</code></td>`)
+}
+
+func TestAnchor(t *testing.T) {
+	tests := map[string]string{
+		"users":       "users",
+		"Order_Items": "order_items",
+		"my table":    "my-table",
+		"a.b$c":       "abc",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, anchor(name), name)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// Dump is the document written by "squirrel dump": every table parsed from a schema, including
+// those in ignore_tables, with its columns, constraints, and indexes.
+type Dump struct {
+	Tables []*parser.Table `json:"tables" yaml:"tables"`
+}
+
+// WriteDump writes the tables to w as JSON or YAML, depending on format.
+func WriteDump(w io.Writer, tables []*parser.Table, format string) error {
+	dump := Dump{Tables: tables}
+	if dump.Tables == nil {
+		dump.Tables = []*parser.Table{}
+	}
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(dump)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(dump); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("dump: format must be json or yaml, not %q", format)
+	}
+}
+
+// dumpCommand runs "squirrel dump [-format json|yaml] [-config path] [schema.sql]", which prints
+// the parsed schema, read from schema.sql if it is given or else from the config's schema.
+func dumpCommand(args []string) error {
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+	format := flags.String("format", "json", "Output format: json or yaml")
+	configPath := flags.String("config", "squirrel.yaml", "Path to the YAML config file, if it exists, whose acronyms are used, and whose schema is used if schema.sql is not given")
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s dump [-format json|yaml] [-config path] [schema.sql]\n\n", os.Args[0])
+		flags.PrintDefaults()
+		fmt.Println("")
+		fmt.Println("Prints every table parsed from schema.sql, or from the config's schema if it is not given,")
+		fmt.Println("with its Go and SQL names, columns, constraints, and indexes.")
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	tables, _, err := loadSchema(*configPath, flags.Arg(0)) // every table, even those ignored
+	if err != nil {
+		return err
+	}
+	return WriteDump(os.Stdout, tables, *format)
+}
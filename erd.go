@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/erd"
+)
+
+// erdCommand runs "squirrel erd [-format mermaid|dot] [-tables a,b | -root table -hops n]
+// [-config path] [schema.sql]", which prints an entity-relationship diagram of the schema, read
+// from schema.sql if it is given or else from the config's schema.
+func erdCommand(args []string) error {
+	flags := flag.NewFlagSet("erd", flag.ExitOnError)
+	format := flags.String("format", "mermaid", "Output format: mermaid or dot")
+	tableList := flags.String("tables", "", "Comma-separated tables to draw, instead of all of them")
+	root := flags.String("root", "", "Table to draw with those within -hops foreign keys of it, instead of all of them")
+	hops := flags.Int("hops", 1, "Foreign keys to follow from -root, in either direction")
+	configPath := flags.String("config", "squirrel.yaml", "Path to the YAML config file, whose schema is used if schema.sql is not given")
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s erd [-format mermaid|dot] [-tables a,b | -root table -hops n] [-config path] [schema.sql]\n\n", os.Args[0])
+		flags.PrintDefaults()
+		fmt.Println("")
+		fmt.Println("Prints a Mermaid erDiagram, or a Graphviz DOT graph, of the tables in schema.sql, or in the")
+		fmt.Println("config's schema if it is not given, with their column types, keys, and foreign keys.")
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 || (*tableList != "" && *root != "") {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	tables, _, err := loadSchema(*configPath, flags.Arg(0)) // ignore_tables only affects generated Go
+	if err != nil {
+		return err
+	}
+	switch {
+	case *tableList != "":
+		tables, err = erd.Select(tables, strings.Split(*tableList, ","))
+	case *root != "":
+		tables, err = erd.Neighbors(tables, *root, *hops)
+	}
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "mermaid":
+		fmt.Print(erd.Mermaid(tables))
+	case "dot":
+		fmt.Print(erd.DOT(tables))
+	default:
+		return fmt.Errorf("erd: format must be mermaid or dot, not %q", *format)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+// Package erd draws entity-relationship diagrams of parsed tables and their foreign keys, as
+// Mermaid erDiagrams or Graphviz DOT graphs.
+package erd
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// Select returns the tables named in names, in the order of tables, or an error if one of names is
+// not a table.
+func Select(tables []*parser.Table, names []string) ([]*parser.Table, error) {
+	for _, name := range names {
+		if parser.FindTable(tables, name) == nil {
+			return nil, fmt.Errorf("erd: no table named %q", name)
+		}
+	}
+	selected := []*parser.Table{}
+	for _, t := range tables {
+		if slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, t.SQLName()) }) {
+			selected = append(selected, t)
+		}
+	}
+	return selected, nil
+}
+
+// Neighbors returns the tables at most hops foreign keys away from root, in either direction, in
+// the order of tables, or an error if root is not a table.
+func Neighbors(tables []*parser.Table, root string, hops int) ([]*parser.Table, error) {
+	start := parser.FindTable(tables, root)
+	if start == nil {
+		return nil, fmt.Errorf("erd: no table named %q", root)
+	}
+	near := map[*parser.Table]bool{start: true}
+	frontier := []*parser.Table{start}
+	for range hops {
+		next := []*parser.Table{}
+		for _, t := range tables {
+			for _, fk := range t.ForeignKeys {
+				parent := parser.FindTable(tables, fk.Table)
+				if parent == nil {
+					continue
+				}
+				for _, pair := range [][2]*parser.Table{{t, parent}, {parent, t}} {
+					if slices.Contains(frontier, pair[0]) && !near[pair[1]] {
+						near[pair[1]] = true
+						next = append(next, pair[1])
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+	selected := []*parser.Table{}
+	for _, t := range tables {
+		if near[t] {
+			selected = append(selected, t)
+		}
+	}
+	return selected, nil
+}
+
+// Mermaid returns a Mermaid erDiagram of the tables: each with its columns' SQL types, PK, UK,
+// and FK markers, and comments, and a relationship per foreign key between two of the tables (see
+// relationship).
+func Mermaid(tables []*parser.Table) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, t := range tables {
+		fmt.Fprintf(&b, "\t%s {\n", mermaidName(t.SQLName()))
+		for i := range t.Columns {
+			c := &t.Columns[i]
+			fmt.Fprintf(&b, "\t\t%s %s", c.Type.SQL(), mermaidName(c.SQLName()))
+			if keys := keys(t, c); len(keys) > 0 {
+				b.WriteString(" " + strings.Join(keys, ", "))
+			}
+			if c.Comment != "" {
+				b.WriteString(" " + mermaidString(c.Comment))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\t}\n")
+	}
+	for _, rel := range relationships(tables) {
+		parent, child, line := "||", "o{", ".."
+		if rel.optional {
+			parent = "|o"
+		}
+		if rel.unique {
+			child = "o|"
+		}
+		if rel.identifying {
+			line = "--"
+		}
+		fmt.Fprintf(&b, "\t%s %s%s%s %s : %s\n", mermaidName(rel.parent.SQLName()), parent, line, child,
+			mermaidName(rel.child.SQLName()), mermaidString(strings.Join(rel.fk.LocalColumns, ", ")))
+	}
+	return b.String()
+}
+
+// DOT returns a Graphviz digraph of the tables: each as an HTML-like table of its columns' names,
+// SQL types, and PK, UK, and FK markers, and an edge per foreign key between two of the tables
+// from the child's columns to the parent's, with crow's foot arrows for its cardinality (see
+// relationship).
+func DOT(tables []*parser.Table) string {
+	var b strings.Builder
+	b.WriteString("digraph schema {\n")
+	b.WriteString("\tgraph [rankdir=LR];\n")
+	b.WriteString("\tnode [shape=plaintext];\n")
+	b.WriteString("\tedge [dir=both];\n")
+	for _, t := range tables {
+		fmt.Fprintf(&b, "\t%s [label=<<TABLE BORDER=\"0\" CELLBORDER=\"1\" CELLSPACING=\"0\">\n", dotID(t.SQLName()))
+		fmt.Fprintf(&b, "\t\t<TR><TD COLSPAN=\"3\" BGCOLOR=\"lightgrey\"><B>%s</B></TD></TR>\n", html.EscapeString(t.SQLName()))
+		for i := range t.Columns {
+			c := &t.Columns[i]
+			fmt.Fprintf(&b, "\t\t<TR><TD PORT=%q ALIGN=\"LEFT\">%s</TD><TD ALIGN=\"LEFT\">%s</TD><TD>%s</TD></TR>\n",
+				html.EscapeString(c.SQLName()), html.EscapeString(c.SQLName()), c.Type.SQL(), strings.Join(keys(t, c), ", "))
+		}
+		b.WriteString("\t</TABLE>>];\n")
+	}
+	for _, rel := range relationships(tables) {
+		head, tail, style := "teetee", "crowodot", "dashed"
+		if rel.optional {
+			head = "teeodot"
+		}
+		if rel.unique {
+			tail = "teeodot"
+		}
+		if rel.identifying {
+			style = "solid"
+		}
+		fmt.Fprintf(&b, "\t%s:%s -> %s:%s [arrowhead=%s, arrowtail=%s, style=%s, label=%s];\n",
+			dotID(rel.child.SQLName()), dotID(rel.fk.LocalColumns[0]), dotID(rel.parent.SQLName()), dotID(rel.fk.Columns[0]),
+			head, tail, style, dotID(strings.Join(rel.fk.LocalColumns, ", ")))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// relationship is a foreign key from child to parent, drawn with crow's foot notation: the parent
+// end is exactly one, or zero or one if optional, and the child end is zero or many, or zero or
+// one if unique. An identifying relationship's columns are part of the child's primary key, and
+// it is drawn with a solid line rather than a dashed one.
+type relationship struct {
+	child, parent *parser.Table
+	fk            *parser.ForeignKey
+	optional      bool // optional is true if any of the foreign key's columns are nullable, outside the primary key.
+	unique        bool // unique is true if the foreign key's columns are unique in child.
+	identifying   bool // identifying is true if the foreign key's columns are in child's primary key.
+}
+
+// relationships returns a relationship for each foreign key between two of tables, in order.
+func relationships(tables []*parser.Table) []relationship {
+	rels := []relationship{}
+	for _, t := range tables {
+		for _, fk := range t.ForeignKeys {
+			parent := parser.FindTable(tables, fk.Table)
+			if parent == nil {
+				continue
+			}
+			rel := relationship{child: t, parent: parent, fk: fk, identifying: true}
+			for _, name := range fk.LocalColumns {
+				c := t.Column(name)
+				if c == nil {
+					continue
+				}
+				rel.optional = rel.optional || (c.Nullable && !c.PrimaryKey && !c.CompositePrimaryKey)
+				rel.identifying = rel.identifying && (c.PrimaryKey || c.CompositePrimaryKey)
+			}
+			for _, key := range uniqueKeys(t) {
+				if len(key) > 0 && !slices.ContainsFunc(key, func(col string) bool { return !slices.Contains(fk.LocalColumns, col) }) {
+					rel.unique = true // a unique key within the foreign key's columns makes them unique too
+				}
+			}
+			rels = append(rels, rel)
+		}
+	}
+	return rels
+}
+
+// uniqueKeys returns the sets of columns that are unique in t: its primary key, UNIQUE
+// constraints, and unique indexes of columns that cover every row.
+func uniqueKeys(t *parser.Table) [][]string {
+	keys := [][]string{}
+	pk := []string{}
+	for _, c := range t.PrimaryKeys() {
+		pk = append(pk, c.SQLName())
+	}
+	keys = append(keys, pk)
+	for _, uc := range t.UniqueConstraints {
+		keys = append(keys, uc.Columns)
+	}
+	for _, idx := range t.Indexes {
+		if idx.Unique && !idx.Partial() && !slices.ContainsFunc(idx.Columns, func(col string) bool { return t.Column(col) == nil }) {
+			keys = append(keys, idx.Columns)
+		}
+	}
+	return keys
+}
+
+// keys returns the markers for column c of t: PK if it is in the primary key, UK if it is unique
+// by itself, and FK if it is in a foreign key.
+func keys(t *parser.Table, c *parser.Column) []string {
+	markers := []string{}
+	if c.PrimaryKey || c.CompositePrimaryKey {
+		markers = append(markers, "PK")
+	}
+	if !c.PrimaryKey && slices.ContainsFunc(uniqueKeys(t), func(key []string) bool { return len(key) == 1 && key[0] == c.SQLName() }) {
+		markers = append(markers, "UK")
+	}
+	if slices.ContainsFunc(t.ForeignKeys, func(fk *parser.ForeignKey) bool { return slices.Contains(fk.LocalColumns, c.SQLName()) }) {
+		markers = append(markers, "FK")
+	}
+	return markers
+}
+
+// mermaidWord matches the names Mermaid allows unquoted.
+var mermaidWord = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// mermaidName returns name, double-quoted unless Mermaid allows it bare.
+func mermaidName(name string) string {
+	if mermaidWord.MatchString(name) {
+		return name
+	}
+	return mermaidString(name)
+}
+
+// mermaidString returns s as a double-quoted Mermaid string, which cannot hold double quotes or
+// line breaks, so they become single quotes and spaces.
+func mermaidString(s string) string {
+	return `"` + strings.NewReplacer(`"`, "'", "\r\n", " ", "\n", " ").Replace(s) + `"`
+}
+
+// dotID returns name as a double-quoted DOT ID.
+func dotID(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `\"`) + `"`
+}
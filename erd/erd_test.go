@@ -0,0 +1,147 @@
+package erd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+const schema = `
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE -- The "login" address.
+);
+CREATE TABLE profiles (
+	user_id INTEGER PRIMARY KEY REFERENCES users (id),
+	bio TEXT
+);
+CREATE TABLE posts (
+	id INTEGER PRIMARY KEY,
+	author_id INTEGER REFERENCES users (id),
+	title TEXT NOT NULL
+);
+CREATE TABLE tags (
+	post_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	PRIMARY KEY (post_id, name),
+	FOREIGN KEY (post_id) REFERENCES posts (id)
+);
+CREATE TABLE tag_notes (
+	post_id INTEGER NOT NULL,
+	tag TEXT NOT NULL,
+	note TEXT,
+	FOREIGN KEY (post_id, tag) REFERENCES tags (post_id, name)
+);
+CREATE UNIQUE INDEX tag_notes_key ON tag_notes (post_id, tag);
+CREATE TABLE settings (
+	key TEXT PRIMARY KEY,
+	value TEXT
+);`
+
+func parse(t *testing.T) []*parser.Table {
+	tables, err := parser.Parse(schema)
+	require.NoError(t, err)
+	return tables
+}
+
+func names(tables []*parser.Table) []string {
+	s := []string{}
+	for _, t := range tables {
+		s = append(s, t.SQLName())
+	}
+	return s
+}
+
+func TestMermaid(t *testing.T) {
+	assert.Equal(t, `erDiagram
+	users {
+		INTEGER id PK
+		TEXT email UK "The 'login' address."
+	}
+	profiles {
+		INTEGER user_id PK, FK
+		TEXT bio
+	}
+	posts {
+		INTEGER id PK
+		INTEGER author_id FK
+		TEXT title
+	}
+	tags {
+		INTEGER post_id PK, FK
+		TEXT name PK
+	}
+	tag_notes {
+		INTEGER post_id FK
+		TEXT tag FK
+		TEXT note
+	}
+	settings {
+		TEXT key PK
+		TEXT value
+	}
+	users ||--o| profiles : "user_id"
+	users |o..o{ posts : "author_id"
+	posts ||--o{ tags : "post_id"
+	tags ||..o| tag_notes : "post_id, tag"
+`, Mermaid(parse(t)))
+}
+
+func TestDOT(t *testing.T) {
+	tables, err := Select(parse(t), []string{"tag_notes", "TAGS"})
+	require.NoError(t, err)
+	assert.Equal(t, `digraph schema {
+	graph [rankdir=LR];
+	node [shape=plaintext];
+	edge [dir=both];
+	"tags" [label=<<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0">
+		<TR><TD COLSPAN="3" BGCOLOR="lightgrey"><B>tags</B></TD></TR>
+		<TR><TD PORT="post_id" ALIGN="LEFT">post_id</TD><TD ALIGN="LEFT">INTEGER</TD><TD>PK, FK</TD></TR>
+		<TR><TD PORT="name" ALIGN="LEFT">name</TD><TD ALIGN="LEFT">TEXT</TD><TD>PK</TD></TR>
+	</TABLE>>];
+	"tag_notes" [label=<<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0">
+		<TR><TD COLSPAN="3" BGCOLOR="lightgrey"><B>tag_notes</B></TD></TR>
+		<TR><TD PORT="post_id" ALIGN="LEFT">post_id</TD><TD ALIGN="LEFT">INTEGER</TD><TD>FK</TD></TR>
+		<TR><TD PORT="tag" ALIGN="LEFT">tag</TD><TD ALIGN="LEFT">TEXT</TD><TD>FK</TD></TR>
+		<TR><TD PORT="note" ALIGN="LEFT">note</TD><TD ALIGN="LEFT">TEXT</TD><TD></TD></TR>
+	</TABLE>>];
+	"tag_notes":"post_id" -> "tags":"post_id" [arrowhead=teetee, arrowtail=teeodot, style=dashed, label="post_id, tag"];
+}
+`, DOT(tables))
+}
+
+func TestSelect(t *testing.T) {
+	tables, err := Select(parse(t), []string{"posts", "users"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "posts"}, names(tables))
+	assert.Contains(t, Mermaid(tables), "users |o..o{ posts", "a foreign key between selected tables is drawn")
+	assert.NotContains(t, Mermaid(tables), "profiles", "a foreign key to an unselected table is not")
+
+	_, err = Select(parse(t), []string{"posts", "comments"})
+	assert.EqualError(t, err, `erd: no table named "comments"`)
+}
+
+func TestNeighbors(t *testing.T) {
+	tests := []struct {
+		root string
+		hops int
+		want []string
+	}{
+		{"posts", 0, []string{"posts"}},
+		{"posts", 1, []string{"users", "posts", "tags"}},
+		{"posts", 2, []string{"users", "profiles", "posts", "tags", "tag_notes"}},
+		{"tag_notes", 2, []string{"posts", "tags", "tag_notes"}},
+		{"settings", 3, []string{"settings"}},
+	}
+	for _, tt := range tests {
+		got, err := Neighbors(parse(t), tt.root, tt.hops)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, names(got), "%s within %d hops", tt.root, tt.hops)
+	}
+
+	_, err := Neighbors(parse(t), "comments", 1)
+	assert.EqualError(t, err, `erd: no table named "comments"`)
+}
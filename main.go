@@ -1,18 +1,42 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
+	"slices"
 
 	"github.com/carlmjohnson/versioninfo"
 
 	"github.com/joshsziegler/squirrel/config"
 	"github.com/joshsziegler/squirrel/name"
 	"github.com/joshsziegler/squirrel/parser"
+	"github.com/joshsziegler/squirrel/queries"
 	"github.com/joshsziegler/squirrel/templates"
 )
 
+// ParseQueries reads the query files in the config and resolves each query against tables.
+func ParseQueries(cfg *config.Config, tables []*parser.Table) ([]*queries.Query, error) {
+	if len(cfg.Queries) == 0 {
+		return nil, nil
+	}
+	qs, err := queries.ParseFiles(cfg.Queries)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range qs {
+		if err := queries.Resolve(q, tables); err != nil {
+			return nil, err
+		}
+		if q.Result != nil && q.Result.Table != nil && slices.Contains(cfg.IgnoreTables, q.Result.Table.SQLName()) {
+			return nil, fmt.Errorf("queries: %s: returns rows of %s, which is in ignore_tables", q, q.Result.Table.SQLName())
+		}
+	}
+	return qs, nil
+}
+
 // readFile from disk and return its content as a string.
 func readFile(path string) (string, error) {
 	fileBytes, err := os.ReadFile(path)
@@ -35,24 +59,72 @@ func ParseFile(path string) ([]*parser.Table, error) {
 	return tables, nil
 }
 
-// GenerateGoFromSQL by reading the schema file from disk, parsing it, and then writing it to goPath.
-// Any table in ignoreTables will be parsed, but not included in the generated Go.
-func GenerateGoFromSQL(schemaPath, goPath, pkgName string, ignoreTables []string, ctxOnly bool) error {
-	tables, err := ParseFile(schemaPath)
+// loadSchema parses the schema for the commands that read one: the file at path, or the config's
+// schema if path is "". The config at configPath may be missing if path is given, but if it exists
+// its acronyms are registered before parsing, and its ignore_tables returned for the caller to
+// leave out.
+func loadSchema(configPath, path string) (tables []*parser.Table, ignore []string, err error) {
+	cfg := config.Default()
+	loaded, err := config.Load(configPath)
+	switch {
+	case err == nil:
+		cfg = *loaded
+	case path == "" || !errors.Is(err, fs.ErrNotExist):
+		return nil, nil, err
+	}
+	if path == "" {
+		if cfg.Schema == "" {
+			return nil, nil, fmt.Errorf("config: 'schema' is required")
+		}
+		path = cfg.Schema
+	}
+	name.RegisterAcronyms(cfg.Acronyms)
+	tables, err = ParseFile(path)
+	return tables, cfg.IgnoreTables, err
+}
+
+// GenerateGoFromSQL by reading the schema file from disk, parsing it, and then writing it to the
+// destination, both given by cfg. Any table in cfg.IgnoreTables will be parsed, but not included in
+// the generated Go. If cfg.Tests is set, a round-trip test is written next to the destination.
+func GenerateGoFromSQL(cfg *config.Config) error {
+	ddl, err := readFile(cfg.Schema)
+	if err != nil {
+		return err
+	}
+	tables, err := parser.Parse(ddl)
+	if err != nil {
+		return err
+	}
+	qs, err := ParseQueries(cfg, tables)
 	if err != nil {
 		return err
 	}
 
 	// Write Go-SQL code to disk
-	f, err := os.OpenFile(goPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	f, err := os.OpenFile(cfg.Dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	templates.Write(f, pkgName, tables, ignoreTables, ctxOnly)
+	templates.Write(f, tables, cfg)
+	templates.WriteQueries(f, qs, cfg)
+	if cfg.Tests {
+		return writeFile(cfg.TestsDest(), func(f *os.File) { templates.RoundTrip(f, ddl, tables, cfg) })
+	}
 	return nil
 }
 
+// writeFile creates or truncates the file at path and writes it with write.
+func writeFile(path string, write func(f *os.File)) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	write(f)
+	return f.Close()
+}
+
 // printVersion prints build/version information.
 func printVersion() {
 	fmt.Printf("Version: %s\n", versioninfo.Version)
@@ -64,10 +136,25 @@ func printVersion() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		commands := map[string]func([]string) error{"diff": diffCommand, "docs": docsCommand, "dump": dumpCommand, "erd": erdCommand}
+		if command := commands[os.Args[1]]; command != nil {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	configPath := flag.String("config", "squirrel.yaml", "Path to the YAML config file")
 	showVersion := flag.Bool("version", false, "Print version information and exit")
 	flag.Usage = func() {
-		fmt.Printf("Usage: %s [-config path]\n\n", os.Args[0])
+		fmt.Printf("Usage: %s [-config path]\n", os.Args[0])
+		fmt.Printf("       %s diff [-goose path] old.sql new.sql\n", os.Args[0])
+		fmt.Printf("       %s docs [-format markdown|html] [-config path] [schema.sql]\n", os.Args[0])
+		fmt.Printf("       %s dump [-format json|yaml] [-config path] [schema.sql]\n", os.Args[0])
+		fmt.Printf("       %s erd [-format mermaid|dot] [-tables a,b | -root table -hops n] [-config path] [schema.sql]\n\n", os.Args[0])
 		flag.PrintDefaults()
 		fmt.Println("")
 		fmt.Println("")
@@ -80,9 +167,27 @@ func main() {
 		fmt.Println("  ignore_tables:          # Tables to parse but exclude from the generated Go")
 		fmt.Println("    - goose_db_version")
 		fmt.Println("  ctx_only: true          # Only emit context-aware DB methods (default: true)")
+		fmt.Println("  runtime: sqlx           # sqlx, or database/sql for *sql.DB without sqlx or merry")
+		fmt.Println("  errors: merry           # merry, stdlib (fmt.Errorf), or hook (a hand-written wrapErr)")
 		fmt.Println("  acronyms:               # SQL word -> Go form kept uppercase and not singularized")
 		fmt.Println("    dns: DNS              # e.g. dns_zones -> DNSZone")
 		fmt.Println("    oauth: OAuth")
+		fmt.Println("  soft_delete: deleted_at # Nullable column marking soft-deleted rows")
+		fmt.Println("  version: version        # NOT NULL integer column for optimistic concurrency")
+		fmt.Println("  queries:                # Annotated SQL query files (or globs) to compile to Go")
+		fmt.Println("    - queries/*.sql")
+		fmt.Println("  stores: false           # Generate a Store interface, DB store, and in-memory fake per table")
+		fmt.Println("  fixtures: false         # Generate New<Table>Fixture builders for tests")
+		fmt.Println("  tests: false            # Write a round-trip test of the generated Go next to dest")
+		fmt.Println("  test_driver: github.com/mattn/go-sqlite3 # or modernc.org/sqlite, for the round-trip test")
+		fmt.Println("  timestamps:             # Columns the DB stamps with the current time")
+		fmt.Println("    created: created_at")
+		fmt.Println("    updated: updated_at")
+		fmt.Println("    deleted: deleted_at")
+		fmt.Println("    format: iso           # iso, unixepoch, or subsec")
+		fmt.Println("  tables:                 # Per-table overrides")
+		fmt.Println("    audit_log:")
+		fmt.Println("      soft_delete: \"\"")
 		fmt.Println("")
 	}
 	flag.Parse()
@@ -107,7 +212,7 @@ func main() {
 	// singularized) when SQL names are converted to Go names during parsing.
 	name.RegisterAcronyms(cfg.Acronyms)
 
-	err = GenerateGoFromSQL(cfg.Schema, cfg.Dest, cfg.Package, cfg.IgnoreTables, cfg.CtxOnly)
+	err = GenerateGoFromSQL(cfg)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1) // Return an error code so the caller knows we failed.
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/joshsziegler/squirrel/name"
 )
@@ -9,19 +10,23 @@ import (
 type Column struct {
 	sqlName             string
 	goName              string
-	Type                Datatype
-	PrimaryKey          bool // True if this column is the one and only primary key (typically defined inline with the column).
-	CompositePrimaryKey bool // True if this column is part of a composite primary key.
-	autoIncrement       bool // AutoIncrement is true if the this column explicitly specified AUTOINCREMENT. Use AutoIncrement()!
-	Nullable            bool
-	Comment             string // Comment at the end of this column definition if provided.
+	Type                Datatype `json:"type" yaml:"type"`
+	PrimaryKey          bool     `json:"primary_key" yaml:"primary_key"`                     // True if this column is the one and only primary key (typically defined inline with the column).
+	CompositePrimaryKey bool     `json:"composite_primary_key" yaml:"composite_primary_key"` // True if this column is part of a composite primary key.
+	autoIncrement       bool     // AutoIncrement is true if the this column explicitly specified AUTOINCREMENT. Use AutoIncrement()!
+	Nullable            bool     `json:"nullable" yaml:"nullable"`
+	Comment             string   `json:"comment" yaml:"comment"` // Comment at the end of this column definition if provided.
 
-	// Default which can be a constant or expression and is type-dependent.
+	// Default which can be a constant or expression and is type-dependent. They are serialized as
+	// default_string, default_int, default_float, and default_bool, left out if not Valid.
 	// TODO: Handle expressions
-	DefaultString sql.NullString
-	DefaultInt    sql.NullInt64
-	DefaultFloat  sql.NullFloat64
-	DefaultBool   sql.NullBool
+	DefaultString sql.NullString  `json:"-" yaml:"-"`
+	DefaultInt    sql.NullInt64   `json:"-" yaml:"-"`
+	DefaultFloat  sql.NullFloat64 `json:"-" yaml:"-"`
+	DefaultBool   sql.NullBool    `json:"-" yaml:"-"`
+	// DefaultExpr is the DEFAULT as SQL when it is not a constant, such as CURRENT_TIMESTAMP or an
+	// expression like datetime ( 'now' ) (the outer parentheses are dropped).
+	DefaultExpr string `json:"default_expr" yaml:"default_expr"`
 }
 
 func (t *Column) GoName() string  { return t.goName }
@@ -36,21 +41,39 @@ func (t *Column) GetGoType() string {
 	return t.Type.ToGo(t.Nullable)
 }
 
-// DBGenerated returns true if the database creates this column, such as row IDs, or
-// created/updates_at metadata.
+// DBGenerated returns true if the database creates this column, such as row IDs, or timestamps
+// that DEFAULT to the current time (see DefaultsToNow).
 func (c *Column) DBGenerated() bool {
 	switch {
 	case c.PrimaryKey:
 		return true
-	case c.sqlName == "created_at":
+	case c.DefaultsToNow():
 		return true
-	case c.sqlName == "updated_at":
+	default:
+		return false
+	}
+}
+
+// DefaultsToNow returns true if the column's DEFAULT is the current time, either one of SQLite's
+// CURRENT_TIMESTAMP, CURRENT_DATE, or CURRENT_TIME keywords or an expression using 'now' or
+// unixepoch() (e.g. DEFAULT (strftime('%s', 'now'))).
+func (c *Column) DefaultsToNow() bool {
+	expr := strings.ToUpper(c.DefaultExpr)
+	switch {
+	case expr == "CURRENT_TIMESTAMP", expr == "CURRENT_DATE", expr == "CURRENT_TIME":
+		return true
+	case strings.Contains(expr, "'NOW'"), strings.Contains(expr, "UNIXEPOCH"):
 		return true
 	default:
 		return false
 	}
 }
 
+// HasDefault returns true if the column has a constant DEFAULT value.
+func (c *Column) HasDefault() bool {
+	return c.DefaultString.Valid || c.DefaultInt.Valid || c.DefaultFloat.Valid || c.DefaultBool.Valid
+}
+
 // AutoIncrement is true if the column explicitly defined or SQLite's deems it to be a row_id alias.
 //
 // My understanding of the docs is that any column that is both a PK and type INTEGER will be auto-
@@ -51,6 +51,24 @@ func (t Datatype) ToGo(nullable bool) string {
 	}
 }
 
+// SQL returns the SQLite type name for this domain type, as Format prints it (e.g. INTEGER).
+func (t Datatype) SQL() string {
+	switch t {
+	case INT:
+		return "INTEGER"
+	case FLOAT:
+		return "REAL"
+	case TEXT:
+		return "TEXT"
+	case BOOL:
+		return "BOOLEAN"
+	case DATETIME:
+		return "DATETIME"
+	default:
+		return "BLOB"
+	}
+}
+
 // DatatypeFromSQL to internal domain type.
 //
 // SQLite's STRICT data types (i.e. INT, INTEGER, REAL, TEXT, BLOB, ANY).
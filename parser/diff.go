@@ -0,0 +1,512 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// renameAnnotation in the comment of a table or column names what it was called in the old schema,
+// so Diff reports a rename rather than a drop and an add (e.g. "email TEXT -- squirrel:renamed_from mail").
+const renameAnnotation = "squirrel:renamed_from"
+
+// Change is how a table, column, constraint, or index differs between two schemas.
+type Change int
+
+const (
+	Added   Change = iota + 1 // Added is only in the new schema.
+	Removed                   // Removed is only in the old schema.
+	Changed                   // Changed is in both with a different definition.
+	Renamed                   // Renamed is in both under a different name, and may also be changed.
+)
+
+func (c Change) String() string {
+	switch c {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	case Renamed:
+		return "renamed"
+	default:
+		return "unchanged"
+	}
+}
+
+// SchemaDiff is how one schema differs from another (see Diff).
+type SchemaDiff struct {
+	// Tables are the tables that differ: those in the new schema in its order, and then those only in
+	// the old schema in its order.
+	Tables []*TableDiff
+}
+
+// TableDiff is how a table differs. Old is nil if it was Added, and New is nil if it was Removed.
+// A Changed or Renamed table lists what changed, with the old table's names updated to the new
+// ones, so a renamed column is not also reported as changing the constraints that use it.
+type TableDiff struct {
+	Change      Change
+	Old, New    *Table
+	Columns     []ColumnDiff
+	Constraints []ConstraintDiff
+	Indexes     []IndexDiff
+}
+
+// ColumnDiff is how a column differs. Old is nil if it was Added, and New is nil if it was Removed.
+type ColumnDiff struct {
+	Change   Change
+	Old, New *Column
+}
+
+// ConstraintDiff is a table constraint that was Added or Removed, as it is declared after the
+// columns (see Format). Inline constraints other than a single primary key are compared this way
+// too, so moving one between a column and the table is not a change.
+type ConstraintDiff struct {
+	Change Change
+	SQL    string
+}
+
+// IndexDiff is how an index differs. Old is nil if it was Added, and New is nil if it was Removed.
+type IndexDiff struct {
+	Change   Change
+	Old, New *Index
+}
+
+// Diff returns how the tables of schema b differ from those of schema a. Tables, columns, and
+// indexes are matched by name, ignoring case as SQLite does, and constraints by their SQL. A table
+// or column only in b is matched to one only in a if its comment names it with
+// "squirrel:renamed_from <name>", or otherwise if it is the only one with the same definition
+// (the same columns for tables) and their names are related, such as mail and email, which is then
+// reported as Renamed. Comments are not compared.
+func Diff(a, b []*Table) *SchemaDiff {
+	d := &SchemaDiff{}
+	pairs := matchTables(a, b)
+	tableNames := map[string]string{}             // old table -> new name
+	columnNames := map[string]map[string]string{} // old table -> old column -> new name
+	for oldT, newT := range pairs {
+		tableNames[strings.ToLower(oldT.SQLName())] = newT.SQLName()
+		columnNames[strings.ToLower(oldT.SQLName())] = map[string]string{}
+		for oldCol, newCol := range matchColumns(oldT, newT) {
+			columnNames[strings.ToLower(oldT.SQLName())][strings.ToLower(oldCol.SQLName())] = newCol.SQLName()
+		}
+	}
+
+	matched := map[*Table]*Table{} // new table -> old
+	for oldT, newT := range pairs {
+		matched[newT] = oldT
+	}
+	for _, newT := range b {
+		oldT := matched[newT]
+		if oldT == nil {
+			d.Tables = append(d.Tables, &TableDiff{Change: Added, New: newT})
+			continue
+		}
+		td := diffTable(oldT, newT, renameTable(oldT, tableNames, columnNames))
+		if !strings.EqualFold(oldT.SQLName(), newT.SQLName()) {
+			td.Change = Renamed
+		}
+		if td.Change != 0 {
+			d.Tables = append(d.Tables, td)
+		}
+	}
+	for _, oldT := range a {
+		if pairs[oldT] == nil {
+			d.Tables = append(d.Tables, &TableDiff{Change: Removed, Old: oldT})
+		}
+	}
+	return d
+}
+
+// Empty returns true if the schemas do not differ.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Tables) == 0
+}
+
+// Reverse returns the diff from the new schema back to the old one.
+func (d *SchemaDiff) Reverse() *SchemaDiff {
+	r := &SchemaDiff{}
+	for _, td := range d.Tables {
+		rt := &TableDiff{Change: reverse(td.Change), Old: td.New, New: td.Old}
+		for _, cd := range td.Columns {
+			rt.Columns = append(rt.Columns, ColumnDiff{Change: reverse(cd.Change), Old: cd.New, New: cd.Old})
+		}
+		for _, cd := range td.Constraints {
+			rt.Constraints = append(rt.Constraints, ConstraintDiff{Change: reverse(cd.Change), SQL: cd.SQL})
+		}
+		for _, id := range td.Indexes {
+			rt.Indexes = append(rt.Indexes, IndexDiff{Change: reverse(id.Change), Old: id.New, New: id.Old})
+		}
+		r.Tables = append(r.Tables, rt)
+	}
+	return r
+}
+
+// reverse returns the change undoing c.
+func reverse(c Change) Change {
+	switch c {
+	case Added:
+		return Removed
+	case Removed:
+		return Added
+	default:
+		return c
+	}
+}
+
+// String returns a line per changed table, followed by an indented line per change within it.
+func (d *SchemaDiff) String() string {
+	var b strings.Builder
+	for _, td := range d.Tables {
+		switch td.Change {
+		case Added:
+			fmt.Fprintf(&b, "added table %s\n", td.New.SQLName())
+			continue
+		case Removed:
+			fmt.Fprintf(&b, "removed table %s\n", td.Old.SQLName())
+			continue
+		case Renamed:
+			fmt.Fprintf(&b, "renamed table %s to %s\n", td.Old.SQLName(), td.New.SQLName())
+		default:
+			fmt.Fprintf(&b, "changed table %s\n", td.New.SQLName())
+		}
+		for _, cd := range td.Columns {
+			switch cd.Change {
+			case Added:
+				fmt.Fprintf(&b, "\tadded column %s\n", columnSQL(cd.New, td.New.PrimaryKeyName))
+			case Removed:
+				fmt.Fprintf(&b, "\tremoved column %s\n", cd.Old.SQLName())
+			case Renamed:
+				fmt.Fprintf(&b, "\trenamed column %s to %s\n", cd.Old.SQLName(), cd.New.SQLName())
+				if columnDef(cd.Old, td.Old) != columnDef(cd.New, td.New) {
+					fmt.Fprintf(&b, "\tchanged column %s from %s to %s\n", cd.New.SQLName(), columnDef(cd.Old, td.Old), columnDef(cd.New, td.New))
+				}
+			default:
+				fmt.Fprintf(&b, "\tchanged column %s from %s to %s\n", cd.New.SQLName(), columnDef(cd.Old, td.Old), columnDef(cd.New, td.New))
+			}
+		}
+		for _, cd := range td.Constraints {
+			fmt.Fprintf(&b, "\t%s constraint %s\n", cd.Change, cd.SQL)
+		}
+		for _, id := range td.Indexes {
+			switch id.Change {
+			case Added:
+				fmt.Fprintf(&b, "\tadded index %s\n", id.New.Name)
+			case Removed:
+				fmt.Fprintf(&b, "\tremoved index %s\n", id.Old.Name)
+			default:
+				fmt.Fprintf(&b, "\tchanged index %s\n", id.New.Name)
+			}
+		}
+	}
+	return b.String()
+}
+
+// diffTable returns how newT differs from oldT, where renamed is oldT with the new schema's names
+// (see renameTable), or a TableDiff without a Change if it does not.
+func diffTable(oldT, newT, renamed *Table) *TableDiff {
+	td := &TableDiff{Old: oldT, New: newT}
+	for i := range oldT.Columns {
+		oldCol, renamedCol := &oldT.Columns[i], &renamed.Columns[i]
+		newCol := newT.Column(renamedCol.SQLName())
+		switch {
+		case newCol == nil:
+			td.Columns = append(td.Columns, ColumnDiff{Change: Removed, Old: oldCol})
+		case !strings.EqualFold(oldCol.SQLName(), newCol.SQLName()):
+			td.Columns = append(td.Columns, ColumnDiff{Change: Renamed, Old: oldCol, New: newCol})
+		case columnDef(renamedCol, renamed) != columnDef(newCol, newT):
+			td.Columns = append(td.Columns, ColumnDiff{Change: Changed, Old: oldCol, New: newCol})
+		}
+	}
+	for i := range newT.Columns {
+		if renamed.Column(newT.Columns[i].SQLName()) == nil {
+			td.Columns = append(td.Columns, ColumnDiff{Change: Added, New: &newT.Columns[i]})
+		}
+	}
+
+	oldConstraints, newConstraints := renamed.constraintsSQL(), newT.constraintsSQL()
+	for _, sql := range oldConstraints {
+		if !slices.Contains(newConstraints, sql) {
+			td.Constraints = append(td.Constraints, ConstraintDiff{Change: Removed, SQL: sql})
+		}
+	}
+	for _, sql := range newConstraints {
+		if !slices.Contains(oldConstraints, sql) {
+			td.Constraints = append(td.Constraints, ConstraintDiff{Change: Added, SQL: sql})
+		}
+	}
+
+	for i := range oldT.Indexes {
+		newIdx := findIndex(newT, oldT.Indexes[i].Name)
+		switch {
+		case newIdx == nil:
+			td.Indexes = append(td.Indexes, IndexDiff{Change: Removed, Old: &oldT.Indexes[i]})
+		case renamed.IndexSQL(&renamed.Indexes[i]) != newT.IndexSQL(newIdx):
+			td.Indexes = append(td.Indexes, IndexDiff{Change: Changed, Old: &oldT.Indexes[i], New: newIdx})
+		}
+	}
+	for i := range newT.Indexes {
+		if findIndex(oldT, newT.Indexes[i].Name) == nil {
+			td.Indexes = append(td.Indexes, IndexDiff{Change: Added, New: &newT.Indexes[i]})
+		}
+	}
+
+	if len(td.Columns) > 0 || len(td.Constraints) > 0 || len(td.Indexes) > 0 {
+		td.Change = Changed
+	}
+	return td
+}
+
+// findIndex returns the table's index with the given name, ignoring case, or nil if it has none.
+func findIndex(t *Table, name string) *Index {
+	for i := range t.Indexes {
+		if strings.EqualFold(t.Indexes[i].Name, name) {
+			return &t.Indexes[i]
+		}
+	}
+	return nil
+}
+
+// columnDef returns the column's definition in table t without its name, to compare columns.
+func columnDef(c *Column, t *Table) string {
+	def := *c
+	def.SetSQLName("")
+	return strings.TrimPrefix(columnSQL(&def, t.PrimaryKeyName), `"" `)
+}
+
+// renamedFrom returns the name in a comment's "squirrel:renamed_from <name>" annotation, or "".
+func renamedFrom(comment string) string {
+	_, after, ok := strings.Cut(comment, renameAnnotation)
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(after)
+	if len(fields) == 0 {
+		return ""
+	}
+	return removeQuotes(fields[0])
+}
+
+// matchTables returns each table of a that is also in b, mapped to it: by name, then by a
+// squirrel:renamed_from annotation, and then by being the only two with the same columns and
+// related names.
+func matchTables(a, b []*Table) map[*Table]*Table {
+	pairs := map[*Table]*Table{}
+	taken := map[*Table]bool{}
+	pair := func(oldT, newT *Table) {
+		pairs[oldT] = newT
+		taken[newT] = true
+	}
+	for _, newT := range b {
+		if oldT := FindTable(a, newT.SQLName()); oldT != nil {
+			pair(oldT, newT)
+		}
+	}
+	for _, newT := range b {
+		if from := renamedFrom(newT.Comment); !taken[newT] && from != "" {
+			if oldT := FindTable(a, from); oldT != nil && pairs[oldT] == nil {
+				pair(oldT, newT)
+			}
+		}
+	}
+	olds, news := []*Table{}, []*Table{}
+	for _, oldT := range a {
+		if pairs[oldT] == nil {
+			olds = append(olds, oldT)
+		}
+	}
+	for _, newT := range b {
+		if !taken[newT] {
+			news = append(news, newT)
+		}
+	}
+	columns := func(t *Table) string {
+		cols := []string{}
+		for i := range t.Columns {
+			cols = append(cols, strings.ToLower(t.Columns[i].SQLName())+" "+columnDef(&t.Columns[i], t))
+		}
+		return strings.Join(cols, ", ")
+	}
+	for oldT, newT := range uniquePairs(olds, news, columns, columns) {
+		if relatedNames(oldT.SQLName(), newT.SQLName()) {
+			pair(oldT, newT)
+		}
+	}
+	return pairs
+}
+
+// matchColumns returns each column of oldT that is also in newT, mapped to it, the same way
+// matchTables matches tables, except by being the only two with the same definition and related
+// names.
+func matchColumns(oldT, newT *Table) map[*Column]*Column {
+	pairs := map[*Column]*Column{}
+	taken := map[*Column]bool{}
+	pair := func(oldCol, newCol *Column) {
+		pairs[oldCol] = newCol
+		taken[newCol] = true
+	}
+	for i := range newT.Columns {
+		if oldCol := columnFold(oldT, newT.Columns[i].SQLName()); oldCol != nil {
+			pair(oldCol, &newT.Columns[i])
+		}
+	}
+	for i := range newT.Columns {
+		newCol := &newT.Columns[i]
+		if from := renamedFrom(newCol.Comment); !taken[newCol] && from != "" {
+			if oldCol := columnFold(oldT, from); oldCol != nil && pairs[oldCol] == nil {
+				pair(oldCol, newCol)
+			}
+		}
+	}
+	olds, news := []*Column{}, []*Column{}
+	for i := range oldT.Columns {
+		if pairs[&oldT.Columns[i]] == nil {
+			olds = append(olds, &oldT.Columns[i])
+		}
+	}
+	for i := range newT.Columns {
+		if !taken[&newT.Columns[i]] {
+			news = append(news, &newT.Columns[i])
+		}
+	}
+	oldDef := func(c *Column) string { return columnDef(c, oldT) }
+	newDef := func(c *Column) string { return columnDef(c, newT) }
+	for oldCol, newCol := range uniquePairs(olds, news, oldDef, newDef) {
+		if relatedNames(oldCol.SQLName(), newCol.SQLName()) {
+			pair(oldCol, newCol)
+		}
+	}
+	return pairs
+}
+
+// uniquePairs returns each of olds mapped to the one of news with the same key, if they are the
+// only two with that key.
+func uniquePairs[T comparable](olds, news []T, oldKey, newKey func(T) string) map[T]T {
+	byOld, byNew := map[string][]T{}, map[string][]T{}
+	for _, o := range olds {
+		byOld[oldKey(o)] = append(byOld[oldKey(o)], o)
+	}
+	for _, n := range news {
+		byNew[newKey(n)] = append(byNew[newKey(n)], n)
+	}
+	pairs := map[T]T{}
+	for key, os := range byOld {
+		if ns := byNew[key]; len(os) == 1 && len(ns) == 1 {
+			pairs[os[0]] = ns[0]
+		}
+	}
+	return pairs
+}
+
+// relatedNames returns true if one name contains the other or they share a word, ignoring case
+// (e.g. mail and email, or groups_old and groups), so unrelated tables or columns with the same
+// definition are not taken for a rename.
+func relatedNames(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return true
+	}
+	words := strings.Split(a, "_")
+	for _, word := range strings.Split(b, "_") {
+		if word != "" && slices.Contains(words, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnFold returns the table's column with the given SQL name, ignoring case, or nil if it has
+// none.
+func columnFold(t *Table, sqlName string) *Column {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].SQLName(), sqlName) {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// renameTable returns a copy of t with tables and columns renamed to their names in the new
+// schema: tables maps old table names to new, and columns maps old table names to their old
+// column names to new, all lowercased. Names in CHECK constraints and indexed expressions are
+// renamed too, so the copy compares equal to the new table if only names changed.
+func renameTable(t *Table, tables map[string]string, columns map[string]map[string]string) *Table {
+	rename := func(table, column string) string {
+		if n := columns[strings.ToLower(table)][strings.ToLower(column)]; n != "" {
+			return n
+		}
+		return column
+	}
+	renameAll := func(table string, cols []string) []string {
+		renamed := make([]string, len(cols))
+		for i, col := range cols {
+			renamed[i] = rename(table, col)
+		}
+		return renamed
+	}
+	own := columns[strings.ToLower(t.SQLName())]
+
+	r := *t
+	if n := tables[strings.ToLower(t.SQLName())]; n != "" {
+		r.SetSQLName(n)
+	}
+	r.Columns = slices.Clone(t.Columns)
+	for i := range r.Columns {
+		r.Columns[i].SetSQLName(rename(t.SQLName(), r.Columns[i].SQLName()))
+	}
+	r.UniqueConstraints = nil
+	for _, uc := range t.UniqueConstraints {
+		r.UniqueConstraints = append(r.UniqueConstraints, UniqueConstraint{Name: uc.Name, Columns: renameAll(t.SQLName(), uc.Columns)})
+	}
+	r.CheckConstraints = nil
+	for _, cc := range t.CheckConstraints {
+		r.CheckConstraints = append(r.CheckConstraints, CheckConstraint{Name: cc.Name, Expr: renameIdents(cc.Expr, own)})
+	}
+	r.ForeignKeys = nil
+	for _, fk := range t.ForeignKeys {
+		rfk := *fk
+		rfk.LocalColumns = renameAll(t.SQLName(), fk.LocalColumns)
+		rfk.Columns = renameAll(fk.Table, fk.Columns)
+		if n := tables[strings.ToLower(fk.Table)]; n != "" {
+			rfk.Table = n
+		}
+		r.ForeignKeys = append(r.ForeignKeys, &rfk)
+	}
+	r.Indexes = nil
+	for _, idx := range t.Indexes {
+		ridx := idx
+		ridx.Table = r.SQLName()
+		ridx.Columns = make([]string, len(idx.Columns))
+		for i, col := range idx.Columns {
+			if t.Column(col) != nil {
+				ridx.Columns[i] = rename(t.SQLName(), col)
+			} else {
+				ridx.Columns[i] = renameIdents(col, own)
+			}
+		}
+		ridx.Where = renameIdents(idx.Where, own)
+		r.Indexes = append(r.Indexes, ridx)
+	}
+	return &r
+}
+
+// renameIdents returns the SQL expression with each identifier in renames (lowercased) renamed,
+// other than function names, and its tokens separated by spaces as the parser keeps them.
+func renameIdents(expr string, renames map[string]string) string {
+	if len(renames) == 0 || expr == "" {
+		return expr
+	}
+	toks := Lex(expr).All()
+	sql := make([]string, len(toks))
+	for i, tok := range toks {
+		n := renames[strings.ToLower(tok.Value)]
+		if tok.Type == Ident && n != "" && n != tok.Value && (i+1 >= len(toks) || toks[i+1].Value != "(") {
+			if tok.Quote == 0 && quoteName(n) != n {
+				tok.Quote = '"'
+			}
+			tok.Value = n
+		}
+		sql[i] = tok.Source()
+	}
+	return strings.Join(sql, " ")
+}
@@ -32,12 +32,12 @@ func (a OnFkAction) String() string {
 // are stored on the Table rather than on individual Columns. LocalColumns and Columns are paired
 // positionally: LocalColumns[i] in this table references Columns[i] in the foreign Table.
 type ForeignKey struct {
-	Name         string     // Name from a CONSTRAINT <name> prefix (table-level only), or "" if unnamed.
-	Table        string     // Table is the referenced (foreign) table.
-	LocalColumns []string   // LocalColumns are the column(s) in THIS table, in order.
-	Columns      []string   // Columns are the referenced column(s) in Table, paired positionally with LocalColumns.
-	OnUpdate     OnFkAction // OnUpdate action to take (e.g. none, Set Null, Set Default, etc.)
-	OnDelete     OnFkAction // OnDelete action to take (e.g. none, Set Null, Set Default, etc.)
+	Name         string     `json:"name" yaml:"name"`                   // Name from a CONSTRAINT <name> prefix (table-level only), or "" if unnamed.
+	Table        string     `json:"table" yaml:"table"`                 // Table is the referenced (foreign) table.
+	LocalColumns []string   `json:"local_columns" yaml:"local_columns"` // LocalColumns are the column(s) in THIS table, in order.
+	Columns      []string   `json:"columns" yaml:"columns"`             // Columns are the referenced column(s) in Table, paired positionally with LocalColumns.
+	OnUpdate     OnFkAction `json:"on_update" yaml:"on_update"`         // OnUpdate action to take (e.g. none, Set Null, Set Default, etc.)
+	OnDelete     OnFkAction `json:"on_delete" yaml:"on_delete"`         // OnDelete action to take (e.g. none, Set Null, Set Default, etc.)
 }
 
 // Composite returns true if this foreign key spans more than one column.
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format prints tables back to SQLite DDL, each CREATE TABLE followed by its CREATE INDEX
+// statements, separated by blank lines (see Table.SQL). Parsing the result gives back the same
+// tables, though not the statements as written: the model does not keep COLLATE, ASC/DESC,
+// conflict clauses, or comments on their own lines, and prints every table constraint, including
+// those declared inline, after the columns.
+func Format(tables []*Table) string {
+	stmts := make([]string, 0, len(tables))
+	for _, t := range tables {
+		stmts = append(stmts, t.SQL())
+	}
+	return strings.Join(stmts, "\n")
+}
+
+// SQL returns the table's CREATE TABLE statement and then its CREATE INDEX statements, each
+// ending with a semicolon and separated by a blank line. Columns and table constraints go one per line, with
+// comments at the end of their line.
+func (t *Table) SQL() string {
+	s := t.CreateSQL() + ";\n"
+	for i := range t.Indexes {
+		s += "\n" + t.IndexSQL(&t.Indexes[i]) + ";\n"
+	}
+	return s
+}
+
+// CreateSQL returns the table's CREATE TABLE statement, without a closing semicolon.
+func (t *Table) CreateSQL() string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if t.Temp {
+		b.WriteString("TEMP ")
+	}
+	b.WriteString("TABLE ")
+	if t.IfNotExists {
+		b.WriteString("IF NOT EXISTS ")
+	}
+	b.WriteString(qualifiedName(t.SchemaName, t.SQLName()) + " (")
+	writeComment(&b, t.Comment)
+	b.WriteString("\n")
+
+	lines := []string{}
+	comments := []string{}
+	for _, c := range t.Columns {
+		lines = append(lines, columnSQL(&c, t.PrimaryKeyName))
+		comments = append(comments, c.Comment)
+	}
+	lines = append(lines, t.constraintsSQL()...)
+	for i, line := range lines {
+		b.WriteString("\t" + line)
+		if i < len(lines)-1 {
+			b.WriteString(",")
+		}
+		if i < len(comments) {
+			writeComment(&b, comments[i])
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(")")
+	if t.Strict {
+		b.WriteString(" STRICT")
+	}
+	return b.String()
+}
+
+// constraintsSQL returns the table's constraints other than a single primary key, each as it is
+// declared after the columns: a composite primary key, then its UNIQUE, CHECK, and FOREIGN KEY
+// constraints in order.
+func (t *Table) constraintsSQL() []string {
+	lines := []string{}
+	if pks := t.PrimaryKeys(); len(pks) > 1 {
+		names := []string{}
+		for _, pk := range pks {
+			names = append(names, pk.SQLName())
+		}
+		lines = append(lines, constraintName(t.PrimaryKeyName)+"PRIMARY KEY ("+quoteNames(names)+")")
+	}
+	for _, uc := range t.UniqueConstraints {
+		lines = append(lines, constraintName(uc.Name)+"UNIQUE ("+quoteNames(uc.Columns)+")")
+	}
+	for _, cc := range t.CheckConstraints {
+		lines = append(lines, constraintName(cc.Name)+"CHECK ("+cc.Expr+")")
+	}
+	for _, fk := range t.ForeignKeys {
+		lines = append(lines, foreignKeySQL(fk))
+	}
+	return lines
+}
+
+// foreignKeySQL returns a foreign key as it is declared after the columns.
+func foreignKeySQL(fk *ForeignKey) string {
+	return constraintName(fk.Name) + "FOREIGN KEY (" + quoteNames(fk.LocalColumns) + ") " + referencesSQL(fk)
+}
+
+// referencesSQL returns the REFERENCES clause of a foreign key, with its actions.
+func referencesSQL(fk *ForeignKey) string {
+	s := "REFERENCES " + quoteName(fk.Table) + " (" + quoteNames(fk.Columns) + ")"
+	if fk.OnDelete != NoAction {
+		s += " ON DELETE " + fk.OnDelete.String()
+	}
+	if fk.OnUpdate != NoAction {
+		s += " ON UPDATE " + fk.OnUpdate.String()
+	}
+	return s
+}
+
+// IndexSQL returns the CREATE INDEX statement for idx, without a closing semicolon. Indexed
+// columns of the table are quoted as needed, and indexed expressions are printed as they are.
+func (t *Table) IndexSQL(idx *Index) string {
+	s := "CREATE "
+	if idx.Unique {
+		s += "UNIQUE "
+	}
+	s += "INDEX "
+	if idx.IfNotExists {
+		s += "IF NOT EXISTS "
+	}
+	cols := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		if t.Column(col) != nil {
+			cols[i] = quoteName(col)
+		} else {
+			cols[i] = col
+		}
+	}
+	s += qualifiedName(idx.SchemaName, idx.Name) + " ON " + quoteName(idx.Table) + " (" + strings.Join(cols, ", ") + ")"
+	if idx.Where != "" {
+		s += " WHERE " + idx.Where
+	}
+	return s
+}
+
+// columnSQL returns the column's definition: its name, type, and column constraints. A single
+// primary key is declared inline, named pkName if it is not "".
+func columnSQL(c *Column, pkName string) string {
+	s := quoteName(c.SQLName()) + " " + c.Type.SQL()
+	if c.PrimaryKey {
+		s += " " + constraintName(pkName) + "PRIMARY KEY"
+		if c.autoIncrement {
+			s += " AUTOINCREMENT"
+		}
+	}
+	if !c.Nullable {
+		s += " NOT NULL"
+	}
+	if value := c.DefaultSQL(); value != "" {
+		s += " DEFAULT " + value
+	}
+	return s
+}
+
+// DefaultSQL returns the column's DEFAULT value as SQL, or "" if it has none. An expression is
+// parenthesized unless it is a single literal or keyword, which DATETIME columns may use bare.
+func (c *Column) DefaultSQL() string {
+	switch {
+	case c.DefaultInt.Valid:
+		return strconv.FormatInt(c.DefaultInt.Int64, 10)
+	case c.DefaultFloat.Valid:
+		return strconv.FormatFloat(c.DefaultFloat.Float64, 'f', -1, 64)
+	case c.DefaultString.Valid:
+		return Token{Type: String, Value: c.DefaultString.String}.Source()
+	case c.DefaultBool.Valid && c.DefaultBool.Bool:
+		return "TRUE"
+	case c.DefaultBool.Valid:
+		return "FALSE"
+	case c.DefaultExpr == "":
+		return ""
+	case c.Type == DATETIME && literal(c.DefaultExpr):
+		return c.DefaultExpr
+	default:
+		return "(" + c.DefaultExpr + ")"
+	}
+}
+
+// literal returns true if expr is a single token, or a signed number.
+func literal(expr string) bool {
+	toks := Lex(expr).All()
+	switch {
+	case len(toks) == 1:
+		return toks[0].Type != Comment && toks[0].Type != Punct
+	case len(toks) == 2:
+		return (toks[0].Value == "+" || toks[0].Value == "-") && toks[1].Type == Number
+	default:
+		return false
+	}
+}
+
+// writeComment writes comment at the end of the current line: as a -- comment, or as a /* */
+// comment if it spans lines.
+func writeComment(b *strings.Builder, comment string) {
+	switch {
+	case comment == "":
+	case strings.Contains(comment, "\n"):
+		b.WriteString(" /* " + comment + " */")
+	default:
+		b.WriteString(" -- " + comment)
+	}
+}
+
+// constraintName returns the CONSTRAINT prefix for a named constraint, or "" if name is "".
+func constraintName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "CONSTRAINT " + quoteName(name) + " "
+}
+
+// qualifiedName returns name, quoted as needed, prefixed with its schema if it has one.
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return quoteName(name)
+	}
+	return quoteName(schema) + "." + quoteName(name)
+}
+
+// quoteNames returns the names quoted as needed and separated by commas.
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteName(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quoteName returns name as is if it can be used bare, or double-quoted if it is a keyword or
+// contains anything but letters, digits, underscores, and dollar signs.
+func quoteName(name string) string {
+	bare := name != "" && !slices.Contains(keywords, strings.ToUpper(name))
+	for i, c := range name {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && (c == '$' || '0' <= c && c <= '9'):
+		default:
+			bare = false
+		}
+	}
+	if bare {
+		return name
+	}
+	return Token{Type: Ident, Quote: '"', Value: name}.Source()
+}
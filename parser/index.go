@@ -0,0 +1,24 @@
+package parser
+
+// Index is a CREATE [UNIQUE] INDEX statement. Indexes are parsed as separate statements but stored
+// on the Table they index.
+//
+// SQLite Docs: https://www.sqlite.org/lang_createindex.html
+type Index struct {
+	Name        string `json:"name" yaml:"name"`
+	SchemaName  string `json:"schema_name" yaml:"schema_name"`
+	Table       string `json:"table" yaml:"table"` // Table is the indexed table.
+	Unique      bool   `json:"unique" yaml:"unique"`
+	IfNotExists bool   `json:"if_not_exists" yaml:"if_not_exists"`
+	// Columns are the indexed columns, in order. An indexed expression (e.g. lower(email)) is kept as
+	// its SQL text, so use Table.Column to tell columns from expressions.
+	Columns []string `json:"columns" yaml:"columns"`
+	// Where is the predicate of a partial index as space-normalized SQL, or "" if the index covers
+	// every row.
+	Where string `json:"where" yaml:"where"`
+}
+
+// Partial returns true if this index only covers the rows matching its WHERE clause.
+func (idx *Index) Partial() bool {
+	return idx.Where != ""
+}
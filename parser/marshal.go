@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tables and columns are serialized through these types, which add their unexported names to
+// their exported fields, and for columns, replace the sql.Null* defaults with optional values.
+type (
+	table     Table
+	column    Column
+	tableJSON struct {
+		SQLName string `json:"sql_name" yaml:"sql_name"`
+		GoName  string `json:"go_name" yaml:"go_name"`
+		table   `yaml:",inline"`
+	}
+	columnJSON struct {
+		SQLName       string `json:"sql_name" yaml:"sql_name"`
+		GoName        string `json:"go_name" yaml:"go_name"`
+		column        `yaml:",inline"`
+		AutoIncrement bool     `json:"auto_increment" yaml:"auto_increment"` // AutoIncrement is true if AUTOINCREMENT was given.
+		DefaultString *string  `json:"default_string,omitempty" yaml:"default_string,omitempty"`
+		DefaultInt    *int64   `json:"default_int,omitempty" yaml:"default_int,omitempty"`
+		DefaultFloat  *float64 `json:"default_float,omitempty" yaml:"default_float,omitempty"`
+		DefaultBool   *bool    `json:"default_bool,omitempty" yaml:"default_bool,omitempty"`
+	}
+)
+
+// toJSON returns the table as it is serialized, with empty rather than nil lists.
+func (t Table) toJSON() tableJSON {
+	v := tableJSON{SQLName: t.sqlName, GoName: t.goName, table: table(t)}
+	if v.Columns == nil {
+		v.Columns = []Column{}
+	}
+	if v.ForeignKeys == nil {
+		v.ForeignKeys = []*ForeignKey{}
+	}
+	if v.UniqueConstraints == nil {
+		v.UniqueConstraints = []UniqueConstraint{}
+	}
+	if v.CheckConstraints == nil {
+		v.CheckConstraints = []CheckConstraint{}
+	}
+	if v.Indexes == nil {
+		v.Indexes = []Index{}
+	}
+	return v
+}
+
+// fromJSON sets the table from its serialized form, with nil rather than empty lists of
+// constraints and indexes, as Parse leaves them.
+func (t *Table) fromJSON(v tableJSON) {
+	*t = Table(v.table)
+	t.sqlName, t.goName = v.SQLName, v.GoName
+	if len(t.ForeignKeys) == 0 {
+		t.ForeignKeys = nil
+	}
+	if len(t.UniqueConstraints) == 0 {
+		t.UniqueConstraints = nil
+	}
+	if len(t.CheckConstraints) == 0 {
+		t.CheckConstraints = nil
+	}
+	if len(t.Indexes) == 0 {
+		t.Indexes = nil
+	}
+}
+
+// MarshalJSON returns the table as a JSON object with snake_case keys, including its sql_name and
+// go_name.
+func (t Table) MarshalJSON() ([]byte, error) { return json.Marshal(t.toJSON()) }
+
+// UnmarshalJSON sets the table from the JSON returned by MarshalJSON.
+func (t *Table) UnmarshalJSON(data []byte) error {
+	var v tableJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.fromJSON(v)
+	return nil
+}
+
+// MarshalYAML returns the table as a YAML mapping with the keys of MarshalJSON.
+func (t Table) MarshalYAML() (any, error) { return t.toJSON(), nil }
+
+// UnmarshalYAML sets the table from the YAML returned by MarshalYAML.
+func (t *Table) UnmarshalYAML(node *yaml.Node) error {
+	var v tableJSON
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	t.fromJSON(v)
+	return nil
+}
+
+// toJSON returns the column as it is serialized.
+func (c Column) toJSON() columnJSON {
+	v := columnJSON{SQLName: c.sqlName, GoName: c.goName, AutoIncrement: c.autoIncrement, column: column(c)}
+	if c.DefaultString.Valid {
+		v.DefaultString = &c.DefaultString.String
+	}
+	if c.DefaultInt.Valid {
+		v.DefaultInt = &c.DefaultInt.Int64
+	}
+	if c.DefaultFloat.Valid {
+		v.DefaultFloat = &c.DefaultFloat.Float64
+	}
+	if c.DefaultBool.Valid {
+		v.DefaultBool = &c.DefaultBool.Bool
+	}
+	return v
+}
+
+// fromJSON sets the column from its serialized form.
+func (c *Column) fromJSON(v columnJSON) {
+	*c = Column(v.column)
+	c.sqlName, c.goName, c.autoIncrement = v.SQLName, v.GoName, v.AutoIncrement
+	if v.DefaultString != nil {
+		c.DefaultString = sql.NullString{Valid: true, String: *v.DefaultString}
+	}
+	if v.DefaultInt != nil {
+		c.DefaultInt = sql.NullInt64{Valid: true, Int64: *v.DefaultInt}
+	}
+	if v.DefaultFloat != nil {
+		c.DefaultFloat = sql.NullFloat64{Valid: true, Float64: *v.DefaultFloat}
+	}
+	if v.DefaultBool != nil {
+		c.DefaultBool = sql.NullBool{Valid: true, Bool: *v.DefaultBool}
+	}
+}
+
+// MarshalJSON returns the column as a JSON object with snake_case keys, including its sql_name,
+// go_name, and whether it was declared AUTOINCREMENT, and its DEFAULT as default_string,
+// default_int, default_float, or default_bool, whichever matches its type.
+func (c Column) MarshalJSON() ([]byte, error) { return json.Marshal(c.toJSON()) }
+
+// UnmarshalJSON sets the column from the JSON returned by MarshalJSON.
+func (c *Column) UnmarshalJSON(data []byte) error {
+	var v columnJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	c.fromJSON(v)
+	return nil
+}
+
+// MarshalYAML returns the column as a YAML mapping with the keys of MarshalJSON.
+func (c Column) MarshalYAML() (any, error) { return c.toJSON(), nil }
+
+// UnmarshalYAML sets the column from the YAML returned by MarshalYAML.
+func (c *Column) UnmarshalYAML(node *yaml.Node) error {
+	var v columnJSON
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	c.fromJSON(v)
+	return nil
+}
+
+// MarshalText returns the action as SQL (e.g. "SET NULL"), so it is serialized as a string.
+func (a OnFkAction) MarshalText() ([]byte, error) { return []byte(a.String()), nil }
+
+// UnmarshalText sets the action from the SQL returned by MarshalText.
+func (a *OnFkAction) UnmarshalText(text []byte) error {
+	for _, action := range []OnFkAction{NoAction, SetNull, SetDefault, Cascade, Restrict} {
+		if string(text) == action.String() {
+			*a = action
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown foreign key action %q", text)
+}
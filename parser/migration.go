@@ -0,0 +1,273 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Migration returns SQLite statements that migrate a database from the old schema to the new one:
+// renaming tables, creating added tables, altering changed tables, and then dropping removed
+// tables. A changed table is altered with ALTER TABLE ADD, DROP, and RENAME COLUMN where SQLite
+// allows it, and is otherwise rebuilt (see Rebuilds). Rows of a rebuilt table keep the values of
+// the columns it had before, and columns it did not have take their DEFAULT, as do NULLs in a
+// column that becomes NOT NULL. Returns an error if a rebuilt table has a NOT NULL column without
+// a DEFAULT that is added, or that was nullable, since its rows might not copy.
+func (d *SchemaDiff) Migration() (string, error) {
+	return d.migration(d.Rebuilds())
+}
+
+// Rebuilds returns true if Migration rebuilds a table by following SQLite's procedure for the
+// changes ALTER TABLE cannot make (https://www.sqlite.org/lang_altertable.html#otheralter): create
+// the new table, copy the rows, drop the old table, rename the new one, and recreate its indexes.
+// The parser does not accept triggers or views, so there are none to recreate. Foreign keys are
+// turned off while it runs, which cannot be done in a transaction, so Migration begins and commits
+// its own, and turns foreign keys back on after checking them. PRAGMA foreign_key_check only lists
+// the rows whose foreign keys are broken; it does not fail the migration, so check its output.
+func (d *SchemaDiff) Rebuilds() bool {
+	for _, td := range d.Tables {
+		if (td.Change == Changed || td.Change == Renamed) && !td.alterable() {
+			return true
+		}
+	}
+	return false
+}
+
+// Goose returns a goose migration (https://github.com/pressly/goose) with Migration as its Up, and
+// the migration of the Reverse diff as its Down. If either rebuilds a table (see Rebuilds), the
+// file is marked NO TRANSACTION and both begin and commit their own. Returns an error if either
+// cannot be migrated (see Migration).
+func (d *SchemaDiff) Goose() (string, error) {
+	down := d.Reverse()
+	tx := d.Rebuilds() || down.Rebuilds()
+	upSQL, err := d.migration(tx)
+	if err != nil {
+		return "", err
+	}
+	downSQL, err := down.migration(tx)
+	if err != nil {
+		return "", fmt.Errorf("down: %w", err)
+	}
+	s := ""
+	if tx {
+		s += "-- +goose NO TRANSACTION\n"
+	}
+	s += "-- +goose Up\n" + upSQL + "\n-- +goose Down\n" + downSQL
+	return s, nil
+}
+
+// migration returns the statements of Migration, in a transaction it begins and commits if tx is
+// true.
+func (d *SchemaDiff) migration(tx bool) (string, error) {
+	stmts := []string{}
+	for _, td := range d.Tables {
+		if td.Change == Renamed {
+			stmts = append(stmts, "ALTER TABLE "+qualifiedName(td.Old.SchemaName, td.Old.SQLName())+" RENAME TO "+quoteName(td.New.SQLName())+";\n")
+		}
+	}
+	for _, td := range d.Tables {
+		if td.Change == Added {
+			stmts = append(stmts, td.New.SQL())
+		}
+	}
+	for _, td := range d.Tables {
+		switch {
+		case td.Change != Changed && td.Change != Renamed:
+		case td.alterable():
+			stmts = append(stmts, td.alter()...)
+		default:
+			rebuild, err := td.rebuild()
+			if err != nil {
+				return "", err
+			}
+			stmts = append(stmts, rebuild...)
+		}
+	}
+	for _, td := range slices.Backward(d.Tables) { // children are usually declared after their parents
+		if td.Change == Removed {
+			stmts = append(stmts, "DROP TABLE "+qualifiedName(td.Old.SchemaName, td.Old.SQLName())+";\n")
+		}
+	}
+	if len(stmts) == 0 {
+		return "", nil
+	}
+	if tx {
+		stmts = append([]string{"BEGIN;\n"}, stmts...)
+		stmts = append(stmts, "COMMIT;\n")
+	}
+	if d.Rebuilds() {
+		stmts = append([]string{"PRAGMA foreign_keys = OFF;\n"}, stmts...)
+		stmts = slices.Insert(stmts, len(stmts)-1, "PRAGMA foreign_key_check;\n")
+		stmts = append(stmts, "PRAGMA foreign_keys = ON;\n")
+	}
+	return strings.Join(stmts, "\n"), nil
+}
+
+// alterable returns true if ALTER TABLE can make the table's changes: only indexes change, and
+// columns are renamed, dropped, or added after the others without a change to their definitions
+// or constraints other than a foreign key on an added column. SQLite does not allow dropping a
+// primary key, or adding one, a UNIQUE column, a NOT NULL column without a DEFAULT, or a column
+// with an expression as its DEFAULT, or with a foreign key and a DEFAULT.
+func (td *TableDiff) alterable() bool {
+	for _, cd := range td.Constraints {
+		if cd.Change != Added || td.addedForeignKey(cd.SQL) == nil {
+			return false
+		}
+	}
+	for _, cd := range td.Columns {
+		switch cd.Change {
+		case Changed:
+			return false
+		case Renamed:
+			if columnDef(cd.Old, td.Old) != columnDef(cd.New, td.New) {
+				return false
+			}
+		case Removed:
+			if cd.Old.PrimaryKey || cd.Old.CompositePrimaryKey {
+				return false
+			}
+		case Added:
+			c := cd.New
+			switch {
+			case c.PrimaryKey, c.CompositePrimaryKey, c.DefaultExpr != "":
+				return false
+			case !c.Nullable && !c.HasDefault():
+				return false
+			case td.foreignKey(c) != nil && c.HasDefault():
+				return false
+			}
+		}
+	}
+	last, added := -1, false // columns must keep their order, with any added last
+	for i := range td.New.Columns {
+		old := td.source(&td.New.Columns[i])
+		if old == nil {
+			added = true
+			continue
+		}
+		j := slices.IndexFunc(td.Old.Columns, func(c Column) bool { return c.SQLName() == old.SQLName() })
+		if added || j < last {
+			return false
+		}
+		last = j
+	}
+	return true
+}
+
+// alter returns the statements making the table's changes with ALTER TABLE (see alterable).
+func (td *TableDiff) alter() []string {
+	name := qualifiedName(td.New.SchemaName, td.New.SQLName())
+	stmts := td.dropIndexes()
+	for _, cd := range td.Columns {
+		if cd.Change == Renamed {
+			stmts = append(stmts, "ALTER TABLE "+name+" RENAME COLUMN "+quoteName(cd.Old.SQLName())+" TO "+quoteName(cd.New.SQLName())+";\n")
+		}
+	}
+	for _, cd := range td.Columns {
+		if cd.Change == Removed {
+			stmts = append(stmts, "ALTER TABLE "+name+" DROP COLUMN "+quoteName(cd.Old.SQLName())+";\n")
+		}
+	}
+	for _, cd := range td.Columns {
+		if cd.Change == Added {
+			col := columnSQL(cd.New, "")
+			if fk := td.foreignKey(cd.New); fk != nil {
+				col += " " + constraintName(fk.Name) + referencesSQL(fk)
+			}
+			stmts = append(stmts, "ALTER TABLE "+name+" ADD COLUMN "+col+";\n")
+		}
+	}
+	for _, id := range td.Indexes {
+		if id.Change != Removed {
+			stmts = append(stmts, td.New.IndexSQL(id.New)+";\n")
+		}
+	}
+	return stmts
+}
+
+// rebuild returns the statements rebuilding the table with its new definition (see Rebuilds). The
+// table has its new name, as Migration renames tables first. Returns an error if a NOT NULL column
+// without a DEFAULT is added, or was nullable, so the rows might not copy. The sqlite3 shell keeps
+// going after a statement fails, so the rows would then be dropped with the old table.
+func (td *TableDiff) rebuild() ([]string, error) {
+	name := qualifiedName(td.New.SchemaName, td.New.SQLName())
+	tmp := *td.New
+	tmp.SetSQLName("new_" + td.New.SQLName())
+	tmpName := qualifiedName(tmp.SchemaName, tmp.SQLName())
+
+	stmts := []string{tmp.CreateSQL() + ";\n"}
+	cols, from := []string{}, []string{}
+	for i := range td.New.Columns {
+		c := &td.New.Columns[i]
+		old, def := td.source(c), c.DefaultSQL()
+		switch {
+		case old != nil && (c.Nullable || !old.Nullable || c.AutoIncrement()):
+			cols = append(cols, c.SQLName())
+			from = append(from, quoteName(old.SQLName()))
+		case old != nil && def != "": // NULLs take the DEFAULT, as NOT NULL would reject them
+			cols = append(cols, c.SQLName())
+			from = append(from, "COALESCE("+quoteName(old.SQLName())+", "+def+")")
+		case old != nil:
+			return nil, fmt.Errorf("%s.%s becomes NOT NULL without a DEFAULT, so rows where it is NULL cannot be copied when the table is rebuilt; give it a DEFAULT",
+				td.New.SQLName(), c.SQLName())
+		case !c.Nullable && def == "" && !c.AutoIncrement():
+			return nil, fmt.Errorf("%s.%s is added NOT NULL without a DEFAULT, so existing rows cannot be copied when the table is rebuilt; give it a DEFAULT",
+				td.New.SQLName(), c.SQLName())
+		}
+	}
+	if len(cols) > 0 {
+		stmts = append(stmts, "INSERT INTO "+tmpName+" ("+quoteNames(cols)+")\n\tSELECT "+strings.Join(from, ", ")+" FROM "+name+";\n")
+	}
+	stmts = append(stmts,
+		"DROP TABLE "+name+";\n",
+		"ALTER TABLE "+tmpName+" RENAME TO "+quoteName(td.New.SQLName())+";\n")
+	for i := range td.New.Indexes {
+		stmts = append(stmts, td.New.IndexSQL(&td.New.Indexes[i])+";\n")
+	}
+	return stmts, nil
+}
+
+// dropIndexes returns the statements dropping the table's removed and changed indexes.
+func (td *TableDiff) dropIndexes() []string {
+	stmts := []string{}
+	for _, id := range td.Indexes {
+		if id.Change != Added {
+			stmts = append(stmts, "DROP INDEX "+qualifiedName(id.Old.SchemaName, id.Old.Name)+";\n")
+		}
+	}
+	return stmts
+}
+
+// source returns the old column that the new column c was, or nil if it was added.
+func (td *TableDiff) source(c *Column) *Column {
+	for _, cd := range td.Columns {
+		if cd.New == c {
+			return cd.Old
+		}
+	}
+	return columnFold(td.Old, c.SQLName())
+}
+
+// foreignKey returns the new table's foreign key on column c alone, or nil if it has none.
+func (td *TableDiff) foreignKey(c *Column) *ForeignKey {
+	for _, fk := range td.New.ForeignKeys {
+		if len(fk.LocalColumns) == 1 && strings.EqualFold(fk.LocalColumns[0], c.SQLName()) {
+			return fk
+		}
+	}
+	return nil
+}
+
+// addedForeignKey returns the foreign key declared by sql if it is on an added column alone, which
+// ALTER TABLE ADD COLUMN can declare inline, or nil if it is not.
+func (td *TableDiff) addedForeignKey(sql string) *ForeignKey {
+	for _, cd := range td.Columns {
+		if cd.Change != Added {
+			continue
+		}
+		if fk := td.foreignKey(cd.New); fk != nil && foreignKeySQL(fk) == sql {
+			return fk
+		}
+	}
+	return nil
+}
@@ -14,11 +14,19 @@ import (
 func Parse(sql string) ([]*Table, error) {
 	tokens := Lex(sql)
 	tables := make([]*Table, 0)
+	indexes := make([]*Index, 0) // Attached to their tables once every table has been parsed.
 	for {
 		switch {
 		case tokens.NextType() == Comment: // Comment between statements; discarded.
 			tokens.Take()
 		case tokens.NextType() == EOF: // End of SQL
+			for _, idx := range indexes {
+				if table := findTable(tables, idx.Table); table != nil {
+					table.Indexes = append(table.Indexes, *idx)
+				} else {
+					log.Debugf("[IGNORED] CREATE INDEX %s on unknown table %s", idx.Name, idx.Table)
+				}
+			}
 			return tables, nil
 		case tokens.KeywordSeq("CREATE", "TABLE"):
 			table, err := parseCreateTable(tokens)
@@ -31,11 +39,12 @@ func Parse(sql string) ([]*Table, error) {
 			fallthrough
 		case tokens.KeywordSeq("CREATE", "UNIQUE", "INDEX"):
 			// https://www.sqlite.org/syntax/create-index-stmt.html
-			err := parseCreateIndex(tokens)
+			idx, err := parseCreateIndex(tokens)
 			if err != nil {
 				printContext(tokens, err)
 				return nil, err
 			}
+			indexes = append(indexes, idx)
 		default:
 			err := fmt.Errorf("unsupported statement: %s", tokens.NextN(3))
 			printContext(tokens, err)
@@ -44,6 +53,16 @@ func Parse(sql string) ([]*Table, error) {
 	}
 }
 
+// findTable returns the table with the given SQL name, or nil if there is none.
+func findTable(tables []*Table, sqlName string) *Table {
+	for _, t := range tables {
+		if t.SQLName() == sqlName {
+			return t
+		}
+	}
+	return nil
+}
+
 func printContext(tokens *Tokens, err error) {
 	tokens.ReturnN(10)
 	fmt.Println(err)
@@ -250,6 +269,7 @@ func parseColumn(tokens *Tokens, strict bool) (parsedColumn, error) {
 			return pc, fmt.Errorf("column constraint must be 'PRIMARY KEY', not %s", tokens.NextN(2))
 		} else if tokens.KeywordIs("AUTOINCREMENT") {
 			if c.PrimaryKey {
+				c.autoIncrement = true
 				tokens.Take() // Consume ONE token
 			} else {
 				return pc, errors.New("column constraint 'AUTOINCREMENT' must follow 'PRIMARY KEY'")
@@ -274,6 +294,13 @@ func parseColumn(tokens *Tokens, strict bool) (parsedColumn, error) {
 		} else if tokens.KeywordIs("DEFAULT") {
 			tokens.Take()
 			constraintName = "" // no model slot for a DEFAULT constraint name
+			switch {
+			case c.Type == BOOL: // handles its own parentheses, e.g. DEFAULT (TRUE)
+			case c.Type == DATETIME:
+			case tokens.Next() == "(":
+				c.DefaultExpr = parseDefaultExpr(tokens)
+				continue
+			}
 			switch c.Type {
 			case INT:
 				token := takeDefaultValue(tokens)
@@ -315,7 +342,7 @@ func parseColumn(tokens *Tokens, strict bool) (parsedColumn, error) {
 					return pc, fmt.Errorf("default value for BOOL/BOOLEAN must be TRUE/true/1 or FALSE/false/0, not %s", token)
 				}
 			case DATETIME:
-				parseDatetimeDefault(tokens)
+				c.DefaultExpr = parseDatetimeDefault(tokens)
 			default:
 				return pc, fmt.Errorf("default values for %s type are not supported", c.Type)
 			}
@@ -504,40 +531,49 @@ func parseFkAction(tokens *Tokens, fk *ForeignKey) error {
 	return nil
 }
 
-// parseDatetimeDefault consumes a DATETIME/TIMESTAMP column's DEFAULT value (which the generator
-// currently ignores) without reading past it. Per SQLite's column-constraint grammar the value is
-// one of two shapes:
+// parseDatetimeDefault consumes a DATETIME/TIMESTAMP column's DEFAULT value without reading past it,
+// returning it as SQL, or "" if it is NULL. Per SQLite's column-constraint grammar the value is one
+// of two shapes:
 //   - a bare literal or keyword -- NULL, CURRENT_TIMESTAMP, CURRENT_TIME, CURRENT_DATE, a number,
 //     or a string literal -- which is a single token (an optional leading +/- sign for a number is
-//     reassembled by takeDefaultValue), or
-//   - a parenthesized expression: ( expr ).
-//
-// The parenthesized branch stops when its parentheses balance OR on EOF, so a malformed/unterminated
-// default can never cause an infinite loop.
-func parseDatetimeDefault(tokens *Tokens) {
-	if tokens.Next() != "(" {
-		// Bare literal or keyword (e.g. CURRENT_TIMESTAMP, NULL, 0). A single token, except a
-		// signed number whose sign is lexed separately.
-		log.Debugf("[IGNORED] DateTime Default: %s\n", takeDefaultValue(tokens))
-		return
+//     lexed separately), or
+//   - a parenthesized expression: ( expr ), see parseDefaultExpr.
+func parseDatetimeDefault(tokens *Tokens) string {
+	if tokens.Next() == "(" {
+		return parseDefaultExpr(tokens)
 	}
+	sign := ""
+	if tokens.Next() == "+" || tokens.Next() == "-" {
+		sign = tokens.Take()
+	}
+	value := sign + tokens.TakeSource()
+	if strings.EqualFold(value, "NULL") {
+		return ""
+	}
+	return value
+}
+
+// parseDefaultExpr consumes a parenthesized DEFAULT expression and returns it as SQL without the
+// outer parentheses, with tokens separated by spaces (e.g. "(datetime('now'))" -> "datetime ( 'now' )").
+// It stops when its parentheses balance OR on EOF, so a malformed/unterminated default can never
+// cause an infinite loop.
+func parseDefaultExpr(tokens *Tokens) string {
+	tokens.Take() // opening parenthesis
 	value := []string{}
-	paren := 0
-	for tokens.NextType() != EOF {
-		t := tokens.Take()
-		if t == "(" {
-			paren += 1
-		} else if t == ")" {
-			paren -= 1
-			if paren == 0 {
-				break
-			}
-		} else {
-			// ignore
+	paren := 1
+	for paren > 0 && tokens.NextType() != EOF {
+		t := tokens.TakeSource()
+		switch t {
+		case "(":
+			paren++
+		case ")":
+			paren--
+		}
+		if paren > 0 {
 			value = append(value, t)
 		}
 	}
-	log.Debugf("[IGNORED] DateTime Default: %s\n", strings.Join(value, " "))
+	return strings.Join(value, " ")
 }
 
 // table-constraint
@@ -611,40 +647,112 @@ func parseColumnList(tokens *Tokens) []string {
 
 // create-index-stmt
 // https://www.sqlite.org/syntax/create-index-stmt.html
-//
-// TODO: Parse `CREATE [UNIQUE] INDEX ...` instead of ignoring it.
-func parseCreateIndex(tokens *Tokens) error {
-	// Find the closing semicolon and ignore this index (i.e. handle multiline)
-	value := []string{}
-	tokens.Take() // consume the leading CREATE
+func parseCreateIndex(tokens *Tokens) (*Index, error) {
+	idx := &Index{}
+	tokens.Take() // CREATE
+	idx.Unique = tokens.TakeKeyword("UNIQUE")
+	if !tokens.TakeKeyword("INDEX") {
+		return nil, fmt.Errorf("create index must begin with 'CREATE [UNIQUE] INDEX', not %s", tokens.NextN(3))
+	}
+	if tokens.KeywordSeq("IF", "NOT", "EXISTS") {
+		tokens.TakeN(3)
+		idx.IfNotExists = true
+	}
+	// Schema and Index Name (i.e. Schema.IndexName)
+	if tokens.Peek(1) == "." {
+		idx.SchemaName = removeQuotes(tokens.Take())
+		tokens.Take() // period delimiter
+	}
+	idx.Name = removeQuotes(tokens.Take())
+	if !tokens.TakeKeyword("ON") {
+		return nil, fmt.Errorf("create index must name its table with 'ON table-name', not %s", tokens.NextN(2))
+	}
+	idx.Table = removeQuotes(tokens.Take())
+	cols, err := parseIndexedColumns(tokens)
+	if err != nil {
+		return nil, err
+	}
+	idx.Columns = cols
+	if tokens.TakeKeyword("WHERE") {
+		value := []string{}
+		for tokens.NextType() != EOF && tokens.Next() != ";" {
+			if tokens.NextType() == Comment {
+				tokens.Take()
+				continue
+			}
+			value = append(value, tokens.TakeSource())
+		}
+		idx.Where = strings.Join(value, " ")
+	}
+	for tokens.NextType() == Comment {
+		tokens.Take()
+	}
+	// Detect end of input by token type, not by an empty value: an empty value is also produced by
+	// an empty-string literal ('') such as in a partial-index "WHERE col != ''".
+	if tokens.NextType() == EOF {
+		return nil, fmt.Errorf("expected closing semi-colon while parsing CREATE INDEX %s, but found end of SQL", idx.Name)
+	}
+	if tokens.Take() != ";" {
+		tokens.Return()
+		return nil, fmt.Errorf("expected closing semi-colon while parsing CREATE INDEX %s, not %s", idx.Name, tokens.NextN(3))
+	}
+	return idx, nil
+}
+
+// parseIndexedColumns parses the parenthesized indexed-column list of a CREATE INDEX statement. A
+// plain column (optionally followed by COLLATE and ASC/DESC, which are parsed but ignored) is
+// returned as its name; an expression is returned as its space-normalized SQL text.
+// https://www.sqlite.org/syntax/indexed-column.html
+func parseIndexedColumns(tokens *Tokens) ([]string, error) {
+	if tokens.Take() != "(" {
+		tokens.Return()
+		return nil, fmt.Errorf("create index must list its indexed columns in parentheses, not %s", tokens.NextN(3))
+	}
+	cols := []string{}
+	item := []string{}
+	paren := 1
 	for {
-		// Detect end of input by token type, not by an empty value: an empty value is also
-		// produced by an empty-string literal ('') such as in a partial-index "WHERE col != ''".
 		if tokens.NextType() == EOF {
-			// Provide up to five tokens of context to avoid printing a huge amount of SQL
-			partialValue := ""
-			if len(value) > 5 {
-				partialValue = strings.Join(value[0:5], " ")
-			} else {
-				partialValue = strings.Join(value, " ")
-			}
-			return fmt.Errorf("expected closing semi-colon while parsing CREATE INDEX, but found end of SQL: %s", partialValue)
+			return nil, fmt.Errorf("ran out of tokens unexpectedly - indexed column list was likely not closed properly")
 		}
-		token := tokens.Take()
-		if token == ";" {
-			break // Found end of index
+		switch {
+		case tokens.Next() == "(":
+			paren++
+		case tokens.Next() == ")":
+			paren--
+		case tokens.Next() == "," && paren == 1:
+			tokens.Take()
+			cols = append(cols, strings.Join(item, " "))
+			item = []string{}
+			continue
+		case paren == 1 && (tokens.KeywordIs("ASC") || tokens.KeywordIs("DESC")):
+			tokens.Take()
+			continue
+		case paren == 1 && tokens.KeywordIs("COLLATE"):
+			tokens.TakeN(2) // COLLATE collation-name
+			continue
 		}
-		value = append(value, token)
+		if paren == 0 {
+			tokens.Take() // closing parenthesis
+			cols = append(cols, strings.Join(item, " "))
+			return cols, nil
+		}
+		if len(item) == 0 && tokens.NextType() == Ident && (tokens.Peek(1) == "," || tokens.Peek(1) == ")" ||
+			strings.EqualFold(tokens.Peek(1), "COLLATE") || strings.EqualFold(tokens.Peek(1), "ASC") ||
+			strings.EqualFold(tokens.Peek(1), "DESC")) {
+			item = append(item, tokens.Take()) // plain column name, kept unquoted
+			continue
+		}
+		item = append(item, tokens.TakeSource())
 	}
-	log.Debugf("[IGNORED] CREATE INDEX: %s", strings.Join(value, " "))
-	return nil
 }
 
 // parseCheckConstraint: CHECK ( expr )
 // See column-constraint and table-constraint
 // parseCheckConstraint parses a CHECK ( expr ) constraint, returning the expression as a
 // space-normalized token string (best-effort; nested parentheses are preserved but the outer pair
-// is not). The leading CHECK keyword is consumed.
+// is not). String literals and quoted identifiers keep their quotes, so the expression is valid
+// SQL. The leading CHECK keyword is consumed.
 func parseCheckConstraint(tokens *Tokens) string {
 	tokens.Take() // CHECK
 	if tokens.Next() != "(" {
@@ -654,7 +762,7 @@ func parseCheckConstraint(tokens *Tokens) string {
 	value := []string{}
 	paren := 1
 	for paren > 0 {
-		t := tokens.Take()
+		t := tokens.TakeSource()
 		switch t {
 		case "":
 			return strings.Join(value, " ") // ran out of tokens
@@ -2,11 +2,13 @@ package parser
 
 import (
 	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestParse(t *testing.T) {
@@ -54,7 +56,7 @@ func TestParse(t *testing.T) {
 					sqlName: "users",
 					goName:  "User",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: false},
 						{sqlName: "name", goName: "Name", Type: TEXT, PrimaryKey: false, Nullable: true},
 					},
 					UniqueConstraints: []UniqueConstraint{{Columns: []string{"name"}}},
@@ -73,7 +75,7 @@ func TestParse(t *testing.T) {
 					sqlName: "jobs",
 					goName:  "Job",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: false},
 						{sqlName: "num_nodes", goName: "NumNode", Type: INT, PrimaryKey: false, Nullable: true},
 					},
 				},
@@ -91,7 +93,7 @@ func TestParse(t *testing.T) {
 					goName:  "Foo",
 					Comment: "Hello world!",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: false},
 					},
 				},
 			},
@@ -559,6 +561,10 @@ func TestParse(t *testing.T) {
 						{sqlName: "email", goName: "Email", Type: TEXT},
 						{sqlName: "role", goName: "Role", Type: TEXT},
 					},
+					Indexes: []Index{
+						{Name: "idx_users_email", Table: "users", Columns: []string{"email"}},
+						{Name: "idx_users_role", Table: "users", Columns: []string{"role"}},
+					},
 				},
 			},
 		},
@@ -576,9 +582,9 @@ func TestParse(t *testing.T) {
 					sqlName: "login_attempts",
 					goName:  "LoginAttempt",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: false},
 						{sqlName: "ip", goName: "IP", Type: TEXT, Nullable: false},
-						{sqlName: "time", goName: "Time", Type: DATETIME, Nullable: false},
+						{sqlName: "time", goName: "Time", Type: DATETIME, Nullable: false, DefaultExpr: "datetime ( 'now' )"},
 					},
 				},
 			},
@@ -630,9 +636,9 @@ func TestParse(t *testing.T) {
 					sqlName: "ip_login_attempts",
 					goName:  "IPLoginAttempt",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: true},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: true},
 						{sqlName: "ip", goName: "IP", Type: TEXT, Nullable: false},
-						{sqlName: "time", goName: "Time", Type: DATETIME, Nullable: false},
+						{sqlName: "time", goName: "Time", Type: DATETIME, Nullable: false, DefaultExpr: "datetime ( 'now' )"},
 					},
 					ForeignKeys: []*ForeignKey{
 						{Table: "ip_login_summary", LocalColumns: []string{"ip"}, Columns: []string{"ip"}, OnUpdate: Cascade, OnDelete: Cascade},
@@ -671,9 +677,9 @@ func TestParse(t *testing.T) {
 					sqlName: "ip_login_attempts",
 					goName:  "IPLoginAttempt",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: true},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: true},
 						{sqlName: "ip", goName: "IP", Type: TEXT, Nullable: false},
-						{sqlName: "time", goName: "Time", Type: DATETIME, Nullable: false},
+						{sqlName: "time", goName: "Time", Type: DATETIME, Nullable: false, DefaultExpr: "datetime ( 'now' )"},
 					},
 					ForeignKeys: []*ForeignKey{
 						{Table: "ip_login_summary", LocalColumns: []string{"ip"}, Columns: []string{"ip"}, OnUpdate: Cascade, OnDelete: Cascade},
@@ -701,7 +707,7 @@ func TestParse(t *testing.T) {
 					sqlName: "accounts",
 					goName:  "Account",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: false},
 						{sqlName: "name", goName: "Name", Type: TEXT, PrimaryKey: false, Nullable: false},
 						{sqlName: "type", goName: "Type", Type: INT, PrimaryKey: false, Nullable: false},
 						{sqlName: "total", goName: "Total", Type: INT, PrimaryKey: false, Nullable: false, DefaultInt: sql.NullInt64{Valid: true, Int64: 0}},
@@ -729,7 +735,7 @@ func TestParse(t *testing.T) {
 					sqlName: "accounts",
 					goName:  "Account",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: false},
 						{sqlName: "name", goName: "Name", Type: TEXT, PrimaryKey: false, Nullable: false},
 						{sqlName: "type", goName: "Type", Type: INT, PrimaryKey: false, Nullable: false},
 						{sqlName: "total", goName: "Total", Type: INT, PrimaryKey: false, Nullable: false, DefaultInt: sql.NullInt64{Valid: true, Int64: 0}},
@@ -754,10 +760,16 @@ func TestParse(t *testing.T) {
 					sqlName: "shared_services",
 					goName:  "SharedService",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: false},
 						{sqlName: "source", goName: "Source", Type: TEXT, Nullable: false},
 						{sqlName: "source_key", goName: "SourceKey", Type: TEXT, Nullable: false},
 					},
+					Indexes: []Index{
+						{
+							Name: "idx_shared_services_source_key", Table: "shared_services", Unique: true,
+							Columns: []string{"source", "source_key"}, Where: "source != ''",
+						},
+					},
 				},
 			},
 		},
@@ -1170,7 +1182,7 @@ func TestParse(t *testing.T) {
 					sqlName: "children",
 					goName:  "Child",
 					Columns: []Column{
-						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, autoIncrement: true, Nullable: false},
 						{sqlName: "name", goName: "Name", Type: TEXT, Nullable: true, DefaultString: sql.NullString{Valid: true, String: "bob"}},
 						// "check" is a quoted identifier, so it must be treated as a column name, NOT
 						// the CHECK keyword that the table-constraint dispatch looks for.
@@ -1413,8 +1425,8 @@ func TestParse(t *testing.T) {
 					goName:  "Event",
 					Columns: []Column{
 						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: true},
-						{sqlName: "created_at", goName: "CreatedAt", Type: DATETIME, Nullable: true},
-						{sqlName: "updated_at", goName: "UpdatedAt", Type: DATETIME, Nullable: true},
+						{sqlName: "created_at", goName: "CreatedAt", Type: DATETIME, Nullable: true, DefaultExpr: "CURRENT_TIMESTAMP"},
+						{sqlName: "updated_at", goName: "UpdatedAt", Type: DATETIME, Nullable: true, DefaultExpr: "CURRENT_DATE"},
 						{sqlName: "name", goName: "Name", Type: TEXT, Nullable: true},
 					},
 				},
@@ -1435,7 +1447,7 @@ func TestParse(t *testing.T) {
 					sqlName: "events",
 					goName:  "Event",
 					Columns: []Column{
-						{sqlName: "created_at", goName: "CreatedAt", Type: DATETIME, Nullable: true},
+						{sqlName: "created_at", goName: "CreatedAt", Type: DATETIME, Nullable: true, DefaultExpr: "CURRENT_TIMESTAMP"},
 						{sqlName: "amount", goName: "Amount", Type: INT, Nullable: true},
 					},
 					CheckConstraints: []CheckConstraint{{Name: "", Expr: "amount > 0"}},
@@ -1457,11 +1469,65 @@ func TestParse(t *testing.T) {
 					goName:  "Event",
 					Columns: []Column{
 						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: true},
-						{sqlName: "created_at", goName: "CreatedAt", Type: DATETIME, Nullable: false},
+						{sqlName: "created_at", goName: "CreatedAt", Type: DATETIME, Nullable: false, DefaultExpr: "CURRENT_TIMESTAMP"},
 					},
 				},
 			},
 		},
+		{
+			"expression defaults on non-datetime columns",
+			`CREATE TABLE events (
+				id			INTEGER PRIMARY KEY,
+				created_at	INTEGER NOT NULL DEFAULT (unixepoch()),
+				label		TEXT DEFAULT (lower('NEW'))
+			)`,
+			false,
+			[]*Table{
+				{
+					sqlName: "events",
+					goName:  "Event",
+					Columns: []Column{
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: true},
+						{sqlName: "created_at", goName: "CreatedAt", Type: INT, Nullable: false, DefaultExpr: "unixepoch ( )"},
+						{sqlName: "label", goName: "Label", Type: TEXT, Nullable: true, DefaultExpr: "lower ( 'NEW' )"},
+					},
+				},
+			},
+		},
+		{
+			"unique, expression, and IF NOT EXISTS indexes with COLLATE and ASC/DESC",
+			`CREATE TABLE users (
+				id		INTEGER NOT NULL PRIMARY KEY,
+				email	TEXT NOT NULL,
+				name	TEXT NOT NULL
+			);
+			CREATE UNIQUE INDEX IF NOT EXISTS main.ux_email ON users (email COLLATE NOCASE DESC);
+			CREATE INDEX idx_name_lower ON "users" (lower(name), id ASC);
+			`,
+			false,
+			[]*Table{
+				{
+					sqlName: "users",
+					goName:  "User",
+					Columns: []Column{
+						{sqlName: "id", goName: "ID", Type: INT, PrimaryKey: true, Nullable: false},
+						{sqlName: "email", goName: "Email", Type: TEXT, Nullable: false},
+						{sqlName: "name", goName: "Name", Type: TEXT, Nullable: false},
+					},
+					Indexes: []Index{
+						{Name: "ux_email", SchemaName: "main", Table: "users", Unique: true, IfNotExists: true, Columns: []string{"email"}},
+						{Name: "idx_name_lower", Table: "users", Columns: []string{"lower ( name )", "id"}},
+					},
+				},
+			},
+		},
+		{
+			"CREATE INDEX without a closing semicolon is an error",
+			`CREATE TABLE users ( id INTEGER PRIMARY KEY );
+			CREATE INDEX idx_id ON users (id)`,
+			true,
+			nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1472,10 +1538,314 @@ func TestParse(t *testing.T) {
 			}
 			require.NoError(t, err)
 			assert.Equal(t, tt.want, got, "%+v", got)
+
+			// The tables print to SQL that parses to the same tables.
+			again, err := Parse(Format(got))
+			require.NoError(t, err, Format(got))
+			assert.Equal(t, got, again, Format(got))
 		})
 	}
 }
 
+// TestFormat verifies the printed SQL of a table and its indexes: every constraint after the
+// columns, names quoted only where needed, and comments at the end of their line.
+func TestFormat(t *testing.T) {
+	sql := `CREATE TABLE IF NOT EXISTS "groups" ( -- groups of users
+		id			INTEGER NOT NULL CONSTRAINT pk_groups PRIMARY KEY AUTOINCREMENT,
+		name		TEXT NOT NULL DEFAULT 'it''s' CHECK (length(name) > 0), /* the name,
+		shown to users */
+		"order"		INT DEFAULT -1,
+		ratio		REAL DEFAULT 0.5,
+		hidden		BOOL NOT NULL DEFAULT FALSE,
+		created_at	DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at	DATETIME DEFAULT (datetime('now')),
+		parent_id	INTEGER REFERENCES "groups" (id) ON DELETE SET NULL,
+		CONSTRAINT uc_name UNIQUE (name, parent_id),
+		CONSTRAINT ck_order CHECK ("order" IN (-1, 1))
+	);
+	CREATE TABLE memberships (
+		group_id	INTEGER NOT NULL,
+		user_id		INTEGER NOT NULL,
+		PRIMARY KEY (group_id, user_id),
+		FOREIGN KEY (group_id) REFERENCES "groups" (id) ON DELETE CASCADE ON UPDATE RESTRICT
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS ux_lower ON "groups" (lower(name)) WHERE "order" > 0;
+	CREATE INDEX ix_order ON "groups" ("order", created_at);`
+	tables, err := Parse(sql)
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS "groups" ( -- groups of users
+	id INTEGER CONSTRAINT pk_groups PRIMARY KEY AUTOINCREMENT NOT NULL,
+	name TEXT NOT NULL DEFAULT 'it''s', /* the name,
+		shown to users */
+	"order" INTEGER DEFAULT -1,
+	ratio REAL DEFAULT 0.5,
+	hidden BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT (datetime ( 'now' )),
+	parent_id INTEGER,
+	CONSTRAINT uc_name UNIQUE (name, parent_id),
+	CHECK (length ( name ) > 0),
+	CONSTRAINT ck_order CHECK ("order" IN ( - 1 , 1 )),
+	FOREIGN KEY (parent_id) REFERENCES "groups" (id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_lower ON "groups" (lower ( name )) WHERE "order" > 0;
+
+CREATE INDEX ix_order ON "groups" ("order", created_at);
+
+CREATE TABLE memberships (
+	group_id INTEGER NOT NULL,
+	user_id INTEGER NOT NULL,
+	PRIMARY KEY (group_id, user_id),
+	FOREIGN KEY (group_id) REFERENCES "groups" (id) ON DELETE CASCADE ON UPDATE RESTRICT
+);
+`, Format(tables))
+
+	again, err := Parse(Format(tables))
+	require.NoError(t, err)
+	assert.Equal(t, tables, again)
+}
+
+// TestDiff verifies the changes reported between two schemas, including renames from an
+// annotation and from a column with the same definition and a related name.
+func TestDiff(t *testing.T) {
+	a, err := Parse(`CREATE TABLE users (
+		id		INTEGER PRIMARY KEY,
+		mail	TEXT NOT NULL UNIQUE,
+		name	TEXT NOT NULL,
+		legacy	TEXT
+	);
+	CREATE INDEX ix_users_name ON users (name);
+	CREATE TABLE teams (
+		id		INTEGER PRIMARY KEY,
+		title	TEXT NOT NULL CHECK (title != '')
+	);
+	CREATE TABLE junk (id INTEGER PRIMARY KEY);`)
+	require.NoError(t, err)
+	b, err := Parse(`CREATE TABLE users (
+		id			INTEGER PRIMARY KEY,
+		email		TEXT NOT NULL,
+		name		TEXT NOT NULL,
+		bio			TEXT,
+		group_id	INTEGER REFERENCES "groups" (id),
+		UNIQUE (email)
+	);
+	CREATE INDEX ix_users_name ON users (name, email);
+	CREATE TABLE "groups" ( -- squirrel:renamed_from teams
+		id		INTEGER PRIMARY KEY,
+		name	TEXT NOT NULL CHECK (name != '') -- squirrel:renamed_from title
+	);
+	CREATE TABLE tags (id INTEGER PRIMARY KEY);`)
+	require.NoError(t, err)
+
+	d := Diff(a, b)
+	assert.Equal(t, `changed table users
+	renamed column mail to email
+	removed column legacy
+	added column bio TEXT
+	added column group_id INTEGER
+	added constraint FOREIGN KEY (group_id) REFERENCES "groups" (id)
+	changed index ix_users_name
+renamed table teams to groups
+	renamed column title to name
+added table tags
+removed table junk
+`, d.String())
+	assert.Equal(t, `changed table users
+	renamed column email to mail
+	added column legacy TEXT
+	removed column bio
+	removed column group_id
+	removed constraint FOREIGN KEY (group_id) REFERENCES "groups" (id)
+	changed index ix_users_name
+renamed table groups to teams
+	renamed column name to title
+removed table tags
+added table junk
+`, d.Reverse().String())
+
+	// A schema does not differ from itself printed.
+	again, err := Parse(Format(b))
+	require.NoError(t, err)
+	assert.True(t, Diff(b, again).Empty(), Diff(b, again).String())
+
+	// Names in expressions are not requoted, as temp, a keyword allowed as a bare column name, was.
+	temps, err := Parse(`CREATE TABLE readings (temp REAL CHECK (temp > 0));`)
+	require.NoError(t, err)
+	assert.True(t, Diff(temps, temps).Empty(), Diff(temps, temps).String())
+}
+
+// TestDiffMigration verifies ALTER TABLE is used where SQLite allows it, and that other changes
+// rebuild the table with foreign keys off in a transaction, copying NULLs into a column that becomes
+// NOT NULL as its DEFAULT.
+func TestDiffMigration(t *testing.T) {
+	a, err := Parse(`CREATE TABLE users (
+		id		INTEGER PRIMARY KEY,
+		mail	TEXT NOT NULL,
+		legacy	TEXT
+	);
+	CREATE TABLE posts (
+		id		INTEGER PRIMARY KEY,
+		user_id	INTEGER NOT NULL REFERENCES users (id),
+		body	TEXT
+	);`)
+	require.NoError(t, err)
+	b, err := Parse(`CREATE TABLE users (
+		id		INTEGER PRIMARY KEY,
+		email	TEXT NOT NULL, -- squirrel:renamed_from mail
+		team_id	INTEGER REFERENCES teams (id)
+	);
+	CREATE INDEX ix_users_email ON users (email);
+	CREATE TABLE posts (
+		id		INTEGER PRIMARY KEY,
+		user_id	INTEGER NOT NULL REFERENCES users (id),
+		body	TEXT NOT NULL DEFAULT ''
+	);`)
+	require.NoError(t, err)
+
+	d := Diff(a, b)
+	assert.True(t, d.Rebuilds())
+	migration, err := d.Migration()
+	require.NoError(t, err)
+	assert.Equal(t, `PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+ALTER TABLE users RENAME COLUMN mail TO email;
+
+ALTER TABLE users DROP COLUMN legacy;
+
+ALTER TABLE users ADD COLUMN team_id INTEGER REFERENCES teams (id);
+
+CREATE INDEX ix_users_email ON users (email);
+
+CREATE TABLE new_posts (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	body TEXT NOT NULL DEFAULT '',
+	FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+INSERT INTO new_posts (id, user_id, body)
+	SELECT id, user_id, COALESCE(body, '') FROM posts;
+
+DROP TABLE posts;
+
+ALTER TABLE new_posts RENAME TO posts;
+
+PRAGMA foreign_key_check;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
+`, migration)
+
+	// Without the posts change, users is only altered, but the Down rebuilds it to drop team_id, which
+	// has a foreign key, so the file is NO TRANSACTION and the Up begins its own.
+	b[1] = a[1]
+	d = Diff(a, b)
+	assert.False(t, d.Rebuilds())
+	goose, err := d.Goose()
+	require.NoError(t, err)
+	assert.Equal(t, `-- +goose NO TRANSACTION
+-- +goose Up
+BEGIN;
+
+ALTER TABLE users RENAME COLUMN mail TO email;
+
+ALTER TABLE users DROP COLUMN legacy;
+
+ALTER TABLE users ADD COLUMN team_id INTEGER REFERENCES teams (id);
+
+CREATE INDEX ix_users_email ON users (email);
+
+COMMIT;
+
+-- +goose Down
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+CREATE TABLE new_users (
+	id INTEGER PRIMARY KEY,
+	mail TEXT NOT NULL,
+	legacy TEXT
+);
+
+INSERT INTO new_users (id, mail)
+	SELECT id, email FROM users;
+
+DROP TABLE users;
+
+ALTER TABLE new_users RENAME TO users;
+
+PRAGMA foreign_key_check;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
+`, goose)
+
+	// A rebuilt table's rows cannot be copied into a NOT NULL column without a DEFAULT, whether it is
+	// added, or was nullable, as body is in the Down of a change making it nullable.
+	titled, err := Parse(`CREATE TABLE posts (
+		id		INTEGER PRIMARY KEY,
+		user_id	INTEGER NOT NULL REFERENCES users (id),
+		title	TEXT NOT NULL,
+		body	TEXT
+	);`)
+	require.NoError(t, err)
+	_, err = Diff(a[1:], titled).Migration()
+	assert.ErrorContains(t, err, "posts.title is added NOT NULL without a DEFAULT")
+	strict, err := Parse(`CREATE TABLE posts (
+		id		INTEGER PRIMARY KEY,
+		user_id	INTEGER NOT NULL REFERENCES users (id),
+		body	TEXT NOT NULL
+	);`)
+	require.NoError(t, err)
+	_, err = Diff(strict, a[1:]).Goose()
+	assert.ErrorContains(t, err, "down: posts.body becomes NOT NULL without a DEFAULT")
+}
+
+// TestMarshal verifies tables serialize to JSON and YAML with their names and typed defaults, and
+// back to the same tables.
+func TestMarshal(t *testing.T) {
+	tables, err := Parse(`CREATE TABLE users (
+		id		INTEGER PRIMARY KEY AUTOINCREMENT,
+		name	TEXT NOT NULL DEFAULT 'bob' -- shown to others
+	);
+	CREATE TABLE posts (
+		id		INTEGER PRIMARY KEY,
+		user_id	INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		score	REAL DEFAULT 0.5 CHECK (score >= 0),
+		UNIQUE (user_id, score)
+	);
+	CREATE INDEX ix_posts_score ON posts (score) WHERE score > 0;`)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(tables[0].Columns[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sql_name": "name", "go_name": "Name", "type": "text", "primary_key": false,
+		"composite_primary_key": false, "nullable": false, "comment": "shown to others",
+		"default_expr": "", "auto_increment": false, "default_string": "bob"}`, string(data))
+	data, err = json.Marshal(tables[1].ForeignKeys[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "", "table": "users", "local_columns": ["user_id"], "columns": ["id"],
+		"on_update": "NO ACTION", "on_delete": "CASCADE"}`, string(data))
+
+	data, err = json.Marshal(tables)
+	require.NoError(t, err)
+	fromJSON := []*Table{}
+	require.NoError(t, json.Unmarshal(data, &fromJSON))
+	assert.Equal(t, tables, fromJSON)
+
+	data, err = yaml.Marshal(tables)
+	require.NoError(t, err)
+	fromYAML := []*Table{}
+	require.NoError(t, yaml.Unmarshal(data, &fromYAML))
+	assert.Equal(t, tables, fromYAML)
+}
+
 // TestParseTerminates guards against the infinite-loop bugs that a bare (non-parenthesized)
 // datetime DEFAULT and an unterminated column list previously triggered. Each input is parsed on a
 // background goroutine; if Parse fails to return before the deadline it has regressed into a hang,
@@ -8,36 +8,38 @@ import (
 )
 
 type Table struct {
-	Strict      bool // Strict is true if enabled. Defaults to false.
-	SchemaName  string
+	Strict      bool   `json:"strict" yaml:"strict"` // Strict is true if enabled. Defaults to false.
+	SchemaName  string `json:"schema_name" yaml:"schema_name"`
 	sqlName     string
 	goName      string
-	Temp        bool
-	IfNotExists bool
-	Columns     []Column
-	ForeignKeys []*ForeignKey // ForeignKeys defined on this table (inline single-column and table-level, possibly composite).
+	Temp        bool          `json:"temp" yaml:"temp"`
+	IfNotExists bool          `json:"if_not_exists" yaml:"if_not_exists"`
+	Columns     []Column      `json:"columns" yaml:"columns"`
+	ForeignKeys []*ForeignKey `json:"foreign_keys" yaml:"foreign_keys"` // ForeignKeys defined on this table (inline single-column and table-level, possibly composite).
 	// UniqueConstraints holds every UNIQUE constraint on the table, whether declared inline on a
 	// column or as a table-level constraint, and whether single- or multi-column. Use
 	// SingleColumnUnique to test individual-column uniqueness.
-	UniqueConstraints []UniqueConstraint
+	UniqueConstraints []UniqueConstraint `json:"unique_constraints" yaml:"unique_constraints"`
 	// PrimaryKeyName is the name from a table-level CONSTRAINT <name> PRIMARY KEY (...), or "" if
 	// the primary key is unnamed or declared inline on a column.
-	PrimaryKeyName string
+	PrimaryKeyName string `json:"primary_key_name" yaml:"primary_key_name"`
 	// CheckConstraints holds table-level CHECK constraints, in declaration order.
-	CheckConstraints []CheckConstraint
-	Comment          string // Comment at the end of the CREATE TABLE definition if provided.
+	CheckConstraints []CheckConstraint `json:"check_constraints" yaml:"check_constraints"`
+	// Indexes holds the CREATE [UNIQUE] INDEX statements on this table, in declaration order.
+	Indexes []Index `json:"indexes" yaml:"indexes"`
+	Comment string  `json:"comment" yaml:"comment"` // Comment at the end of the CREATE TABLE definition if provided.
 }
 
 // UniqueConstraint is a table-level UNIQUE constraint over one or more columns.
 type UniqueConstraint struct {
-	Name    string   // Name from a CONSTRAINT <name> prefix, or "" if unnamed.
-	Columns []string // The constrained columns, in the order declared.
+	Name    string   `json:"name" yaml:"name"`       // Name from a CONSTRAINT <name> prefix, or "" if unnamed.
+	Columns []string `json:"columns" yaml:"columns"` // The constrained columns, in the order declared.
 }
 
 // CheckConstraint is a table-level CHECK constraint.
 type CheckConstraint struct {
-	Name string // Name from a CONSTRAINT <name> prefix, or "" if unnamed.
-	Expr string // The check expression as a space-normalized token string (best-effort).
+	Name string `json:"name" yaml:"name"` // Name from a CONSTRAINT <name> prefix, or "" if unnamed.
+	Expr string `json:"expr" yaml:"expr"` // The check expression as a space-normalized token string (best-effort).
 }
 
 func (t *Table) GoName() string  { return t.goName }
@@ -130,12 +132,17 @@ func (t *Table) SingleColumnUnique(colName string) bool {
 
 // hasColumn returns true if the table has a column with the given SQL name.
 func (t *Table) hasColumn(sqlName string) bool {
+	return t.Column(sqlName) != nil
+}
+
+// Column returns the column with the given SQL name, or nil if the table has no such column.
+func (t *Table) Column(sqlName string) *Column {
 	for i := range t.Columns {
 		if t.Columns[i].SQLName() == sqlName {
-			return true
+			return &t.Columns[i]
 		}
 	}
-	return false
+	return nil
 }
 
 // PrimaryKeys returns the column(s).
@@ -167,3 +174,14 @@ func (t *Table) PrimaryKeyAutoIncrements() bool {
 	pks := t.PrimaryKeys()
 	return len(pks) == 1 && pks[0].AutoIncrement()
 }
+
+// FindTable returns the table with the given SQL name, ignoring case as SQLite does, or nil if there
+// is none.
+func FindTable(tables []*Table, sqlName string) *Table {
+	for _, t := range tables {
+		if strings.EqualFold(t.SQLName(), sqlName) {
+			return t
+		}
+	}
+	return nil
+}
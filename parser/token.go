@@ -17,10 +17,10 @@ const (
 	Comment            // -- line  or  /* block */ ; Value is the trimmed comment text
 )
 
-// isKeyword reports whether this token is the given keyword: an UNQUOTED bare word matching
+// IsKeyword reports whether this token is the given keyword: an UNQUOTED bare word matching
 // case-insensitively. Quoted identifiers (e.g. "check" or [check]) are never keywords, per SQLite,
 // which is what makes case-insensitive keyword matching safe against keyword-named columns.
-func (tok Token) isKeyword(kw string) bool {
+func (tok Token) IsKeyword(kw string) bool {
 	return tok.Type == Ident && tok.Quote == 0 && strings.EqualFold(tok.Value, kw)
 }
 
@@ -36,3 +36,22 @@ type Token struct {
 	// are no longer emitted as tokens.
 	NewlineBefore bool
 }
+
+// Source returns the token as it would appear in SQL, re-quoting identifiers and literals (whose
+// Value is stored unquoted) so expressions can be reassembled into valid SQL.
+func (tok Token) Source() string {
+	switch {
+	case tok.Type == String:
+		return "'" + strings.ReplaceAll(tok.Value, "'", "''") + "'"
+	case tok.Type == Blob:
+		return "x'" + tok.Value + "'"
+	case tok.Type == Ident && tok.Quote == '"':
+		return `"` + strings.ReplaceAll(tok.Value, `"`, `""`) + `"`
+	case tok.Type == Ident && tok.Quote == '`':
+		return "`" + strings.ReplaceAll(tok.Value, "`", "``") + "`"
+	case tok.Type == Ident && tok.Quote == '[':
+		return "[" + tok.Value + "]"
+	default:
+		return tok.Value
+	}
+}
@@ -14,6 +14,9 @@ type Tokens struct {
 	i    int
 }
 
+// All returns every token, including any already taken.
+func (t *Tokens) All() []Token { return t.toks }
+
 // value returns the Value of the token at absolute index j, or "" if out of range.
 func (t *Tokens) value(j int) string {
 	if j >= 0 && j < len(t.toks) {
@@ -46,14 +49,14 @@ func (t *Tokens) NextNewlineBefore() bool {
 // KeywordIs reports whether the next token is the given keyword (unquoted bare word, matched
 // case-insensitively).
 func (t *Tokens) KeywordIs(kw string) bool {
-	return t.i < len(t.toks) && t.toks[t.i].isKeyword(kw)
+	return t.i < len(t.toks) && t.toks[t.i].IsKeyword(kw)
 }
 
 // KeywordSeq reports whether the next tokens are exactly the given keywords, in order (each an
 // unquoted bare word, matched case-insensitively).
 func (t *Tokens) KeywordSeq(kws ...string) bool {
 	for j, kw := range kws {
-		if t.i+j >= len(t.toks) || !t.toks[t.i+j].isKeyword(kw) {
+		if t.i+j >= len(t.toks) || !t.toks[t.i+j].IsKeyword(kw) {
 			return false
 		}
 	}
@@ -99,6 +102,18 @@ func (t *Tokens) Take() string {
 	return ""
 }
 
+// TakeSource takes the next token (i.e. claim/consume it), returning it as it would appear in SQL:
+// unlike Take, quoted identifiers and string/blob literals keep their quotes.
+// Returns an empty string if there are no more tokens.
+func (t *Tokens) TakeSource() string {
+	if t.i < len(t.toks) {
+		v := t.toks[t.i].Source()
+		t.i++
+		return v
+	}
+	return ""
+}
+
 // TakeN takes the next N tokens (i.e. claim/consume them), clamping to the number remaining.
 func (t *Tokens) TakeN(n int) {
 	t.i += n
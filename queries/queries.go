@@ -0,0 +1,179 @@
+// Package queries reads files of annotated SQL statements and resolves them against a parsed schema,
+// so each can be compiled to a typed Go function. A query file holds one or more statements, each
+// preceded by an annotation naming the Go function and what it returns:
+//
+//	-- name: ListActiveUsers :many
+//	-- ListActiveUsers returns the users who have not been deleted.
+//	SELECT * FROM users WHERE deleted_at IS NULL;
+//
+// Comment lines directly after the annotation become the function's doc comment.
+package queries
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// Kind is what a query's function returns, as given by its annotation (e.g. :many).
+type Kind string
+
+const (
+	One      Kind = ":one"      // One returns a single row, or an error if there is none.
+	Many     Kind = ":many"     // Many returns every matching row.
+	Exec     Kind = ":exec"     // Exec only returns an error.
+	ExecRows Kind = ":execrows" // ExecRows returns the number of rows affected.
+)
+
+// annotation matches the comment starting each query (e.g. "-- name: ListActiveUsers :many").
+var annotation = regexp.MustCompile(`^--\s*name:\s*(\S+)\s+(:\w+)\s*$`)
+
+// Query is a single annotated statement from a query file.
+type Query struct {
+	Name   string   // Name of the generated Go function (e.g. ListActiveUsers).
+	Kind   Kind     // Kind of result the function returns.
+	Doc    []string // Doc holds the comment lines directly after the annotation, without the "--".
+	Source string   // Source is the SQL as written, without a trailing semicolon.
+	File   string   // File the query was read from.
+	Line   int      // Line of the query's annotation in File.
+
+	// The following are set by Resolve.
+
+	SQL    string   // SQL is Source with each parameter replaced by ?, and each * by its columns.
+	Params []Param  // Params are the function's arguments, in order of first use.
+	Args   []string // Args are the names of the Params bound to each placeholder in SQL, in order.
+	Result *Result  // Result describes the returned rows, or nil if the query returns none.
+}
+
+// Param is an argument of a query's function.
+type Param struct {
+	Name   string // Name is the Go argument name (e.g. email).
+	GoType string // GoType is the Go type of the column the parameter is compared to (e.g. string).
+}
+
+// Result describes the rows a query returns. Table is set if it returns whole rows of a table (e.g.
+// SELECT * FROM users), which are scanned into the table's generated struct. Otherwise Columns lists
+// each result column.
+type Result struct {
+	Table   *parser.Table
+	Columns []Column
+}
+
+// Column is a single column of a query's result.
+type Column struct {
+	SQLName string // SQLName is the result column's name (e.g. email, or its AS alias).
+	GoName  string // GoName is the field name for this column in a result struct (e.g. Email).
+	GoType  string // GoType is the Go type of the column (e.g. string).
+}
+
+// String returns where the query was defined and its name, for error messages.
+func (q *Query) String() string {
+	return fmt.Sprintf("%s:%d (%s)", q.File, q.Line, q.Name)
+}
+
+// ParseFiles reads and parses every query file matching paths, which may be globs (e.g.
+// queries/*.sql). Returns an error if a path matches no files, or if two queries share a name.
+func ParseFiles(paths []string) ([]*Query, error) {
+	all := []*Query{}
+	names := map[string]*Query{}
+	for _, path := range paths {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("queries: %w", err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("queries: no files match %s", path)
+		}
+		for _, match := range matches {
+			data, err := os.ReadFile(match)
+			if err != nil {
+				return nil, fmt.Errorf("queries: %w", err)
+			}
+			qs, err := Parse(match, string(data))
+			if err != nil {
+				return nil, err
+			}
+			for _, q := range qs {
+				if prev, ok := names[q.Name]; ok {
+					return nil, fmt.Errorf("queries: %s: name already used by %s", q, prev)
+				}
+				names[q.Name] = q
+			}
+			all = append(all, qs...)
+		}
+	}
+	return all, nil
+}
+
+// Parse splits the contents of a query file into its annotated queries. file is only used in error
+// messages. SQL before the first annotation is an error, since it would otherwise be silently
+// ignored.
+func Parse(file, src string) ([]*Query, error) {
+	qs := []*Query{}
+	var q *Query
+	body := []string{}
+	flush := func() error {
+		if q == nil {
+			return nil
+		}
+		q.Source = strings.TrimSuffix(strings.TrimSpace(strings.Join(body, "\n")), ";")
+		if q.Source == "" {
+			return fmt.Errorf("queries: %s: has no SQL", q)
+		}
+		qs = append(qs, q)
+		return nil
+	}
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := annotation.FindStringSubmatch(trimmed); m != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			q = &Query{Name: m[1], Kind: Kind(m[2]), File: file, Line: i + 1}
+			body = body[:0]
+			if !isExported(q.Name) {
+				return nil, fmt.Errorf("queries: %s: name must be an exported Go identifier", q)
+			}
+			switch q.Kind {
+			case One, Many, Exec, ExecRows:
+			default:
+				return nil, fmt.Errorf("queries: %s: unknown kind %s; use :one, :many, :exec, or :execrows", q, q.Kind)
+			}
+			continue
+		}
+		switch {
+		case q == nil && trimmed != "" && !strings.HasPrefix(trimmed, "--"):
+			return nil, fmt.Errorf("queries: %s:%d: SQL before the first '-- name: <Name> <:kind>' annotation", file, i+1)
+		case q == nil:
+			continue // leading blank line or comment
+		case len(body) == 0 && strings.HasPrefix(trimmed, "--"):
+			q.Doc = append(q.Doc, strings.TrimSpace(strings.TrimPrefix(trimmed, "--")))
+		case len(body) == 0 && trimmed == "":
+			continue // blank line between the annotation and the SQL
+		default:
+			body = append(body, line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return qs, nil
+}
+
+// isExported returns true if s is a Go identifier starting with an upper case letter.
+func isExported(s string) bool {
+	for i, r := range s {
+		switch {
+		case i == 0 && !unicode.IsUpper(r):
+			return false
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_':
+			return false
+		}
+	}
+	return s != ""
+}
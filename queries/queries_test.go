@@ -0,0 +1,236 @@
+package queries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+const schema = `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	name TEXT,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE memberships (
+	user_id INTEGER NOT NULL REFERENCES users (id),
+	group_id INTEGER NOT NULL,
+	role TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, group_id)
+);
+CREATE TABLE channels (
+	id INTEGER NOT NULL PRIMARY KEY,
+	owner_id INTEGER NOT NULL REFERENCES users (id),
+	slug TEXT NOT NULL UNIQUE
+);`
+
+func TestParse(t *testing.T) {
+	qs, err := Parse("users.sql", `
+-- Leading comments are ignored.
+
+-- name: GetUser :one
+-- GetUser returns the user with this email.
+SELECT * FROM users WHERE email = ?;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = ?
+`)
+	require.NoError(t, err)
+	require.Len(t, qs, 2)
+	assert.Equal(t, &Query{
+		Name:   "GetUser",
+		Kind:   One,
+		Doc:    []string{"GetUser returns the user with this email."},
+		Source: "SELECT * FROM users WHERE email = ?",
+		File:   "users.sql",
+		Line:   4,
+	}, qs[0])
+	assert.Equal(t, "DELETE FROM users\nWHERE id = ?", qs[1].Source)
+	assert.Equal(t, Exec, qs[1].Kind)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"SQL before the first annotation": "SELECT 1;\n-- name: One :one\nSELECT 1;",
+		"unknown kind":                    "-- name: One :first\nSELECT 1;",
+		"unexported name":                 "-- name: one :one\nSELECT 1;",
+		"no SQL":                          "-- name: One :one\n-- name: Two :one\nSELECT 1;",
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse("q.sql", src)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   Kind
+		sql    string
+		want   string // want is the SQL with placeholders replaced
+		params []Param
+		args   []string
+		result *Result // result, excluding Table which is checked by table
+		table  string
+	}{
+		{
+			name:   "select star maps to the table struct and is expanded",
+			kind:   One,
+			sql:    "SELECT * FROM users WHERE email = ?",
+			want:   "SELECT id, email, name, created_at FROM users WHERE email = ?",
+			params: []Param{{Name: "email", GoType: "string"}},
+			args:   []string{"email"},
+			table:  "users",
+		},
+		{
+			name: "aliased table star with join, named and numbered params, LIMIT",
+			kind: Many,
+			sql:  "SELECT u.* FROM users AS u JOIN memberships m ON m.user_id = u.id WHERE m.group_id = :group AND ?1 <= u.id AND u.id <> ?1 LIMIT ?",
+			want: "SELECT u.id, u.email, u.name, u.created_at FROM users AS u JOIN memberships m ON m.user_id = u.id WHERE m.group_id = ? AND ? <= u.id AND u.id <> ? LIMIT ?",
+			params: []Param{
+				{Name: "group", GoType: "int64"},
+				{Name: "id", GoType: "int64"},
+				{Name: "limit", GoType: "int64"},
+			},
+			args:  []string{"group", "id", "id", "limit"},
+			table: "users",
+		},
+		{
+			name: "column list, alias, and count",
+			kind: Many,
+			sql:  "SELECT email, name AS full_name, count(*) FROM users WHERE name NOT LIKE ? AND created_at BETWEEN ? AND ? GROUP BY email",
+			want: "SELECT email, name AS full_name, count(*) FROM users WHERE name NOT LIKE ? AND created_at BETWEEN ? AND ? GROUP BY email",
+			params: []Param{
+				{Name: "name", GoType: "sql.NullString"},
+				{Name: "createdAt", GoType: "time.Time"},
+				{Name: "createdAt2", GoType: "time.Time"},
+			},
+			args: []string{"name", "createdAt", "createdAt2"},
+			result: &Result{Columns: []Column{
+				{SQLName: "email", GoName: "Email", GoType: "string"},
+				{SQLName: "full_name", GoName: "FullName", GoType: "sql.NullString"},
+				{SQLName: "count(*)", GoName: "Count", GoType: "int64"},
+			}},
+		},
+		{
+			name: "insert with upsert and returning",
+			kind: One,
+			sql:  "INSERT INTO memberships (user_id, group_id, role) VALUES (?, ?, 'member') ON CONFLICT DO UPDATE SET role = excluded.role RETURNING role",
+			want: "INSERT INTO memberships (user_id, group_id, role) VALUES (?, ?, 'member') ON CONFLICT DO UPDATE SET role = excluded.role RETURNING role",
+			params: []Param{
+				{Name: "userID", GoType: "int64"},
+				{Name: "groupID", GoType: "int64"},
+			},
+			args:   []string{"userID", "groupID"},
+			result: &Result{Columns: []Column{{SQLName: "role", GoName: "Role", GoType: "string"}}},
+		},
+		{
+			name:   "update has no result",
+			kind:   ExecRows,
+			sql:    "UPDATE users SET name = @name WHERE id IN (?)",
+			want:   "UPDATE users SET name = ? WHERE id IN (?)",
+			params: []Param{{Name: "name", GoType: "sql.NullString"}, {Name: "id", GoType: "int64"}},
+			args:   []string{"name", "id"},
+		},
+		{
+			name: "every parameter in an IN list takes the column's type",
+			kind: Many,
+			sql:  "SELECT email FROM users WHERE id NOT IN (?, ?, :other) AND name IN (?)",
+			want: "SELECT email FROM users WHERE id NOT IN (?, ?, ?) AND name IN (?)",
+			params: []Param{
+				{Name: "id", GoType: "int64"},
+				{Name: "id2", GoType: "int64"},
+				{Name: "other", GoType: "int64"},
+				{Name: "name", GoType: "sql.NullString"},
+			},
+			args:   []string{"id", "id2", "other", "name"},
+			result: &Result{Columns: []Column{{SQLName: "email", GoName: "Email", GoType: "string"}}},
+		},
+		{
+			name:   "subquery columns resolve in the subquery first",
+			kind:   Many,
+			sql:    "SELECT id FROM users WHERE id IN (SELECT owner_id FROM channels WHERE slug = ?)",
+			want:   "SELECT id FROM users WHERE id IN (SELECT owner_id FROM channels WHERE slug = ?)",
+			params: []Param{{Name: "slug", GoType: "string"}},
+			args:   []string{"slug"},
+			result: &Result{Columns: []Column{{SQLName: "id", GoName: "ID", GoType: "int64"}}},
+		},
+		{
+			name:   "correlated subquery columns resolve in the outer query",
+			kind:   Many,
+			sql:    "SELECT email FROM users u WHERE EXISTS (SELECT 1 FROM channels WHERE owner_id = u.id AND (slug = ? OR email = ?))",
+			want:   "SELECT email FROM users u WHERE EXISTS (SELECT 1 FROM channels WHERE owner_id = u.id AND (slug = ? OR email = ?))",
+			params: []Param{{Name: "slug", GoType: "string"}, {Name: "email", GoType: "string"}},
+			args:   []string{"slug", "email"},
+			result: &Result{Columns: []Column{{SQLName: "email", GoName: "Email", GoType: "string"}}},
+		},
+		{
+			name:   "columns from the nullable side of a LEFT JOIN are nullable",
+			kind:   Many,
+			sql:    "SELECT u.email, c.slug, c.id AS channel_id FROM users u LEFT OUTER JOIN channels c ON c.owner_id = u.id WHERE c.slug = ?",
+			want:   "SELECT u.email, c.slug, c.id AS channel_id FROM users u LEFT OUTER JOIN channels c ON c.owner_id = u.id WHERE c.slug = ?",
+			params: []Param{{Name: "slug", GoType: "string"}},
+			args:   []string{"slug"},
+			result: &Result{Columns: []Column{
+				{SQLName: "email", GoName: "Email", GoType: "string"},
+				{SQLName: "slug", GoName: "Slug", GoType: "sql.NullString"},
+				{SQLName: "channel_id", GoName: "ChannelID", GoType: "sql.NullInt64"},
+			}},
+		},
+	}
+	tables, err := parser.Parse(schema)
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Query{Name: "Q", Kind: tt.kind, Source: tt.sql, File: "q.sql", Line: 1}
+			require.NoError(t, Resolve(q, tables))
+			assert.Equal(t, tt.want, q.SQL)
+			assert.Equal(t, tt.params, q.Params)
+			assert.Equal(t, tt.args, q.Args)
+			switch {
+			case tt.table != "":
+				require.NotNil(t, q.Result)
+				require.NotNil(t, q.Result.Table)
+				assert.Equal(t, tt.table, q.Result.Table.SQLName())
+			default:
+				assert.Equal(t, tt.result, q.Result)
+			}
+		})
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	tests := map[string]struct {
+		kind Kind
+		sql  string
+	}{
+		"unknown table":            {Many, "SELECT * FROM people"},
+		"ambiguous column":         {Many, "SELECT email FROM users JOIN memberships ON user_id = id WHERE created_at > ?"},
+		"uninferable parameter":    {Exec, "DELETE FROM users WHERE lower(email) = lower(?)"},
+		"uninferable result":       {Many, "SELECT email || name FROM users"},
+		"star over a join":         {Many, "SELECT * FROM users JOIN memberships ON user_id = id"},
+		"rows expected":            {One, "DELETE FROM users WHERE id = ?"},
+		"more than one statement":  {Exec, "DELETE FROM users; DELETE FROM memberships"},
+		"table star and a column":  {Many, "SELECT users.*, role FROM users JOIN memberships ON user_id = id"},
+		"duplicate result columns": {Many, "SELECT users.email, users.email FROM users"},
+		"ambiguous in subquery":    {Many, "SELECT email FROM users WHERE id IN (SELECT owner_id FROM channels JOIN users ON users.id = owner_id WHERE id = ?)"},
+		"inner table result":       {Many, "SELECT slug FROM users WHERE id IN (SELECT owner_id FROM channels)"},
+		"outer join table star":    {Many, "SELECT c.* FROM users u LEFT JOIN channels c ON c.owner_id = u.id"},
+	}
+	tables, err := parser.Parse(schema)
+	require.NoError(t, err)
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Resolve(&Query{Name: "Q", Kind: tt.kind, Source: tt.sql, File: "q.sql", Line: 1}, tables)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,660 @@
+package queries
+
+import (
+	"fmt"
+	"go/token"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/joshsziegler/squirrel/name"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// comparisons are the operators a parameter may be compared to a column with (e.g. email = ?).
+var comparisons = []string{"=", "==", "!=", "<>", "<", "<=", ">", ">="}
+
+// comparisonKeywords are the keyword operators a parameter may be compared to a column with.
+var comparisonKeywords = []string{"LIKE", "GLOB", "REGEXP", "MATCH", "IS"}
+
+// notAlias are keywords that may follow a table name, and so are never its alias.
+var notAlias = []string{
+	"AS", "ON", "USING", "WHERE", "JOIN", "LEFT", "RIGHT", "FULL", "INNER", "OUTER", "CROSS",
+	"NATURAL", "SET", "VALUES", "DEFAULT", "SELECT", "ORDER", "GROUP", "HAVING", "LIMIT", "OFFSET",
+	"UNION", "INTERSECT", "EXCEPT", "WINDOW", "RETURNING", "INDEXED", "NOT", "DO", "FROM",
+}
+
+// scoped is a table referenced by a query (e.g. in FROM or JOIN), and the alias it is known by.
+type scoped struct {
+	table    *parser.Table
+	alias    string // alias is the table's name if it was not given one.
+	group    int    // group is the index of the ( the reference is nested in (e.g. a subquery), or -1.
+	nullable bool   // nullable is true if the table is on the nullable side of an outer join.
+}
+
+// resolver holds the state of resolving a single query.
+type resolver struct {
+	q      *Query
+	toks   []parser.Token
+	tables []*parser.Table
+	scope  []scoped
+	groups []int // groups holds the index of the ( each token is nested in, or -1 if it is in none.
+	cte    bool  // cte is true if the query starts with WITH, whose tables are not in the schema.
+	edits  []edit
+}
+
+// edit replaces the bytes of the query's Source from start up to end with text, to build its SQL.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// Resolve infers the parameters and results of q from the tables it uses, and sets q's SQL, Params,
+// Args, and Result. A parameter is either a ? (numbered as ?NNN, or named as :name, @name, or $name)
+// and takes the type of the column it is compared to (e.g. email = ?), the column it is inserted
+// into, or int64 for LIMIT and OFFSET. Results are inferred for SELECT and RETURNING lists of columns,
+// count(...), table.*, and *, which maps a single table's rows to its generated struct. The * is
+// expanded to the table's columns in the SQL, so rows scan in the order the struct expects. Columns
+// from the nullable side of an outer join (e.g. LEFT JOIN) are nullable in the result.
+func Resolve(q *Query, tables []*parser.Table) error {
+	r := &resolver{q: q, toks: parser.Lex(q.Source).All(), tables: tables}
+	if len(r.toks) == 0 {
+		return fmt.Errorf("queries: %s: has no SQL", q)
+	}
+	for _, tok := range r.toks {
+		if tok.Value == ";" && tok.Type == parser.Punct {
+			return fmt.Errorf("queries: %s: must be a single statement", q)
+		}
+	}
+	r.cte = r.toks[0].IsKeyword("WITH")
+	r.findGroups()
+	if err := r.findTables(); err != nil {
+		return err
+	}
+	if err := r.resolveParams(); err != nil {
+		return err
+	}
+	result, err := r.resolveResult()
+	if err != nil {
+		return err
+	}
+	q.SQL = r.rewrite()
+	switch {
+	case (q.Kind == One || q.Kind == Many) && result == nil:
+		return fmt.Errorf("queries: %s: is %s but returns no rows; use :exec or :execrows, or add RETURNING", q, q.Kind)
+	case q.Kind == One || q.Kind == Many:
+		q.Result = result
+	}
+	return nil
+}
+
+// errorf returns an error prefixed with the query's location.
+func (r *resolver) errorf(format string, a ...any) error {
+	return fmt.Errorf("queries: %s: %s", r.q, fmt.Sprintf(format, a...))
+}
+
+// is returns true if the token at i is the keyword or punctuation/operator s.
+func (r *resolver) is(i int, s string) bool {
+	if i < 0 || i >= len(r.toks) {
+		return false
+	}
+	tok := r.toks[i]
+	if tok.Type == parser.Ident {
+		return tok.IsKeyword(s)
+	}
+	return (tok.Type == parser.Punct || tok.Type == parser.Operator) && tok.Value == s
+}
+
+// isAny returns true if the token at i is any of the keywords or operators in ss.
+func (r *resolver) isAny(i int, ss []string) bool {
+	for _, s := range ss {
+		if r.is(i, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ident returns true if the token at i is an identifier.
+func (r *resolver) ident(i int) bool {
+	return i >= 0 && i < len(r.toks) && r.toks[i].Type == parser.Ident
+}
+
+// findGroups sets the group each token is nested in. A ( is in the group around it, so following
+// the groups from any token leads out through each enclosing group to -1.
+func (r *resolver) findGroups() {
+	r.groups = make([]int, len(r.toks))
+	open := []int{-1}
+	for i := range r.toks {
+		if r.is(i, ")") && len(open) > 1 {
+			open = open[:len(open)-1]
+		}
+		r.groups[i] = open[len(open)-1]
+		if r.is(i, "(") {
+			open = append(open, i)
+		}
+	}
+}
+
+// findTables adds each table named after FROM, JOIN, UPDATE, or INTO to the scope, along with its
+// alias. The tables on the nullable side of a LEFT, RIGHT, or FULL JOIN are marked nullable.
+func (r *resolver) findTables() error {
+	for i := 0; i < len(r.toks); i++ {
+		switch {
+		case r.is(i, "UPDATE") && r.is(i-1, "DO"):
+			continue // an upsert's ON CONFLICT DO UPDATE, not a table
+		case r.isAny(i, []string{"FROM", "JOIN", "UPDATE", "INTO"}):
+			join := ""
+			if r.is(i, "JOIN") {
+				join = r.joinKind(i)
+			}
+			if join == "RIGHT" || join == "FULL" {
+				for k := range r.scope {
+					if r.scope[k].group == r.groups[i] {
+						r.scope[k].nullable = true // the tables joined so far
+					}
+				}
+			}
+			for j := i + 1; r.ident(j); {
+				next, err := r.addTable(j, join == "LEFT" || join == "FULL")
+				if err != nil {
+					return err
+				}
+				if !r.is(next, ",") || !r.is(i, "FROM") {
+					break
+				}
+				j = next + 1 // FROM a, b
+			}
+		}
+	}
+	return nil
+}
+
+// joinKind returns LEFT, RIGHT, or FULL for an outer JOIN at i (e.g. LEFT OUTER JOIN), or "".
+func (r *resolver) joinKind(i int) string {
+	if r.is(i-1, "OUTER") {
+		i--
+	}
+	for _, kind := range []string{"LEFT", "RIGHT", "FULL"} {
+		if r.is(i-1, kind) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// addTable adds the table named at i (and its alias, if any) to the scope, returning the index of
+// the token after it.
+func (r *resolver) addTable(i int, nullable bool) (int, error) {
+	group := r.groups[i]
+	if r.is(i+1, ".") && r.ident(i+2) { // schema.table
+		i += 2
+	}
+	tableName := r.toks[i].Value
+	i++
+	alias := tableName
+	switch {
+	case r.is(i, "AS") && r.ident(i+1):
+		alias = r.toks[i+1].Value
+		i += 2
+	case r.ident(i) && !r.isAny(i, notAlias):
+		alias = r.toks[i].Value
+		i++
+	}
+	t := parser.FindTable(r.tables, tableName)
+	switch {
+	case t == nil && r.cte:
+		return i, nil // probably a common table expression
+	case t == nil:
+		return i, r.errorf("unknown table %q", tableName)
+	}
+	r.scope = append(r.scope, scoped{table: t, alias: alias, group: group, nullable: nullable})
+	return i, nil
+}
+
+// columnEndingAt returns the column referenced by the token(s) ending at i, such as email or
+// u.email. Returns nil (and no error) if there is no column reference there.
+func (r *resolver) columnEndingAt(i int) (*parser.Column, error) {
+	if !r.ident(i) {
+		return nil, nil
+	}
+	if r.is(i-1, ".") && r.ident(i-2) {
+		col, _, err := r.column(i, r.toks[i-2].Value, r.toks[i].Value)
+		return col, err
+	}
+	col, _, err := r.column(i, "", r.toks[i].Value)
+	return col, err
+}
+
+// columnStartingAt returns the column referenced by the token(s) starting at i, whether it is on the
+// nullable side of an outer join, and the index of the token after it. Returns nil (and no error) if
+// there is no column reference there.
+func (r *resolver) columnStartingAt(i int) (*parser.Column, bool, int, error) {
+	if !r.ident(i) {
+		return nil, false, i, nil
+	}
+	if r.is(i+1, ".") && r.ident(i+2) {
+		col, nullable, err := r.column(i, r.toks[i].Value, r.toks[i+2].Value)
+		return col, nullable, i + 3, err
+	}
+	col, nullable, err := r.column(i, "", r.toks[i].Value)
+	return col, nullable, i + 1, err
+}
+
+// column returns the column with the given name in the table known by qualifier (its alias or name),
+// or in whichever table in scope has it if qualifier is empty, and whether that table is on the
+// nullable side of an outer join. The tables of the innermost query around the token at i are
+// searched first, then those of each query around it (e.g. from a subquery out to the outer query).
+// Returns nil if no table has it, or an error if more than one table of the same query does.
+func (r *resolver) column(i int, qualifier, colName string) (*parser.Column, bool, error) {
+	for group := r.groups[i]; ; group = r.groups[group] {
+		var found *parser.Column
+		var foundIn *scoped
+		for k, s := range r.scope {
+			if s.group != group {
+				continue
+			}
+			if qualifier != "" && !strings.EqualFold(s.alias, qualifier) && !strings.EqualFold(s.table.SQLName(), qualifier) {
+				continue
+			}
+			col := s.table.Column(colName)
+			if col == nil || foundIn != nil && s.table == foundIn.table {
+				continue
+			}
+			if found != nil {
+				return nil, false, r.errorf("column %q is ambiguous; qualify it with its table", colName)
+			}
+			found, foundIn = col, &r.scope[k]
+		}
+		if found != nil {
+			return found, foundIn.nullable, nil
+		}
+		if group < 0 {
+			return nil, false, nil
+		}
+	}
+}
+
+// placeholder is a single parameter in the query's SQL.
+type placeholder struct {
+	start, end int    // start and end are the indexes of the placeholder's first and last tokens.
+	key        string // key identifies placeholders that share an argument (e.g. ":email" or "?2").
+	name       string // name is the parameter's name from the SQL, if it was named.
+}
+
+// placeholders returns every parameter in the query's SQL, in order.
+func (r *resolver) placeholders() []placeholder {
+	ps := []placeholder{}
+	for i := 0; i < len(r.toks); i++ {
+		tok := r.toks[i]
+		if tok.Type != parser.Operator {
+			continue
+		}
+		adjacent := i+1 < len(r.toks) && r.toks[i+1].Pos == tok.Pos+1
+		switch {
+		case tok.Value == "?" && adjacent && r.toks[i+1].Type == parser.Number:
+			ps = append(ps, placeholder{start: i, end: i + 1, key: "?" + r.toks[i+1].Value})
+			i++
+		case tok.Value == "?":
+			ps = append(ps, placeholder{start: i, end: i, key: fmt.Sprintf("?#%d", len(ps))})
+		case strings.Contains(":@$", tok.Value) && adjacent && r.ident(i+1) && r.toks[i+1].Quote == 0:
+			ps = append(ps, placeholder{start: i, end: i + 1, key: ":" + r.toks[i+1].Value, name: r.toks[i+1].Value})
+			i++
+		}
+	}
+	return ps
+}
+
+// resolveParams sets the query's Params and Args from its placeholders, and its SQL with each
+// placeholder replaced by ?.
+func (r *resolver) resolveParams() error {
+	inserted, err := r.insertedColumns()
+	if err != nil {
+		return err
+	}
+	params := map[string]string{} // placeholder key -> param name
+	used := map[string]bool{}
+	for n, p := range r.placeholders() {
+		endTok := r.toks[p.end]
+		r.edits = append(r.edits, edit{start: r.toks[p.start].Pos, end: endTok.Pos + len(endTok.Value), text: "?"})
+
+		if paramName, ok := params[p.key]; ok {
+			r.q.Args = append(r.q.Args, paramName)
+			continue
+		}
+		col, goType, hint, err := r.infer(p, inserted)
+		if err != nil {
+			return err
+		}
+		if col == nil && goType == "" {
+			return r.errorf("cannot infer the type of parameter %d; compare it directly to a column (e.g. email = ?)", n+1)
+		}
+		if col != nil {
+			goType, hint = col.GetGoType(), col.GoName()
+		}
+		if p.name != "" {
+			hint = name.ToGo(p.name)
+		}
+		paramName := goArgName(hint)
+		for i := 2; used[paramName]; i++ {
+			paramName = fmt.Sprintf("%s%d", goArgName(hint), i)
+		}
+		used[paramName] = true
+		params[p.key] = paramName
+		r.q.Params = append(r.q.Params, Param{Name: paramName, GoType: goType})
+		r.q.Args = append(r.q.Args, paramName)
+	}
+	return nil
+}
+
+// rewrite returns the query's Source with its edits applied, which are in order and do not overlap.
+func (r *resolver) rewrite() string {
+	slices.SortFunc(r.edits, func(a, b edit) int { return a.start - b.start })
+	sql := strings.Builder{}
+	last := 0
+	for _, e := range r.edits {
+		sql.WriteString(r.q.Source[last:e.start])
+		sql.WriteString(e.text)
+		last = e.end
+	}
+	sql.WriteString(r.q.Source[last:])
+	return sql.String()
+}
+
+// expandStar records an edit replacing the * (or table.*) from token start up to end with each of
+// the table's columns, prefixed with qualifier if it is not empty (e.g. "u.").
+func (r *resolver) expandStar(start, end int, t *parser.Table, qualifier string) {
+	cols := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		cols[i] = qualifier + col.SQLName()
+	}
+	last := r.toks[end-1]
+	r.edits = append(r.edits, edit{start: r.toks[start].Pos, end: last.Pos + len(last.Value), text: strings.Join(cols, ", ")})
+}
+
+// infer returns the column the placeholder is compared to or inserted into, or the Go type and a
+// name hint if it is not a column (e.g. LIMIT). Returns a nil column and empty type if it cannot
+// tell.
+func (r *resolver) infer(p placeholder, inserted map[int]*parser.Column) (*parser.Column, string, string, error) {
+	prev, next := p.start-1, p.end+1
+	if col, ok := inserted[p.start]; ok {
+		return col, "", "", nil
+	}
+	switch {
+	case r.is(prev, "LIMIT"):
+		return nil, "int64", "Limit", nil
+	case r.is(prev, "OFFSET"):
+		return nil, "int64", "Offset", nil
+	case r.is(prev, "NOT") && r.is(prev-1, "IS"): // col IS NOT ?
+		col, err := r.columnEndingAt(prev - 2)
+		return col, "", "", err
+	case r.isAny(prev, comparisons), r.isAny(prev, comparisonKeywords), r.is(prev, "BETWEEN"): // col = ?
+		col, err := r.columnEndingAt(r.skipNot(prev - 1))
+		return col, "", "", err
+	case r.inList(p): // col IN (?, ?)
+		open := r.groups[p.start]
+		col, err := r.columnEndingAt(r.skipNot(open - 2))
+		return col, "", "", err
+	case r.is(prev, "AND"): // col BETWEEN ? AND ?
+		for _, between := range []int{prev - 2, prev - 3} {
+			if r.is(between, "BETWEEN") {
+				col, err := r.columnEndingAt(r.skipNot(between - 1))
+				return col, "", "", err
+			}
+		}
+	}
+	if r.isAny(next, comparisons) || r.isAny(next, comparisonKeywords) { // ? = col
+		col, _, _, err := r.columnStartingAt(next + 1)
+		return col, "", "", err
+	}
+	return nil, "", "", nil
+}
+
+// inList returns true if the placeholder is an item of its own in an IN list (e.g. either ? in
+// col IN (?, ?)).
+func (r *resolver) inList(p placeholder) bool {
+	open := r.groups[p.start]
+	return open >= 0 && r.is(open-1, "IN") &&
+		(r.is(p.start-1, "(") || r.is(p.start-1, ",")) && (r.is(p.end+1, ")") || r.is(p.end+1, ","))
+}
+
+// skipNot returns the index before i if the token at i is NOT (e.g. in col NOT LIKE ?), or i.
+func (r *resolver) skipNot(i int) int {
+	if r.is(i, "NOT") {
+		return i - 1
+	}
+	return i
+}
+
+// insertedColumns returns the column each token in an INSERT's VALUES list is inserted into, keyed
+// by the token's index, or nil if the query is not an INSERT.
+func (r *resolver) insertedColumns() (map[int]*parser.Column, error) {
+	into := -1
+	for i := range r.toks {
+		if r.is(i, "INTO") && (r.is(0, "INSERT") || r.is(0, "REPLACE")) {
+			into = i
+			break
+		}
+	}
+	if into < 0 || !r.ident(into+1) {
+		return nil, nil
+	}
+	i := into + 1
+	if r.is(i+1, ".") { // schema.table
+		i += 2
+	}
+	t := parser.FindTable(r.tables, r.toks[i].Value)
+	if t == nil {
+		return nil, nil // reported by findTables
+	}
+	for i++; i < len(r.toks) && !r.is(i, "(") && !r.is(i, "VALUES"); i++ {
+		// skip the alias
+	}
+	cols := []*parser.Column{}
+	if r.is(i, "(") { // explicit column list
+		for i++; i < len(r.toks) && !r.is(i, ")"); i++ {
+			if r.is(i, ",") {
+				continue
+			}
+			col := t.Column(r.toks[i].Value)
+			if col == nil {
+				return nil, r.errorf("table %q has no column %q", t.SQLName(), r.toks[i].Value)
+			}
+			cols = append(cols, col)
+		}
+		i++
+	} else {
+		for j := range t.Columns {
+			cols = append(cols, &t.Columns[j])
+		}
+	}
+	if !r.is(i, "VALUES") {
+		return nil, nil // e.g. INSERT ... SELECT, whose parameters are compared like any other
+	}
+	inserted := map[int]*parser.Column{}
+	depth, k := 0, 0
+	for i++; i < len(r.toks); i++ {
+		switch {
+		case r.is(i, "("):
+			depth++
+			if depth == 1 {
+				k = 0
+			}
+		case r.is(i, ")"):
+			depth--
+		case depth == 1 && r.is(i, ","):
+			k++
+		case depth == 1 && k < len(cols) && (r.is(i-1, "(") || r.is(i-1, ",")):
+			inserted[i] = cols[k] // the first token of this value
+		case depth == 0 && !r.is(i, ","):
+			return inserted, nil // end of the VALUES list (e.g. ON CONFLICT or RETURNING)
+		}
+	}
+	return inserted, nil
+}
+
+// resolveResult returns the result of a SELECT, or of a RETURNING clause, or nil if the query
+// returns no rows.
+func (r *resolver) resolveResult() (*Result, error) {
+	start, depth := -1, 0
+find:
+	for i := range r.toks {
+		switch {
+		case r.is(i, "("):
+			depth++
+		case r.is(i, ")"):
+			depth--
+		case depth == 0 && r.is(i, "RETURNING"):
+			start = i + 1
+			break find
+		case depth == 0 && r.is(i, "SELECT") && start < 0:
+			start = i + 1 // the first SELECT, not one after UNION or in INSERT ... SELECT
+		}
+	}
+	if start < 0 {
+		return nil, nil
+	}
+	if r.is(start, "DISTINCT") || r.is(start, "ALL") {
+		start++
+	}
+	// Split the result columns by commas, up to FROM (or the end of RETURNING).
+	items := [][2]int{}
+	itemStart := start
+	depth = 0
+	i := start
+	for ; i < len(r.toks); i++ {
+		if r.is(i, "(") {
+			depth++
+		} else if r.is(i, ")") {
+			depth--
+		} else if depth == 0 && r.is(i, ",") {
+			items = append(items, [2]int{itemStart, i})
+			itemStart = i + 1
+		} else if depth == 0 && r.isAny(i, []string{"FROM", "WHERE", "GROUP", "ORDER", "LIMIT", "UNION"}) {
+			break
+		}
+	}
+	items = append(items, [2]int{itemStart, i})
+
+	res := &Result{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		table, cols, err := r.resultItem(item[0], item[1])
+		if err != nil {
+			return nil, err
+		}
+		if table != nil {
+			if len(items) > 1 {
+				return nil, r.errorf("select either a single table.* (or *) or a list of columns, not both")
+			}
+			return &Result{Table: table}, nil
+		}
+		for _, col := range cols {
+			if seen[col.GoName] {
+				return nil, r.errorf("result column %q is selected more than once; give it an alias", col.SQLName)
+			}
+			seen[col.GoName] = true
+			res.Columns = append(res.Columns, col)
+		}
+	}
+	return res, nil
+}
+
+// topTables returns the tables in the outermost FROM (or the table an INSERT, UPDATE, or DELETE
+// writes to).
+func (r *resolver) topTables() []scoped {
+	top := []scoped{}
+	for _, s := range r.scope {
+		if s.group < 0 {
+			top = append(top, s)
+		}
+	}
+	return top
+}
+
+// resultItem returns the table whose whole rows are selected by the result item between tokens
+// start and end (e.g. * or u.*), or its column (e.g. email AS address).
+func (r *resolver) resultItem(start, end int) (*parser.Table, []Column, error) {
+	if start >= end {
+		return nil, nil, r.errorf("empty result column")
+	}
+	// Split off the alias (e.g. "AS address", or just "address").
+	alias := ""
+	switch {
+	case end-start >= 3 && r.is(end-2, "AS") && r.ident(end-1):
+		alias = r.toks[end-1].Value
+		end -= 2
+	case end-start >= 2 && r.ident(end-1) && (r.ident(end-2) || r.is(end-2, ")")):
+		alias = r.toks[end-1].Value
+		end--
+	}
+	n := end - start
+	switch {
+	case n == 1 && r.is(start, "*"):
+		top := r.topTables()
+		if len(top) != 1 {
+			return nil, nil, r.errorf("SELECT * is only supported with a single table; use table.* or list the columns")
+		}
+		r.expandStar(start, end, top[0].table, "")
+		return top[0].table, nil, nil
+	case n == 3 && r.ident(start) && r.is(start+1, ".") && r.is(start+2, "*"):
+		for _, s := range r.topTables() {
+			if strings.EqualFold(s.alias, r.toks[start].Value) || strings.EqualFold(s.table.SQLName(), r.toks[start].Value) {
+				if s.nullable {
+					return nil, nil, r.errorf("%s.* is on the nullable side of an outer join, so cannot be scanned into its struct; list its columns", r.toks[start].Value)
+				}
+				qualifier := strings.TrimSpace(r.q.Source[r.toks[start].Pos:r.toks[start+1].Pos]) // as written, with any quotes
+				r.expandStar(start, start+3, s.table, qualifier+".")
+				return s.table, nil, nil
+			}
+		}
+		return nil, nil, r.errorf("unknown table %q in %s.*", r.toks[start].Value, r.toks[start].Value)
+	case n == 1 || n == 3 && r.is(start+1, "."):
+		col, nullable, _, err := r.columnStartingAt(start)
+		if err != nil {
+			return nil, nil, err
+		}
+		if col == nil {
+			return nil, nil, r.errorf("unknown result column %q", r.source(start, end))
+		}
+		sqlName := col.SQLName()
+		if alias != "" {
+			sqlName = alias
+		}
+		return nil, []Column{{SQLName: sqlName, GoName: name.ToGo(sqlName), GoType: col.Type.ToGo(col.Nullable || nullable)}}, nil
+	case r.is(start, "count") && r.is(start+1, "(") && r.is(end-1, ")"):
+		if alias == "" { // SQLite names the column after the expression as written (e.g. count(*))
+			return nil, []Column{{SQLName: r.source(start, end), GoName: "Count", GoType: "int64"}}, nil
+		}
+		return nil, []Column{{SQLName: alias, GoName: name.ToGo(alias), GoType: "int64"}}, nil
+	}
+	return nil, nil, r.errorf("cannot infer the type of result column %q; select a column or count(...)", r.source(start, end))
+}
+
+// source returns the query's SQL from token start up to (but not including) token end, as written.
+// Only the last token's length is needed, so it must not be a quoted identifier or string.
+func (r *resolver) source(start, end int) string {
+	last := r.toks[end-1]
+	return r.q.Source[r.toks[start].Pos : last.Pos+len(last.Value)]
+}
+
+// goArgName returns goName (e.g. GroupID) as an unexported Go argument name (e.g. groupID), which
+// must not shadow the generated function's ctx and db arguments or be a Go keyword.
+func goArgName(goName string) string {
+	runes := []rune(goName)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		upper-- // keep the start of the next word (e.g. URLPath -> urlPath)
+	}
+	for i := range upper {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	arg := string(runes)
+	if token.IsKeyword(arg) || arg == "ctx" || arg == "db" {
+		arg += "Arg"
+	}
+	return arg
+}
@@ -2,81 +2,257 @@ package templates
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/name"
 	"github.com/joshsziegler/squirrel/parser"
 )
 
-// WherePKs returns the where clause for the table provided, such as:
-// "WHERE id=:id" or "WHERE artist=:artist, album=:album"
-func WherePKs(t *parser.Table) string {
+// WherePKs returns the where clause for the table provided, binding each primary key through p,
+// such as: "id=:id" or "artist=:artist AND album=:album" (or "id=?" with database/sql)
+func WherePKs(t *parser.Table, p *Params) string {
 	res := ""
 	pks := t.PrimaryKeys()
 	for i, col := range pks {
 		if i > 0 {
 			res += " AND "
 		}
-		res += fmt.Sprintf("%s=:%s", col.SQLName(), col.SQLName())
+		res += fmt.Sprintf("%s=%s", col.SQLName(), p.Bind(col))
 	}
 	return res
 }
 
-// InsertColumns returns the column list for INSERT or VALUES, depending on the last param.
-func InsertColumns(t *parser.Table, value bool) string {
-	cols := []string{}
-	for _, col := range t.Columns {
+// InsertableColumns returns the columns included in INSERT statements, skipping those the DB
+// generates (e.g. rowid aliases, and timestamps configured by config.Timestamps or that DEFAULT to
+// the current time).
+func InsertableColumns(t *parser.Table, cfg *config.Config) []*parser.Column {
+	ts := cfg.TimestampColumns(t.SQLName())
+	cols := []*parser.Column{}
+	for i, col := range t.Columns {
 		switch {
 		case col.AutoIncrement():
 			continue // skip this column (e.g. rowid, or ID)
-		case col.SQLName() == "created_at":
-			continue // skip because the DB should generate this // TODO: Or should we specify datetime('now')?
-		case col.SQLName() == "updated_at":
-			continue //  skip because the DB should generate this // TODO: Or should we specify datetime('now')?
-		case col.SQLName() == "deleted_at":
-			continue //  skip because the DB should generate this
-		case value:
-			cols = append(cols, fmt.Sprintf(":% s", col.SQLName()))
+		case col.DefaultsToNow():
+			continue // skip because the DB should generate this
+		case isColumn(col, ts.Created), isColumn(col, ts.Updated):
+			continue // skip because the DB should DEFAULT this to the current time
+		case isColumn(col, ts.Deleted):
+			continue // skip because new rows are never deleted
+		case col.SQLName() == cfg.SoftDeleteColumn(t.SQLName()):
+			continue // skip because new rows are never soft-deleted
+		case &t.Columns[i] == VersionColumn(t, cfg) && col.HasDefault():
+			continue // skip so new rows start at the schema's default version
 		default:
+			cols = append(cols, &t.Columns[i])
+		}
+	}
+	return cols
+}
+
+// InsertColumns returns the column list for INSERT, or for VALUES if p is not nil, binding each
+// column through p.
+func InsertColumns(t *parser.Table, cfg *config.Config, p *Params) string {
+	cols := []string{}
+	for _, col := range InsertableColumns(t, cfg) {
+		if p != nil {
+			cols = append(cols, p.Bind(col))
+		} else {
 			cols = append(cols, col.SQLName())
 		}
 	}
 	return strings.Join(cols, ", ")
 }
 
-// UpdateColumns returns the column list for UPDATE clauses (e.g. name=:name, updated_at=datetime('now')).
-func UpdateColumns(t *parser.Table) string {
-	cols := []string{}
-	for _, col := range t.Columns {
+// SelectColumns returns every column of the table, in schema order, for a SELECT or RETURNING list
+// (e.g. "id, email, name"). Naming them, rather than using *, keeps reads working if a column is
+// added to the database before the code is regenerated, and matches the order the scan function
+// reads them in.
+func SelectColumns(t *parser.Table) string {
+	cols := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		cols[i] = col.SQLName()
+	}
+	return strings.Join(cols, ", ")
+}
+
+// UpdatableColumns returns the columns an Update may SET: every column except the primary key(s)
+// and those the DB maintains (e.g. the created and updated timestamps).
+func UpdatableColumns(t *parser.Table, cfg *config.Config) []*parser.Column {
+	ts := cfg.TimestampColumns(t.SQLName())
+	cols := []*parser.Column{}
+	for i, col := range t.Columns {
 		switch {
-		case col.AutoIncrement():
-			continue // skip this column (e.g. rowid, or ID)
-		case col.SQLName() == "created_at":
+		case col.PrimaryKey, col.CompositePrimaryKey:
+			continue // identifies the row, so it is matched in WHERE instead
+		case isColumn(col, ts.Created):
 			continue // skip because Created At should not be updated
-		case col.SQLName() == "updated_at":
-			cols = append(cols, "updated_at=datetime('now')") // Use SQLite to update this column
-			continue
+		case isColumn(col, ts.Updated):
+			continue // skip because the DB should update this
+		case col.SQLName() == cfg.SoftDeleteColumn(t.SQLName()):
+			continue // skip because only Delete and Restore should change this
+		case &t.Columns[i] == VersionColumn(t, cfg):
+			continue // skip because every write increments this instead
 		default:
-			cols = append(cols, fmt.Sprintf("%s=:% s", col.SQLName(), col.SQLName()))
+			cols = append(cols, &t.Columns[i])
 		}
 	}
-	return strings.Join(cols, ", ")
+	return cols
 }
 
-// UpsertUpdateColumns returns the column list for DO UPDATE SET.
-func UpsertUpdateColumns(t *parser.Table) string {
+// UpsertUpdateColumns returns the column list for DO UPDATE SET, leaving out any excluded columns
+// (e.g. those in the conflict target, which cannot change), and those the INSERT leaves to the DB.
+func UpsertUpdateColumns(t *parser.Table, cfg *config.Config, exclude ...string) string {
+	ts := cfg.TimestampColumns(t.SQLName())
 	cols := []string{}
-	for _, col := range t.Columns {
+	for _, col := range upsertSetColumns(t, cfg, exclude...) {
+		switch {
+		case col == VersionColumn(t, cfg):
+			cols = append(cols, fmt.Sprintf("%s=%s+1", col.SQLName(), col.SQLName()))
+		case isColumn(*col, ts.Updated): // Use SQLite to update this column
+			cols = append(cols, fmt.Sprintf("%s=%s", col.SQLName(), ts.Format.Expr()))
+		default:
+			cols = append(cols, fmt.Sprintf("%s=EXCLUDED.%s", col.SQLName(), col.SQLName()))
+		}
+	}
+	return strings.Join(cols, ", ")
+}
+
+// upsertSetColumns returns the columns an upsert's DO UPDATE SET writes (see UpsertUpdateColumns).
+func upsertSetColumns(t *parser.Table, cfg *config.Config, exclude ...string) []*parser.Column {
+	ts := cfg.TimestampColumns(t.SQLName())
+	cols := []*parser.Column{}
+	for i, col := range t.Columns {
 		switch {
 		case col.AutoIncrement():
 			continue // skip this column (e.g. rowid, or ID)
-		case col.SQLName() == "created_at":
+		case slices.Contains(exclude, col.SQLName()):
+			continue
+		case &t.Columns[i] == VersionColumn(t, cfg): // incremented
+		case col.SQLName() == cfg.SoftDeleteColumn(t.SQLName()):
+			continue // skip so upserting a soft-deleted row does not restore it
+		case isColumn(col, ts.Created):
 			continue // skip because Created At should not be updated
-		case col.SQLName() == "updated_at": // Use SQLite to update this column
-			cols = append(cols, fmt.Sprintf("%s=datetime('now')", col.SQLName()))
+		case isColumn(col, ts.Updated): // set to the current time
+		case col.DefaultsToNow():
+			continue // skip because EXCLUDED holds the DEFAULT (i.e. now) rather than a value to keep
+		}
+		cols = append(cols, &t.Columns[i])
+	}
+	return cols
+}
+
+// isColumn returns true if name is non-empty and names col.
+func isColumn(col parser.Column, name string) bool {
+	return name != "" && col.SQLName() == name
+}
+
+// UpdatedSet returns the SET clause that stamps the table's updated timestamp column (see
+// config.Timestamps) with the current time, such as "updated_at=datetime('now')", or "" if the
+// table has none.
+func UpdatedSet(t *parser.Table, cfg *config.Config) string {
+	ts := cfg.TimestampColumns(t.SQLName())
+	if ts.Updated == "" || t.Column(ts.Updated) == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s=%s", ts.Updated, ts.Format.Expr())
+}
+
+// ConflictTarget is a set of columns that must be unique, and so can be named by an upsert's
+// ON CONFLICT clause.
+type ConflictTarget struct {
+	Name       string   // Name is the Go suffix for the upsert method (e.g. Email for UpsertOnEmail).
+	Source     string   // Source describes where the target comes from (e.g. "UNIQUE uc_email").
+	Constraint string   // Constraint is the name of the constraint or index, or "" if it is unnamed.
+	Columns    []string // Columns are the SQL names of the target's columns, in order.
+}
+
+// conflictTargetPrefixes are stripped from constraint and index names when naming a ConflictTarget
+// (e.g. uc_owner_channel -> OwnerChannel).
+var conflictTargetPrefixes = []string{"pk_", "uc_", "uq_", "uk_", "ux_", "unique_", "idx_", "ix_"}
+
+// ConflictTargets returns the primary key, UNIQUE constraints, and unique indexes of the table that
+// an upsert can name in ON CONFLICT (cols), in that order. Targets that include a column the DB
+// generates on insert (e.g. an auto-incrementing ID) are skipped since they can never conflict, as
+// are partial and expression indexes, which SQLite cannot match without repeating the index. A
+// target covering the same columns as an earlier one is skipped too.
+func ConflictTargets(t *parser.Table, cfg *config.Config) []ConflictTarget {
+	targets := []ConflictTarget{}
+	add := func(constraintName, source string, cols []string) {
+		insertable := InsertableColumns(t, cfg)
+		for _, col := range cols {
+			if !slices.ContainsFunc(insertable, func(c *parser.Column) bool { return c.SQLName() == col }) {
+				return // generated by the DB, or an expression
+			}
+		}
+		for _, target := range targets {
+			if slices.Equal(target.Columns, cols) {
+				return // already covered
+			}
+		}
+		taken := func(goName string) bool {
+			return slices.ContainsFunc(targets, func(target ConflictTarget) bool { return target.Name == goName })
+		}
+		goName := conflictTargetName(t, constraintName, cols)
+		if taken(goName) { // Name clash (e.g. similar constraint names), so use the columns.
+			goName = conflictTargetName(t, "", cols)
+		}
+		if taken(goName) && constraintName != "" { // then the whole constraint name
+			goName = name.ToGo(constraintName)
+		}
+		for i, base := 2, goName; taken(goName); i++ {
+			goName = fmt.Sprintf("%s%d", base, i)
+		}
+		targets = append(targets, ConflictTarget{Name: goName, Source: source, Constraint: constraintName, Columns: cols})
+	}
+
+	if pks := t.PrimaryKeys(); len(pks) > 0 {
+		cols := make([]string, len(pks))
+		for i, pk := range pks {
+			cols[i] = pk.SQLName()
+		}
+		add(t.PrimaryKeyName, strings.TrimSpace("PRIMARY KEY "+t.PrimaryKeyName), cols)
+	}
+	for _, uc := range t.UniqueConstraints {
+		add(uc.Name, strings.TrimSpace("UNIQUE "+uc.Name), uc.Columns)
+	}
+	for _, idx := range t.Indexes {
+		if !idx.Unique || idx.Partial() {
 			continue
-		default:
-			cols = append(cols, fmt.Sprintf("%s=EXCLUDED.%s", col.SQLName(), col.SQLName()))
 		}
+		add(idx.Name, "UNIQUE INDEX "+idx.Name, idx.Columns)
 	}
-	return strings.Join(cols, ", ")
+	return targets
+}
+
+// conflictTargetName returns the Go name for a conflict target: the constraint or index name
+// without common prefixes (e.g. uc_, idx_) and the table name, singular or plural, wherever it is
+// (e.g. pk_memberships, uc_membership_role), or the column names joined if nothing else is left.
+func conflictTargetName(t *parser.Table, constraintName string, cols []string) string {
+	n := strings.ToLower(constraintName)
+	for _, prefix := range conflictTargetPrefixes {
+		if strings.HasPrefix(n, prefix) {
+			n = strings.TrimPrefix(n, prefix)
+			break
+		}
+	}
+	words := strings.Split(n, "_")
+	for i := range words {
+		for j := len(words); j > i; j-- {
+			if name.ToGo(strings.Join(words[i:j], "_")) == t.GoName() {
+				words = slices.Delete(words, i, j)
+				break
+			}
+		}
+	}
+	n = strings.Trim(strings.Join(words, "_"), "_")
+	if n != "" {
+		return name.ToGo(n)
+	}
+	goName := ""
+	for _, col := range cols {
+		goName += t.Column(col).GoName()
+	}
+	return goName
 }
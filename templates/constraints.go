@@ -0,0 +1,205 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/name"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// checkPrefixes are stripped from CHECK constraint names when naming their sentinel errors (e.g.
+// chk_positive_balance -> ErrAccountPositiveBalanceFailed).
+var checkPrefixes = []string{"chk_", "ck_", "check_"}
+
+// ConstraintTypes writes the ConstraintError type and the helpers each table's classify function
+// (see Constraints) uses to parse SQLite's constraint error messages.
+func ConstraintTypes(w *ShortWriter) {
+	w.N(`
+var (
+	ErrUniqueViolation	= errors.New("UNIQUE constraint failed")
+	ErrForeignKeyViolation	= errors.New("FOREIGN KEY constraint failed")
+	ErrCheckViolation	= errors.New("CHECK constraint failed")
+	ErrNotNullViolation	= errors.New("NOT NULL constraint failed")
+)
+
+// ConstraintError is returned by write methods when SQLite rejects a row for violating one of the
+// table's constraints. errors.Is matches it against Kind, the table's error for the constraint (e.g.
+// ErrUserEmailTaken) if it has one, and the driver's error.
+//
+// SQLite does not say which foreign key failed, so a FOREIGN KEY violation names its constraint and
+// columns only if the table has a single foreign key and no other table references it. Otherwise
+// Constraint is "" and Columns is nil.
+type ConstraintError struct {
+	Kind		error		// Kind is ErrUniqueViolation, ErrForeignKeyViolation, ErrCheckViolation, or ErrNotNullViolation.
+	Table		string		// Table is the table written to (e.g. users).
+	Constraint	string		// Constraint is the name of the constraint or index, or "" if unnamed or unknown.
+	Columns		[]string	// Columns are the constrained columns, or nil if unknown.
+	Sentinel	error		// Sentinel is the table's error for the constraint (e.g. ErrUserEmailTaken), or nil.
+	Err		error		// Err is the driver's error.
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	errs := []error{e.Kind}
+	if e.Sentinel != nil {
+		errs = append(errs, e.Sentinel)
+	}
+	return append(errs, e.Err)
+}
+
+// constraintFailure splits a SQLite error message such as "UNIQUE constraint failed: users.email"
+// into its kind and detail (e.g. "users.email"), or returns a nil kind if err is not a constraint
+// violation. SQLite does not say which foreign key failed, so their detail is always "".
+func constraintFailure(err error) (kind error, detail string) {
+	msg := err.Error()
+	for _, kind := range []error{ErrUniqueViolation, ErrForeignKeyViolation, ErrCheckViolation, ErrNotNullViolation} {
+		_, after, ok := strings.Cut(msg, kind.Error())
+		if !ok {
+			continue
+		}
+		detail = strings.TrimPrefix(after, ": ")
+		if i := strings.LastIndex(detail, " ("); i >= 0 && strings.Trim(detail[i+2:], "0123456789") == ")" {
+			detail = detail[:i] // e.g. modernc.org/sqlite's extended result code, " (2067)"
+		}
+		return kind, detail
+	}
+	return nil, ""
+}
+
+// constraintColumns returns the columns of table named in detail (e.g. "users.a, users.b").
+func constraintColumns(table, detail string) []string {
+	cols := strings.Split(detail, ", ")
+	for i, col := range cols {
+		cols[i] = strings.TrimPrefix(col, table+".")
+	}
+	return cols
+}
+`)
+}
+
+// constraintSentinel is a table's error for one of its constraints, such as ErrUserEmailTaken.
+type constraintSentinel struct {
+	name       string   // name is the Go name of the error variable.
+	message    string   // message is the error's text.
+	kind       string   // kind is the generated Err*Violation that reports it (e.g. ErrUniqueViolation).
+	detail     string   // detail is what SQLite's error message names the constraint by (see constraintFailure).
+	constraint string   // constraint is its name in the schema, or "" if unnamed.
+	columns    []string // columns are the constrained columns, or nil if SQLite does not report them.
+}
+
+// constraintSentinels returns the table's errors for each of its conflict targets (see
+// ConflictTargets), named after their upsert methods (e.g. ErrUserEmailTaken for UpsertOnEmail), and
+// for each named CHECK constraint (e.g. ErrAccountPositiveBalanceFailed).
+func constraintSentinels(t *parser.Table, cfg *config.Config) []constraintSentinel {
+	sentinels := []constraintSentinel{}
+	for _, target := range ConflictTargets(t, cfg) {
+		qualified := make([]string, len(target.Columns))
+		for i, col := range target.Columns {
+			qualified[i] = t.SQLName() + "." + col
+		}
+		sentinels = append(sentinels, constraintSentinel{
+			name:       "Err" + t.GoName() + target.Name + "Taken",
+			message:    fmt.Sprintf("%s already has a row with this %s", t.SQLName(), strings.Join(target.Columns, ", ")),
+			kind:       "ErrUniqueViolation",
+			detail:     strings.Join(qualified, ", "),
+			constraint: target.Constraint,
+			columns:    target.Columns,
+		})
+	}
+	for _, check := range t.CheckConstraints {
+		if check.Name == "" {
+			continue // SQLite reports unnamed checks by their expression, which the parser only normalizes.
+		}
+		n := strings.ToLower(check.Name)
+		for _, prefix := range checkPrefixes {
+			if strings.HasPrefix(n, prefix) {
+				n = strings.TrimPrefix(n, prefix)
+				break
+			}
+		}
+		n = strings.TrimPrefix(n, strings.ToLower(t.SQLName())+"_")
+		sentinels = append(sentinels, constraintSentinel{
+			name:       "Err" + t.GoName() + name.ToGo(n) + "Failed",
+			message:    fmt.Sprintf("%s failed CHECK constraint %s", t.SQLName(), check.Name),
+			kind:       "ErrCheckViolation",
+			detail:     check.Name,
+			constraint: check.Name,
+		})
+	}
+	return sentinels
+}
+
+// classifyFunc returns the name of the table's classify function (e.g. classifyUserError).
+func classifyFunc(t *parser.Table) string {
+	return "classify" + t.GoName() + "Error"
+}
+
+// Constraints writes the table's constraint errors and its classify function, which turns the
+// errors of statements on the table that report a constraint violation into a *ConstraintError (see
+// Op.Classify). tables are all of the schema's tables, which may reference t.
+func Constraints(w *ShortWriter, t *parser.Table, tables []*parser.Table, cfg *config.Config) {
+	sentinels := constraintSentinels(t, cfg)
+	fk := soleForeignKey(t, tables)
+	if len(sentinels) > 0 {
+		w.N("var (")
+		for _, s := range sentinels {
+			w.F("	%s = errors.New(%q)\n", s.name, s.message)
+		}
+		w.N(")\n")
+	}
+	w.F("// %s returns err as a *ConstraintError if it reports a constraint violation\n", classifyFunc(t))
+	w.F("// on '%s', or unchanged if not.\n", t.SQLName())
+	w.F("func %s(err error) error {\n", classifyFunc(t))
+	w.N("	var ce *ConstraintError")
+	w.N("	if err == nil || errors.As(err, &ce) {")
+	w.N("		return err")
+	w.N("	}")
+	w.N("	kind, detail := constraintFailure(err)")
+	w.N("	if kind == nil {")
+	w.N("		return err")
+	w.N("	}")
+	w.F("	e := &ConstraintError{Kind: kind, Table: %q, Err: err}\n", t.SQLName())
+	w.N("	switch {")
+	for _, s := range sentinels {
+		w.F("	case kind == %s && detail == %q:\n", s.kind, s.detail)
+		if s.columns != nil {
+			w.F("		e.Constraint, e.Columns, e.Sentinel = %q, %#v, %s\n", s.constraint, s.columns, s.name)
+		} else {
+			w.F("		e.Constraint, e.Sentinel = %q, %s\n", s.constraint, s.name)
+		}
+	}
+	if fk != nil {
+		w.N("	case kind == ErrForeignKeyViolation:")
+		w.F("		e.Constraint, e.Columns = %q, %#v\n", fk.Name, fk.LocalColumns)
+	}
+	w.N("	case kind == ErrUniqueViolation || kind == ErrNotNullViolation:")
+	w.F("		e.Columns = constraintColumns(%q, detail)\n", t.SQLName())
+	w.N("	}")
+	w.N("	return e")
+	w.N("}\n\n")
+}
+
+// soleForeignKey returns the foreign key that must have failed when a statement on t reports a
+// FOREIGN KEY violation, or nil if there could be several: t has more than one foreign key, or
+// another of the tables references t, so deleting or re-keying its rows can fail theirs.
+func soleForeignKey(t *parser.Table, tables []*parser.Table) *parser.ForeignKey {
+	if len(t.ForeignKeys) != 1 {
+		return nil
+	}
+	for _, other := range tables {
+		if other == t {
+			continue // a self-reference is the sole foreign key itself
+		}
+		for _, fk := range other.ForeignKeys {
+			if strings.EqualFold(fk.Table, t.SQLName()) {
+				return nil
+			}
+		}
+	}
+	return t.ForeignKeys[0]
+}
@@ -0,0 +1,118 @@
+package templates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// Op is a generated function or method that returns errors, such as users.Update, along with the
+// key columns identifying the row it works on. Wrap names both in each error it returns, so a log
+// says which row failed.
+type Op struct {
+	cfg      *config.Config
+	name     string
+	keys     []string // keys are the SQL names of the key columns (e.g. id).
+	exprs    []string // exprs are the Go expressions holding each key's value (e.g. x.ID).
+	classify string   // classify is the function turning constraint violations into a *ConstraintError, if any.
+}
+
+// NewOp returns the operation with the given name (e.g. a query's name), without keys.
+func NewOp(cfg *config.Config, name string) *Op {
+	return &Op{cfg: cfg, name: name}
+}
+
+// TableOp returns the operation for one of the table's generated functions or methods, which is
+// named after the table (e.g. users.Update).
+func TableOp(cfg *config.Config, t *parser.Table, method string) *Op {
+	return NewOp(cfg, t.SQLName()+"."+method)
+}
+
+// Fields adds cols as keys whose values are the fields of the row x (e.g. x.ID).
+func (o *Op) Fields(cols ...*parser.Column) *Op {
+	for _, col := range cols {
+		o.keys = append(o.keys, col.SQLName())
+		o.exprs = append(o.exprs, "x."+col.GoName())
+	}
+	return o
+}
+
+// Args adds cols as keys whose values are the function's arguments of the same name (e.g. ID).
+func (o *Op) Args(cols ...*parser.Column) *Op {
+	for _, col := range cols {
+		o.keys = append(o.keys, col.SQLName())
+		o.exprs = append(o.exprs, col.GoName())
+	}
+	return o
+}
+
+// Classify has Wrap pass errors from the database through the table's classify function (see
+// Constraints), so constraint violations are returned as a *ConstraintError.
+func (o *Op) Classify(t *parser.Table) *Op {
+	o.classify = classifyFunc(t)
+	return o
+}
+
+// unclassified returns a copy of o that does not classify errors, for those that did not come
+// from a statement on its table (e.g. a hook's).
+func (o *Op) unclassified() *Op {
+	c := *o
+	c.classify = ""
+	return &c
+}
+
+// Wrap returns the Go expression that wraps the error expression err (e.g. "err" or
+// "ErrStaleVersion") before it is returned, using the configured config.ErrorStrategy.
+func (o *Op) Wrap(err string) string {
+	if o.classify != "" && err == "err" {
+		err = o.classify + "(err)"
+	}
+	switch o.cfg.ErrorWrapping() {
+	case config.ErrorsHook:
+		args := []string{err, strconv.Quote(o.name)}
+		for i := range o.keys {
+			args = append(args, strconv.Quote(o.keys[i]), o.exprs[i])
+		}
+		return fmt.Sprintf("wrapErr(%s)", strings.Join(args, ", "))
+	case config.ErrorsStdlib:
+		return fmt.Sprintf("fmt.Errorf(%s%s, %s)", strconv.Quote(o.message()+": %w"), o.args(), err)
+	default:
+		return fmt.Sprintf("merry.Prependf(%s, %s%s)", err, strconv.Quote(o.message()), o.args())
+	}
+}
+
+// message returns the format string naming the operation and its keys (e.g. "users.Update (id=%v)").
+func (o *Op) message() string {
+	if len(o.keys) == 0 {
+		return o.name
+	}
+	keys := make([]string, len(o.keys))
+	for i, key := range o.keys {
+		keys[i] = key + "=%v"
+	}
+	return fmt.Sprintf("%s (%s)", o.name, strings.Join(keys, ", "))
+}
+
+// args returns the key values for message, each with a leading comma and space.
+func (o *Op) args() string {
+	args := ""
+	for _, expr := range o.exprs {
+		args += ", " + expr
+	}
+	return args
+}
+
+// insertKeys returns the primary key columns known before a row is inserted, skipping an
+// auto-incrementing key whose value the database assigns.
+func insertKeys(t *parser.Table) []*parser.Column {
+	keys := []*parser.Column{}
+	for _, col := range t.PrimaryKeys() {
+		if !col.AutoIncrement() {
+			keys = append(keys, col)
+		}
+	}
+	return keys
+}
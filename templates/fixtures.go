@@ -0,0 +1,320 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/name"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// fixtureParent is a row referenced by a table's foreign key, which the table's fixture holds a
+// fixture of (e.g. the User of a Membership for user_id).
+type fixtureParent struct {
+	field    string           // field is the name of the fixture's field holding the parent's fixture.
+	table    *parser.Table    // table is the referenced table.
+	local    []*parser.Column // local are the foreign key's columns in the fixture's table.
+	columns  []*parser.Column // columns are the columns of table that local reference, in the same order.
+	required bool             // required if InsertFixture creates the parent when its key is not set.
+}
+
+// FixtureSeq writes the sequence counter shared by the fixture constructors if cfg.Fixtures is set.
+func FixtureSeq(w *ShortWriter, cfg *config.Config) {
+	if !cfg.Fixtures {
+		return
+	}
+	w.N(`
+// fixtureSeq numbers the fixtures made by the New*Fixture functions, so their unique columns differ.
+var fixtureSeq struct {
+	sync.Mutex
+	n int64
+}
+
+// nextFixtureSeq returns the next fixture number, starting at 1.
+func nextFixtureSeq() int64 {
+	fixtureSeq.Lock()
+	defer fixtureSeq.Unlock()
+	fixtureSeq.n++
+	return fixtureSeq.n
+}
+`)
+}
+
+// fixtureParents returns the rows t references through foreign keys to the tables that are
+// generated. A parent is required if its key is NOT NULL, unless it is t itself or it would close a
+// cycle of required parents, since those rows could never be inserted first.
+func fixtureParents(t *parser.Table, tables []*parser.Table) []fixtureParent {
+	byName := map[string]*parser.Table{}
+	for _, table := range tables {
+		byName[strings.ToLower(table.SQLName())] = table
+	}
+	resolve := func(table *parser.Table, fk *parser.ForeignKey) (fixtureParent, bool) {
+		parent := byName[strings.ToLower(fk.Table)]
+		if parent == nil {
+			return fixtureParent{}, false // ignored, or not in the schema
+		}
+		p := fixtureParent{table: parent, required: parent != table}
+		refs := fk.Columns
+		if len(refs) == 0 { // REFERENCES parent, which means its primary key
+			for _, pk := range parent.PrimaryKeys() {
+				refs = append(refs, pk.SQLName())
+			}
+		}
+		if len(refs) != len(fk.LocalColumns) {
+			return fixtureParent{}, false
+		}
+		for i, local := range fk.LocalColumns {
+			col, ref := table.Column(local), parent.Column(refs[i])
+			if col == nil || ref == nil || col.Type != ref.Type {
+				return fixtureParent{}, false
+			}
+			p.local, p.columns = append(p.local, col), append(p.columns, ref)
+			p.required = p.required && !col.Nullable
+		}
+		return p, true
+	}
+
+	// Drop the required parents that close a cycle, visiting tables (and then their parents) in
+	// order so the same edges are dropped whichever table is being generated.
+	cyclic := map[*parser.ForeignKey]bool{}
+	const visiting, visited = 1, 2
+	state := map[*parser.Table]int{}
+	var visit func(table *parser.Table)
+	visit = func(table *parser.Table) {
+		state[table] = visiting
+		for _, fk := range table.ForeignKeys {
+			p, ok := resolve(table, fk)
+			switch {
+			case !ok || !p.required:
+			case state[p.table] == visiting:
+				cyclic[fk] = true
+			case state[p.table] == 0:
+				visit(p.table)
+			}
+		}
+		state[table] = visited
+	}
+	for _, table := range tables {
+		if state[table] == 0 {
+			visit(table)
+		}
+	}
+
+	used := map[string]bool{t.GoName(): true}
+	for _, col := range t.Columns {
+		used[col.GoName()] = true
+	}
+	parents := []fixtureParent{}
+	for _, fk := range t.ForeignKeys {
+		p, ok := resolve(t, fk)
+		if !ok {
+			continue
+		}
+		p.required = p.required && !cyclic[fk]
+		locals := make([]string, len(fk.LocalColumns))
+		for i, local := range fk.LocalColumns {
+			locals[i] = strings.ToLower(local)
+		}
+		candidates := []string{p.table.GoName()}
+		if len(locals) == 1 { // e.g. user_id -> User, owner_id -> Owner, or owner -> OwnerUser
+			candidates = []string{name.ToGo(strings.TrimSuffix(locals[0], "_id")), name.ToGo(locals[0]) + p.table.GoName()}
+		}
+		candidates = append(candidates, name.ToGo(strings.Join(locals, "_"))+p.table.GoName())
+		for _, c := range candidates {
+			if c != "" && !used[c] {
+				p.field = c
+				break
+			}
+		}
+		if p.field == "" {
+			continue
+		}
+		used[p.field] = true
+		parents = append(parents, p)
+	}
+	return parents
+}
+
+// fixtureColumns returns the columns a new fixture fills: those Insert writes that are NOT NULL (or
+// in the primary key), except the foreign keys InsertFixture sets from the fixture's parents.
+func fixtureColumns(t *parser.Table, cfg *config.Config, parents []fixtureParent) []*parser.Column {
+	cols := []*parser.Column{}
+	for _, col := range InsertableColumns(t, cfg) {
+		fromParent := slices.ContainsFunc(parents, func(p fixtureParent) bool {
+			return p.required && slices.Contains(p.local, col)
+		})
+		if fromParent || (col.Nullable && !col.PrimaryKey && !col.CompositePrimaryKey) {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// Fixture writes the table's fixture type if cfg.Fixtures is set: New<T>Fixture, which fills a row
+// with values the schema accepts, and InsertFixture, which inserts it after the rows it references.
+// tables are the tables being generated, which the fixture may reference.
+func Fixture(w *ShortWriter, t *parser.Table, tables []*parser.Table, cfg *config.Config) {
+	if !cfg.Fixtures {
+		return
+	}
+	T := t.GoName()
+	parents := fixtureParents(t, tables)
+	unique := map[string]bool{}
+	for _, target := range ConflictTargets(t, cfg) {
+		for _, col := range target.Columns {
+			unique[col] = true
+		}
+	}
+
+	w.F("// %sFixture is a row of '%s' for tests (see New%sFixture), along with fixtures of the rows\n", T, t.SQLName(), T)
+	w.N("// it references, which InsertFixture inserts first. Set one to share a row between fixtures.")
+	w.F("type %sFixture struct {\n", T)
+	w.F("	*%s\n", T)
+	for _, p := range parents {
+		local := make([]string, len(p.local))
+		for i, col := range p.local {
+			local[i] = col.SQLName()
+		}
+		w.F("	%s *%sFixture // %s is the row %s references.\n", p.field, p.table.GoName(), p.field, strings.Join(local, ", "))
+	}
+	w.N("}\n")
+
+	w.F("// New%sFixture returns a row of '%s' with a valid value in each NOT NULL column, numbering\n", T, t.SQLName())
+	w.N("// those that must be unique so fixtures do not conflict, and then applies overrides in order.")
+	if slices.ContainsFunc(parents, func(p fixtureParent) bool { return p.required }) {
+		w.N("// The rows its NOT NULL foreign keys reference are made by InsertFixture, unless an override")
+		w.N("// sets the key.")
+	}
+	w.F("func New%sFixture(overrides ...func(*%s)) *%sFixture {\n", T, T, T)
+	cols := fixtureColumns(t, cfg, parents)
+	if slices.ContainsFunc(cols, func(col *parser.Column) bool { return unique[col.SQLName()] }) {
+		w.N("	n := nextFixtureSeq()")
+	}
+	w.F("	x := &%s{\n", T)
+	for _, col := range cols {
+		v := Sample(t, col, 1)
+		if unique[col.SQLName()] {
+			v = fixtureSeqValue(t, col, "n")
+		}
+		w.F("		%s: %s,\n", col.GoName(), v)
+	}
+	w.N("	}")
+	w.N("	for _, override := range overrides {")
+	w.N("		override(x)")
+	w.N("	}")
+	w.F("	return &%sFixture{%s: x}\n", T, T)
+	w.N("}\n")
+
+	w.N("// InsertFixture inserts the row after inserting each fixture it references that does not exist")
+	w.N("// yet and setting the foreign key to it. A NOT NULL foreign key not set by an override gets a")
+	w.N("// new fixture.")
+	w.F("func (f *%sFixture) InsertFixture(ctx context.Context, db DB) error {\n", T)
+	for _, p := range parents {
+		if p.required {
+			zero := make([]string, len(p.local))
+			for i, col := range p.local {
+				zero[i] = fixtureZero(col, "f."+T+"."+col.GoName())
+			}
+			w.F("	if f.%s == nil && %s {\n", p.field, strings.Join(zero, " && "))
+			w.F("		f.%s = New%sFixture()\n", p.field, p.table.GoName())
+			w.N("	}")
+		}
+		w.F("	if f.%s != nil {\n", p.field)
+		w.F("		if !f.%s.Exists() {\n", p.field)
+		w.F("			if err := f.%s.InsertFixture(ctx, db); err != nil {\n", p.field)
+		w.N("				return err")
+		w.N("			}")
+		w.N("		}")
+		for i, col := range p.local {
+			ref := p.columns[i]
+			w.F("		f.%s.%s = %s\n", T, col.GoName(), convertNull(ref, col, "f."+p.field+"."+p.table.GoName()+"."+ref.GoName()))
+		}
+		w.N("	}")
+	}
+	w.F("	return f.%s.Insert(ctx, db)\n", T)
+	w.N("}\n")
+}
+
+// fixtureSeqValue returns a Go expression of col's type holding a value numbered by the int64
+// variable n, so each fixture's value differs. Values that CHECK constraints restrict to a list are
+// not numbered (see Sample).
+func fixtureSeqValue(t *parser.Table, col *parser.Column, n string) string {
+	l := checkLimits(t, col)
+	var v, field string
+	switch {
+	case len(l.in) > 0, col.Type == parser.BOOL:
+		return Sample(t, col, 1)
+	case col.Type == parser.INT:
+		v, field = n, "Int64"
+		switch {
+		case !math.IsInf(l.min, 0) && math.Ceil(l.min) != 1:
+			v = fmt.Sprintf("%d + %s", int64(math.Ceil(l.min))-1, n)
+		case math.IsInf(l.min, 0) && !math.IsInf(l.max, 0):
+			v = fmt.Sprintf("%d - %s", int64(math.Floor(l.max))+1, n)
+		}
+	case col.Type == parser.FLOAT:
+		base := 0.5
+		switch {
+		case !math.IsInf(l.min, 0):
+			base = l.min
+		case !math.IsInf(l.max, 0):
+			base = l.max - 1e6
+		}
+		v, field = fmt.Sprintf("%s + float64(%s)", strconv.FormatFloat(base, 'g', -1, 64), n), "Float64"
+	case col.Type == parser.TEXT, col.Type == parser.BLOB:
+		prefix := col.SQLName() + " "
+		if len(prefix)+1 < l.minLen {
+			prefix += strings.Repeat("x", l.minLen-len(prefix)-1)
+		}
+		if l.maxLen >= 0 && len(prefix)+4 > l.maxLen {
+			prefix = prefix[:max(0, l.maxLen-4)] // leave room for the number
+		}
+		v, field = fmt.Sprintf("%q + strconv.FormatInt(%s, 10)", prefix, n), "String"
+		if col.Type == parser.BLOB {
+			return fmt.Sprintf("[]byte(%s)", v)
+		}
+	case col.Type == parser.DATETIME:
+		v, field = fmt.Sprintf("time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC).Add(time.Duration(%s) * time.Second)", n), "Time"
+	}
+	if col.Nullable {
+		return fmt.Sprintf("%s{%s: %s, Valid: true}", col.GetGoType(), field, v)
+	}
+	return v
+}
+
+// fixtureZero returns a Go condition that is true if v, the value of col, is its type's zero value.
+func fixtureZero(col *parser.Column, v string) string {
+	switch col.Type {
+	case parser.INT, parser.FLOAT:
+		return v + " == 0"
+	case parser.BOOL:
+		return "!" + v
+	case parser.TEXT:
+		return v + ` == ""`
+	case parser.BLOB:
+		return "len(" + v + ") == 0"
+	default: // DATETIME
+		return v + ".IsZero()"
+	}
+}
+
+// convertNull returns a Go expression converting v, the value of column from, to the type of column
+// to, which has the same data type but may differ in whether it is nullable.
+func convertNull(from, to *parser.Column, v string) string {
+	field := map[parser.Datatype]string{
+		parser.INT: "Int64", parser.FLOAT: "Float64", parser.BOOL: "Bool", parser.TEXT: "String", parser.DATETIME: "Time",
+	}[from.Type]
+	switch {
+	case field == "" || from.Nullable == to.Nullable: // e.g. BLOB, which is []byte either way
+		return v
+	case to.Nullable:
+		return fmt.Sprintf("%s{%s: %s, Valid: true}", to.GetGoType(), field, v)
+	default:
+		return v + "." + field
+	}
+}
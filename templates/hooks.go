@@ -0,0 +1,35 @@
+package templates
+
+import "strings"
+
+// hookInterfaces are the optional lifecycle interfaces a generated struct may implement in a
+// hand-written file, keyed by method name. Write methods check for them at runtime (see CallHook).
+var hookInterfaces = map[string]string{
+	"BeforeInsert": "BeforeInserter",
+	"AfterInsert":  "AfterInserter",
+	"BeforeUpdate": "BeforeUpdater",
+	"AfterUpdate":  "AfterUpdater",
+	"BeforeUpsert": "BeforeUpserter",
+	"AfterUpsert":  "AfterUpserter",
+	"BeforeDelete": "BeforeDeleter",
+	"AfterDelete":  "AfterDeleter",
+}
+
+// CallHook writes a call to the hook method (e.g. BeforeInsert) on x if it implements the hook's
+// interface (e.g. BeforeInserter), returning its error. depth is the number of tabs to indent by,
+// and ret is returned before the error (e.g. "false, "), or "" if the function only returns an error.
+func CallHook(w *ShortWriter, op *Op, method string, depth int, ret string) {
+	indent := strings.Repeat("\t", depth)
+	w.F("%sif h, ok := any(x).(%s); ok {\n", indent, hookInterfaces[method])
+	w.F("%s	if err := h.%s(ctx, db); err != nil {\n", indent, method)
+	w.F("%s		return %s%s\n", indent, ret, op.unclassified().Wrap("err"))
+	w.F("%s	}\n", indent)
+	w.F("%s}\n", indent)
+}
+
+// CallHooks writes a loop calling the hook method on each of rows that implements it (see CallHook).
+func CallHooks(w *ShortWriter, op *Op, method string) {
+	w.N("	for _, x := range rows {")
+	CallHook(w, op, method, 2, "")
+	w.N("	}")
+}
@@ -0,0 +1,236 @@
+package templates
+
+import (
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+)
+
+// PreparedQueries writes the Queries type, which implements DB by running each statement as a
+// prepared statement that is prepared the first time it runs and reused after, plus the helpers the
+// generated functions use to run statements through it.
+func PreparedQueries(w *ShortWriter, cfg *config.Config) {
+	pkg, stmt, prepare, rebind := "sql", "*sql.Stmt", "PrepareContext", "StmtContext"
+	if cfg.Runtime != config.DatabaseSQL {
+		pkg, stmt, prepare, rebind = "sqlx", "*sqlx.Stmt", "PreparexContext", "StmtxContext"
+	}
+	r := strings.NewReplacer("PKG", pkg, "STMT", stmt, "PREPARE", prepare, "REBIND", rebind)
+
+	w.N(r.Replace(`
+// Queries runs statements as prepared statements, preparing each the first time it runs and reusing
+// it after, instead of preparing it for a single execution. It implements DB, so it can be passed to
+// any generated function or method in place of the *PKG.DB it was created with. Statements built per
+// call (e.g. Update's SET list of changed fields, or multi-row inserts) run on DB unprepared, so only
+// a fixed set is cached. Use WithTx to run the same statements in a transaction, and Close to release
+// them. It is safe for concurrent use.
+type Queries struct {
+	DB // DB runs what is not cached, such as PrepareContext: the *PKG.DB, or the *PKG.Tx from WithTx.
+
+	db     *PKG.DB
+	tx     *PKG.Tx  // tx is the transaction from WithTx, or nil.
+	parent *Queries // parent holds the statements prepared on db that tx rebinds, or nil.
+	mu     sync.Mutex
+	stmts  map[string]STMT`))
+	if cfg.Runtime != config.DatabaseSQL {
+		w.N("	named  map[string]*sqlx.NamedStmt")
+	}
+	w.N(r.Replace(`}
+
+// NewQueries returns Queries that prepare their statements on db.
+func NewQueries(db *PKG.DB) *Queries {
+	return newQueries(db, db, nil, nil)
+}
+
+// WithTx returns Queries that run in tx, which must have been started on the same database as q.
+// They reuse the statements q has prepared, and prepare any others on tx, which releases them all
+// when it commits or rolls back.
+func (q *Queries) WithTx(tx *PKG.Tx) *Queries {
+	return newQueries(tx, q.db, tx, q)
+}
+
+func newQueries(conn DB, db *PKG.DB, tx *PKG.Tx, parent *Queries) *Queries {
+	return &Queries{
+		DB:     conn,
+		db:     db,
+		tx:     tx,
+		parent: parent,
+		stmts:  map[string]STMT{},`))
+	if cfg.Runtime != config.DatabaseSQL {
+		w.N("		named:  map[string]*sqlx.NamedStmt{},")
+	}
+	w.N(r.Replace(`	}
+}
+
+// Close releases the prepared statements. Any Queries from WithTx must not be used afterwards.
+func (q *Queries) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	errs := []error{}
+	for query, stmt := range q.stmts {
+		errs = append(errs, stmt.Close())
+		delete(q.stmts, query)
+	}`))
+	if cfg.Runtime != config.DatabaseSQL {
+		w.N(`	for query, stmt := range q.named {
+		errs = append(errs, stmt.Close())
+		delete(q.named, query)
+	}`)
+	}
+	w.N(r.Replace(`	return errors.Join(errs...)
+}
+
+// stmt returns the prepared statement for query, preparing it if this is its first use.
+func (q *Queries) stmt(ctx context.Context, query string) (STMT, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if stmt, ok := q.stmts[query]; ok {
+		return stmt, nil
+	}
+	if q.parent != nil {
+		q.parent.mu.Lock()
+		dbStmt, ok := q.parent.stmts[query]
+		q.parent.mu.Unlock()
+		if ok { // rebind the database's statement to the transaction
+			q.stmts[query] = q.tx.REBIND(ctx, dbStmt)
+			return q.stmts[query], nil
+		}
+	}
+	var stmt STMT
+	var err error
+	if q.tx != nil { // prepare on the transaction, which may hold the only connection
+		stmt, err = q.tx.PREPARE(ctx, query)
+	} else {
+		stmt, err = q.db.PREPARE(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (q *Queries) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (q *Queries) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (q *Queries) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return q.DB.QueryRowContext(ctx, query, args...) // reports the same error from Scan
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+`))
+	if cfg.Runtime != config.DatabaseSQL {
+		w.N(`
+func (q *Queries) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return err
+	}
+	return stmt.GetContext(ctx, dest, args...)
+}
+
+func (q *Queries) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return err
+	}
+	return stmt.SelectContext(ctx, dest, args...)
+}
+
+// namedStmt returns the prepared statement for the query with named parameters, preparing it if
+// this is its first use.
+func (q *Queries) namedStmt(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if stmt, ok := q.named[query]; ok {
+		return stmt, nil
+	}
+	if q.parent != nil {
+		q.parent.mu.Lock()
+		dbStmt, ok := q.parent.named[query]
+		q.parent.mu.Unlock()
+		if ok { // rebind the database's statement to the transaction
+			q.named[query] = q.tx.NamedStmtContext(ctx, dbStmt)
+			return q.named[query], nil
+		}
+	}
+	var stmt *sqlx.NamedStmt
+	var err error
+	if q.tx != nil { // prepare on the transaction, which may hold the only connection
+		stmt, err = q.tx.PrepareNamedContext(ctx, query)
+	} else {
+		stmt, err = q.db.PrepareNamedContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.named[query] = stmt
+	return stmt, nil
+}
+
+func (q *Queries) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	stmt, err := q.namedStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, arg)
+}
+
+// errRow is a row whose Scan returns err, for a query that failed before it could run.
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
+
+// stmtRow is a row from a statement prepared for it alone, which Scan closes once the row is read.
+type stmtRow struct {
+	*sqlx.Row
+	stmt *sqlx.NamedStmt
+}
+
+func (r stmtRow) Scan(dest ...any) error {
+	defer r.stmt.Close()
+	return r.Row.Scan(dest...)
+}
+
+// namedQueryRow runs query with its named parameters bound from arg, and returns its first row. If
+// db is a *Queries the statement is prepared once; otherwise it is prepared for this call only.
+func namedQueryRow(ctx context.Context, db DB, query string, arg any) scanner {
+	if q, ok := db.(*Queries); ok {
+		stmt, err := q.namedStmt(ctx, query)
+		if err != nil {
+			return errRow{err}
+		}
+		return stmt.QueryRowxContext(ctx, arg)
+	}
+	stmt, err := db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return errRow{err}
+	}
+	return stmtRow{stmt.QueryRowxContext(ctx, arg), stmt} // a transaction's statement can't close before its row is read
+}`)
+	}
+	w.N(`
+// uncached returns the DB that db runs statements on, bypassing Queries for statements that vary
+// too much to be worth preparing (e.g. multi-row inserts).
+func uncached(db DB) DB {
+	if q, ok := db.(*Queries); ok {
+		return q.DB
+	}
+	return db
+}
+`)
+}
@@ -0,0 +1,149 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/queries"
+)
+
+// WriteQueries writes a function for each query, which must have been resolved (see
+// queries.Resolve). Call it after Write, since it uses the DB interface and table structs.
+func WriteQueries(f io.Writer, qs []*queries.Query, cfg *config.Config) {
+	w := NewShortWriter(f)
+	for _, q := range qs {
+		Query(w, q, cfg)
+	}
+}
+
+// Query writes the function for a single annotated query, and a struct for its rows if it returns
+// more than one column that is not a whole table row.
+func Query(w *ShortWriter, q *queries.Query, cfg *config.Config) {
+	op := NewOp(cfg, q.Name)
+	// Work out the type of each row returned, and whether it is one of the table structs.
+	rowType, loaded := "", false
+	switch {
+	case q.Result == nil:
+	case q.Result.Table != nil:
+		rowType, loaded = q.Result.Table.GoName(), true
+	case len(q.Result.Columns) == 1:
+		rowType = q.Result.Columns[0].GoType
+	default:
+		rowType = q.Name + "Row"
+		w.F("// %s is a row returned by %s.\n", rowType, q.Name)
+		w.F("type %s struct {\n", rowType)
+		for _, col := range q.Result.Columns {
+			w.F("	%s %s `db:%s`\n", col.GoName, col.GoType, strconv.Quote(col.SQLName))
+		}
+		w.N("}\n")
+	}
+	scalar := q.Result != nil && q.Result.Table == nil && len(q.Result.Columns) == 1
+
+	params := []string{"ctx context.Context", "db DB"}
+	for _, p := range q.Params {
+		params = append(params, fmt.Sprintf("%s %s", p.Name, p.GoType))
+	}
+	args := sqlLiteral(q.SQL)
+	if len(q.Args) > 0 {
+		args += ", " + strings.Join(q.Args, ", ")
+	}
+
+	if len(q.Doc) == 0 {
+		w.F("// %s runs the %s query from %s.\n", q.Name, q.Kind, q.File)
+	}
+	for _, line := range q.Doc {
+		w.F("// %s\n", line)
+	}
+	switch {
+	case q.Kind == queries.Exec:
+		w.F("func %s(%s) error {\n", q.Name, strings.Join(params, ", "))
+		w.F("	_, err := db.ExecContext(ctx, %s)\n", args)
+		w.N("	if err != nil {")
+		w.F("		return %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	return nil")
+	case q.Kind == queries.ExecRows:
+		w.F("func %s(%s) (int64, error) {\n", q.Name, strings.Join(params, ", "))
+		w.F("	res, err := db.ExecContext(ctx, %s)\n", args)
+		w.N("	if err != nil {")
+		w.F("		return 0, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	n, err := res.RowsAffected()")
+		w.N("	if err != nil {")
+		w.F("		return 0, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	return n, nil")
+	case q.Kind == queries.One && scalar:
+		w.F("func %s(%s) (%s, error) {\n", q.Name, strings.Join(params, ", "), rowType)
+		w.F("	var v %s\n", rowType)
+		w.F("	err := %s\n", scanQueryRow(q, fmt.Sprintf("db.QueryRowContext(ctx, %s)", args), "v"))
+		NotFoundOnNoRows(w, op, "v, ")
+		w.N("	if err != nil {")
+		w.F("		return v, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	return v, nil")
+	case q.Kind == queries.One:
+		w.F("func %s(%s) (*%s, error) {\n", q.Name, strings.Join(params, ", "), rowType)
+		w.F("	row := %s{}\n", rowType)
+		w.F("	err := %s\n", scanQueryRow(q, fmt.Sprintf("db.QueryRowContext(ctx, %s)", args), "row"))
+		NotFoundOnNoRows(w, op, "nil, ")
+		w.N("	if err != nil {")
+		w.F("		return nil, %s\n", op.Wrap("err"))
+		w.N("	}")
+		if loaded {
+			w.N("	row.loaded()")
+		}
+		w.N("	return &row, nil")
+	case q.Kind == queries.Many:
+		elem, row, appended := rowType, "v", "v"
+		if !scalar {
+			elem, row, appended = "*"+rowType, "row", "&row"
+		}
+		w.F("func %s(%s) ([]%s, error) {\n", q.Name, strings.Join(params, ", "), elem)
+		w.F("	rows, err := db.QueryContext(ctx, %s)\n", args)
+		w.N("	if err != nil {")
+		w.F("		return nil, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	defer rows.Close()")
+		w.F("	all := []%s{}\n", elem)
+		w.N("	for rows.Next() {")
+		if scalar {
+			w.F("		var v %s\n", rowType)
+		} else {
+			w.F("		row := %s{}\n", rowType)
+		}
+		w.F("		if err := %s; err != nil {\n", scanQueryRow(q, "rows", row))
+		w.F("			return nil, %s\n", op.Wrap("err"))
+		w.N("		}")
+		if loaded {
+			w.N("		row.loaded()")
+		}
+		w.F("		all = append(all, %s)\n", appended)
+		w.N("	}")
+		w.N("	if err := rows.Err(); err != nil {")
+		w.F("		return nil, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	return all, nil")
+	}
+	w.N("}\n\n")
+}
+
+// scanQueryRow returns the expression scanning the current row of src (a *sql.Row or *sql.Rows)
+// into the variable dst, without reflection: the table's scan function for whole rows, or Scan with
+// the address of each field or of dst itself.
+func scanQueryRow(q *queries.Query, src, dst string) string {
+	switch {
+	case q.Result.Table != nil:
+		return fmt.Sprintf("%s(%s, &%s)", ScanFunc(q.Result.Table), src, dst)
+	case len(q.Result.Columns) == 1:
+		return fmt.Sprintf("%s.Scan(&%s)", src, dst)
+	}
+	fields := make([]string, len(q.Result.Columns))
+	for i, col := range q.Result.Columns {
+		fields[i] = fmt.Sprintf("&%s.%s", dst, col.GoName)
+	}
+	return fmt.Sprintf("%s.Scan(%s)", src, strings.Join(fields, ", "))
+}
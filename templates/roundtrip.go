@@ -0,0 +1,357 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// RoundTrip writes a test of the generated Go for tables (see Write), which creates ddl, the schema
+// they were parsed from, in an in-memory SQLite database, then inserts, reads, updates, upserts, and
+// deletes a row of every table with values from Sample.
+func RoundTrip(f io.Writer, ddl string, tables []*parser.Table, cfg *config.Config) {
+	var body bytes.Buffer
+	w := NewShortWriter(&body)
+	pkg, open := "sql", "sql.Open"
+	if cfg.Runtime != config.DatabaseSQL {
+		pkg, open = "sqlx", "sqlx.Open"
+	}
+	w.N("// roundTripSchema is the schema this package was generated from.")
+	w.F("const roundTripSchema = %s\n\n", sqlLiteral(ddl))
+	w.N("// roundTripDB returns an in-memory database with roundTripSchema created in it. Foreign keys are")
+	w.N("// not enforced, so each table can be tested without rows in the tables it references.")
+	w.F("func roundTripDB(t *testing.T) *%s.DB {\n", pkg)
+	w.N("	t.Helper()")
+	w.F("	db, err := %s(%q, \":memory:\")\n", open, cfg.TestDriverName())
+	w.N("	if err != nil {")
+	w.N("		t.Fatal(err)")
+	w.N("	}")
+	w.N("	db.SetMaxOpenConns(1) // each connection to :memory: opens a new, empty database")
+	w.N("	t.Cleanup(func() { db.Close() })")
+	w.N("	if _, err := db.Exec(roundTripSchema); err != nil {")
+	w.N("		t.Fatal(err)")
+	w.N("	}")
+	w.N("	if _, err := db.Exec(\"PRAGMA foreign_keys = OFF\"); err != nil {")
+	w.N("		t.Fatal(err)")
+	w.N("	}")
+	w.N("	return db")
+	w.N("}\n")
+	for _, t := range tables {
+		if t.InternalUse() || slices.Contains(cfg.IgnoreTables, t.SQLName()) {
+			continue
+		}
+		RoundTripTable(w, t, cfg)
+	}
+
+	imports := []string{"context"}
+	if strings.Contains(body.String(), "sql.") {
+		imports = append(imports, "database/sql")
+	}
+	if strings.Contains(body.String(), "errors.") {
+		imports = append(imports, "errors")
+	}
+	imports = append(imports, "testing")
+	if strings.Contains(body.String(), "time.") {
+		imports = append(imports, "time")
+	}
+	h := NewShortWriter(f)
+	h.F("// Code generated by squirrel; DO NOT EDIT.\n\n")
+	h.F("package %s\n\n", cfg.Package)
+	h.N("import (")
+	for _, imp := range imports {
+		h.F("	%q\n", imp)
+	}
+	h.N("")
+	if cfg.Runtime != config.DatabaseSQL {
+		h.N(`	"github.com/jmoiron/sqlx"`)
+	}
+	h.F("	_ %q\n", cfg.TestDriver)
+	h.N(")\n")
+	h.F("%s", body.String())
+}
+
+// RoundTripTable writes the table's round-trip test. Tables without a primary key are only inserted
+// and read back with All, since they have no getters, updates, or deletes.
+func RoundTripTable(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	T := t.GoName()
+	pk := t.PrimaryKeys()
+	w.F("func TestRoundTrip%s(t *testing.T) {\n", T)
+	w.N("	ctx := context.Background()")
+	w.N("	db := roundTripDB(t)")
+	w.F("	x := &%s{\n", T)
+	for _, col := range InsertableColumns(t, cfg) {
+		w.F("		%s: %s,\n", col.GoName(), Sample(t, col, 1))
+	}
+	w.N("	}")
+	w.N("	if err := x.Insert(ctx, db); err != nil {")
+	w.N("		t.Fatal(err)")
+	w.N("	}")
+	if len(pk) < 1 {
+		w.N("	rows := 0")
+		w.F("	for got, err := range %sAll(ctx, db) {\n", T)
+		w.N("		if err != nil {")
+		w.N("			t.Fatalf(\"All: %v\", err)")
+		w.N("		}")
+		w.F("		roundTripCheck%s(t, \"All\", got, x)\n", T)
+		w.N("		rows++")
+		w.N("	}")
+		w.N("	if rows != 1 {")
+		w.N("		t.Fatalf(\"All: got %d rows, want 1\", rows)")
+		w.N("	}")
+		w.N("}\n")
+		roundTripCheck(w, t)
+		return
+	}
+
+	names, args := make([]string, len(pk)), make([]string, len(pk))
+	for i, col := range pk {
+		names[i] = col.GoName()
+		args[i] = "x." + col.GoName()
+	}
+	get := fmt.Sprintf("%sGetBy%s(ctx, db, %s)", T, strings.Join(names, ""), strings.Join(args, ", "))
+	getName := "GetBy" + strings.Join(names, "")
+	reread := func(after string) {
+		w.F("	got, err = %s\n", get)
+		w.N("	if err != nil {")
+		w.F("		t.Fatalf(\"%s after %s: %%v\", err)\n", getName, after)
+		w.N("	}")
+		w.F("	roundTripCheck%s(t, \"%s after %s\", got, x)\n", T, getName, after)
+	}
+	w.F("	got, err := %s\n", get)
+	w.N("	if err != nil {")
+	w.F("		t.Fatalf(\"%s: %%v\", err)\n", getName)
+	w.N("	}")
+	w.F("	roundTripCheck%s(t, \"%s\", got, x)\n", T, getName)
+	for _, col := range t.Columns {
+		if !t.SingleColumnUnique(col.SQLName()) || col.Nullable {
+			continue
+		}
+		w.F("	got, err = %sGetBy%s(ctx, db, x.%s)\n", T, col.GoName(), col.GoName())
+		w.N("	if err != nil {")
+		w.F("		t.Fatalf(\"GetBy%s: %%v\", err)\n", col.GoName())
+		w.N("	}")
+		w.F("	roundTripCheck%s(t, \"GetBy%s\", got, x)\n", T, col.GoName())
+	}
+	if cols := UpdatableColumns(t, cfg); len(cols) > 0 {
+		w.F("	x.%s = %s\n", cols[0].GoName(), Sample(t, cols[0], 2))
+		w.N("	if err := x.Update(ctx, db); err != nil {")
+		w.N("		t.Fatalf(\"Update: %v\", err)")
+		w.N("	}")
+		reread("Update")
+	}
+	w.N("	if err := x.Upsert(ctx, db); err != nil {")
+	w.N("		t.Fatalf(\"Upsert: %v\", err)")
+	w.N("	}")
+	reread("Upsert")
+	w.N("	if err := x.Delete(ctx, db); err != nil {")
+	w.N("		t.Fatalf(\"Delete: %v\", err)")
+	w.N("	}")
+	w.F("	if _, err := %s; !errors.Is(err, ErrNotFound) {\n", get)
+	w.F("		t.Fatalf(\"%s after Delete: got %%v, want ErrNotFound\", err)\n", getName)
+	w.N("	}")
+	w.N("}\n")
+	roundTripCheck(w, t)
+}
+
+// roundTripCheck writes the function reporting each field of a row read from the table that
+// differs from the row written.
+func roundTripCheck(w *ShortWriter, t *parser.Table) {
+	w.F("func roundTripCheck%s(t *testing.T, op string, got, want *%s) {\n", t.GoName(), t.GoName())
+	w.N("	t.Helper()")
+	for _, col := range t.Columns {
+		f := col.GoName()
+		switch col.GetGoType() {
+		case "time.Time":
+			w.F("	if !got.%s.Equal(want.%s) {\n", f, f)
+		case "sql.NullTime":
+			w.F("	if got.%s.Valid != want.%s.Valid || !got.%s.Time.Equal(want.%s.Time) {\n", f, f, f, f)
+		case "[]byte":
+			w.F("	if string(got.%s) != string(want.%s) {\n", f, f)
+		default:
+			w.F("	if got.%s != want.%s {\n", f, f)
+		}
+		w.F("		t.Errorf(\"%%s: %s = %%v, want %%v\", op, got.%s, want.%s)\n", col.SQLName(), f, f)
+		w.N("	}")
+	}
+	w.N("}\n")
+}
+
+// sampleLimits are the limits a table's CHECK constraints place on one of its columns, as far as
+// Sample understands them: comparisons with a number, BETWEEN, IN, and length().
+type sampleLimits struct {
+	min, max       float64  // min and max bound the value, inclusively.
+	minLen, maxLen int      // minLen and maxLen bound the length of a TEXT or BLOB, or maxLen is -1.
+	in             []string // in are the SQL literals the value must be one of, or nil.
+}
+
+// checkLimits returns the limits the table's CHECK constraints place on col. Integer columns round
+// exclusive bounds to the next whole number.
+func checkLimits(t *parser.Table, col *parser.Column) sampleLimits {
+	l := sampleLimits{min: math.Inf(-1), max: math.Inf(1), maxLen: -1}
+	step := 1e-3 // the smallest step past an exclusive bound (e.g. > 0)
+	if col.Type == parser.INT {
+		step = 1
+	}
+	limit := func(op string, n float64, length bool) {
+		if length {
+			switch op {
+			case ">":
+				l.minLen = max(l.minLen, int(n)+1)
+			case ">=", "=", "==":
+				l.minLen = max(l.minLen, int(n))
+			}
+			switch {
+			case op == "<" && (l.maxLen < 0 || int(n)-1 < l.maxLen):
+				l.maxLen = int(n) - 1
+			case (op == "<=" || op == "=" || op == "==") && (l.maxLen < 0 || int(n) < l.maxLen):
+				l.maxLen = int(n)
+			}
+			return
+		}
+		switch op {
+		case ">":
+			l.min = max(l.min, n+step)
+		case ">=":
+			l.min = max(l.min, n)
+		case "<":
+			l.max = min(l.max, n-step)
+		case "<=":
+			l.max = min(l.max, n)
+		case "=", "==":
+			l.min, l.max = n, n
+		}
+	}
+	flip := map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<=", "=": "=", "==": "=="}
+	isCol := func(tok string) bool {
+		return strings.EqualFold(strings.Trim(tok, "\"`[]"), col.SQLName())
+	}
+	for _, check := range t.CheckConstraints {
+		tokens := strings.Fields(check.Expr)
+		at := func(i int) string {
+			if i < len(tokens) {
+				return tokens[i]
+			}
+			return ""
+		}
+		// number returns the number starting at token i (e.g. "-" "5"), and the tokens it spans.
+		number := func(i int) (float64, int, bool) {
+			sign, span := 1.0, 1
+			if s := at(i); s == "-" || s == "+" {
+				if s == "-" {
+					sign = -1
+				}
+				i, span = i+1, 2
+			}
+			n, err := strconv.ParseFloat(at(i), 64)
+			return sign * n, span, err == nil
+		}
+		for i := 0; i < len(tokens); i++ {
+			switch {
+			case isCol(at(i)) && flip[at(i+1)] != "":
+				if n, _, ok := number(i + 2); ok {
+					limit(at(i+1), n, false)
+				}
+			case isCol(at(i)) && strings.EqualFold(at(i+1), "BETWEEN"):
+				lo, span, ok := number(i + 2)
+				hi, _, ok2 := number(i + 3 + span)
+				if ok && ok2 && strings.EqualFold(at(i+2+span), "AND") {
+					limit(">=", lo, false)
+					limit("<=", hi, false)
+				}
+			case isCol(at(i)) && strings.EqualFold(at(i+1), "IN") && at(i+2) == "(":
+				l.in = nil
+				for j := i + 3; j < len(tokens) && tokens[j] != ")"; j++ {
+					if tokens[j] != "," {
+						l.in = append(l.in, tokens[j])
+					}
+				}
+			case (strings.EqualFold(at(i), "length") || strings.EqualFold(at(i), "char_length")) &&
+				at(i+1) == "(" && isCol(at(i+2)) && at(i+3) == ")" && flip[at(i+4)] != "":
+				if n, _, ok := number(i + 5); ok {
+					limit(at(i+4), n, true)
+				}
+			default:
+				if n, span, ok := number(i); ok && flip[at(i+span)] != "" && isCol(at(i+span+1)) {
+					limit(flip[at(i+span)], n, false) // e.g. 0 < balance
+				}
+			}
+		}
+	}
+	return l
+}
+
+// Sample returns a Go expression of col's type holding a value the table's CHECK constraints on col
+// accept (see checkLimits). The first sample (n=1) is col's default if it has one; later ones differ
+// from each other, so a test can change the column.
+func Sample(t *parser.Table, col *parser.Column, n int) string {
+	l := checkLimits(t, col)
+	var v, field string
+	switch col.Type {
+	case parser.INT:
+		i := float64(n)
+		switch {
+		case len(l.in) > 0:
+			i, _ = strconv.ParseFloat(l.in[(n-1)%len(l.in)], 64)
+		case n == 1 && col.DefaultInt.Valid:
+			i = float64(col.DefaultInt.Int64)
+		case !math.IsInf(l.min, 0):
+			i = math.Ceil(l.min) + float64(n-1)
+		case !math.IsInf(l.max, 0):
+			i = math.Floor(l.max) - float64(n-1)
+		}
+		v, field = strconv.FormatInt(int64(min(i, l.max)), 10), "Int64"
+	case parser.FLOAT:
+		f := float64(n) + 0.5
+		switch {
+		case len(l.in) > 0:
+			f, _ = strconv.ParseFloat(l.in[(n-1)%len(l.in)], 64)
+		case n == 1 && col.DefaultFloat.Valid:
+			f = col.DefaultFloat.Float64
+		case !math.IsInf(l.min, 0):
+			f = l.min + float64(n-1)
+		case !math.IsInf(l.max, 0):
+			f = l.max - float64(n-1)
+		}
+		v, field = strconv.FormatFloat(min(f, l.max), 'g', -1, 64), "Float64"
+		if !strings.ContainsAny(v, ".e") {
+			v += ".0"
+		}
+	case parser.BOOL:
+		v, field = strconv.FormatBool(n%2 == 1), "Bool"
+		if n == 1 && col.DefaultBool.Valid {
+			v = strconv.FormatBool(col.DefaultBool.Bool)
+		}
+	case parser.TEXT, parser.BLOB:
+		s := fmt.Sprintf("%s %d", col.SQLName(), n)
+		switch {
+		case len(l.in) > 0:
+			s = strings.ReplaceAll(strings.Trim(l.in[(n-1)%len(l.in)], "'"), "''", "'")
+		case n == 1 && col.DefaultString.Valid:
+			s = col.DefaultString.String
+		default:
+			if len(s) < l.minLen {
+				s += strings.Repeat("x", l.minLen-len(s))
+			}
+			if l.maxLen >= 0 && len(s) > l.maxLen {
+				s = s[len(s)-l.maxLen:] // keep the number, so samples still differ
+			}
+		}
+		if col.Type == parser.BLOB {
+			return fmt.Sprintf("[]byte(%q)", s)
+		}
+		v, field = strconv.Quote(s), "String"
+	case parser.DATETIME:
+		v, field = fmt.Sprintf("time.Date(2024, time.January, %d, 12, 0, 0, 0, time.UTC)", n), "Time"
+	}
+	if col.Nullable {
+		return fmt.Sprintf("%s{%s: %s, Valid: true}", col.GetGoType(), field, v)
+	}
+	return v
+}
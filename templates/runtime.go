@@ -0,0 +1,94 @@
+package templates
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// Params builds the parameters of a statement that binds a struct's fields. With sqlx they are
+// named (e.g. :email) and bound from the struct itself. With database/sql they are positional ?
+// placeholders, so the field bound to each is recorded in Args, in the order they are added.
+type Params struct {
+	named bool
+	Args  []string // Args are the Go expressions bound to each ? placeholder, in order (e.g. x.Email).
+}
+
+// NewParams returns empty Params for the configured runtime.
+func NewParams(cfg *config.Config) *Params {
+	return &Params{named: cfg.Runtime != config.DatabaseSQL}
+}
+
+// Bind returns the placeholder for col, and records its field as the next argument if positional.
+func (p *Params) Bind(col *parser.Column) string {
+	if p.named {
+		return ":" + col.SQLName()
+	}
+	p.Args = append(p.Args, "x."+col.GoName())
+	return "?"
+}
+
+// ArgList returns the arguments following the query in a call binding these Params, with a leading
+// comma and space: the struct itself (", x") if named, or each field (e.g. ", x.Name, x.ID").
+func (p *Params) ArgList() string {
+	if p.named {
+		return ", x"
+	}
+	if len(p.Args) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(p.Args, ", ")
+}
+
+// rawSQL returns lines of SQL as a Go raw string literal starting on a new line, with each line
+// indented by indent (e.g. "\t\t").
+func rawSQL(indent string, lines ...string) string {
+	return "`\n" + indent + strings.Join(lines, "\n"+indent) + "`"
+}
+
+// sqlLiteral returns sql as a Go string literal, using a raw string unless it contains a backtick
+// (e.g. a `quoted` identifier).
+func sqlLiteral(sql string) string {
+	if strings.Contains(sql, "`") {
+		return strconv.Quote(sql)
+	}
+	return "`" + sql + "`"
+}
+
+// ScanReturning writes the statement running query, the Go expression for SQL that binds this
+// struct's fields through p and RETURNs its row, and scans the row back into x. err is left for the
+// caller to check (e.g. for sql.ErrNoRows).
+func ScanReturning(w *ShortWriter, t *parser.Table, query string, p *Params) {
+	scanReturningOn(w, t, "db", query, p)
+}
+
+// scanReturningOn is ScanReturning, running query on the Go expression db (e.g. uncached(db)).
+func scanReturningOn(w *ShortWriter, t *parser.Table, db, query string, p *Params) {
+	if !p.named {
+		w.F("	err := %s(%s.QueryRowContext(ctx,%s%s), x)\n", ScanFunc(t), db, query, p.ArgList())
+		return
+	}
+	w.F("	err := %s(namedQueryRow(ctx, %s,%s, x), x)\n", ScanFunc(t), db, query)
+}
+
+// Value writes a method returning the field for an updatable column by its SQL name, which Update
+// uses to bind the columns reported by Changed to positional parameters. Only database/sql needs
+// it, since sqlx binds them by name.
+func Value(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	cols := UpdatableColumns(t, cfg)
+	if cfg.Runtime != config.DatabaseSQL || len(t.PrimaryKeys()) < 1 || len(cols) < 1 {
+		return
+	}
+	w.N("// value returns the field for the named column, as reported by Changed.")
+	w.F("func (x *%s) value(column string) any {\n", t.GoName())
+	w.N("	switch column {")
+	for _, col := range cols {
+		w.F("	case \"%s\":\n", col.SQLName())
+		w.F("		return x.%s\n", col.GoName())
+	}
+	w.N("	}")
+	w.N("	return nil")
+	w.N("}\n\n")
+}
@@ -0,0 +1,26 @@
+package templates
+
+import (
+	"strings"
+
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// ScanFunc returns the name of the table's scan function (e.g. scanUser).
+func ScanFunc(t *parser.Table) string {
+	return "scan" + t.GoName()
+}
+
+// Scan writes a function that scans a row selected with SelectColumns into the table's struct,
+// passing each field's address to Scan directly instead of matching columns to db tags through
+// reflection (e.g. sqlx's StructScan).
+func Scan(w *ShortWriter, t *parser.Table) {
+	fields := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		fields[i] = "&x." + col.GoName()
+	}
+	w.F("// %s scans a row of the columns %s into x, in order.\n", ScanFunc(t), SelectColumns(t))
+	w.F("func %s(row scanner, x *%s) error {\n", ScanFunc(t), t.GoName())
+	w.F("	return row.Scan(%s)\n", strings.Join(fields, ", "))
+	w.N("}\n\n")
+}
@@ -0,0 +1,258 @@
+package templates
+
+import (
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/name"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// schemaOrder returns tables with each after the tables it references (other than itself), and
+// otherwise in the given order. Tables in a cycle of foreign keys keep their order.
+func schemaOrder(tables []*parser.Table) []*parser.Table {
+	byName := map[string]*parser.Table{}
+	for _, t := range tables {
+		byName[strings.ToLower(t.SQLName())] = t
+	}
+	ordered := []*parser.Table{}
+	seen := map[*parser.Table]bool{}
+	var visit func(t *parser.Table)
+	visit = func(t *parser.Table) {
+		if seen[t] {
+			return
+		}
+		seen[t] = true // before its parents, so a cycle ends here
+		for _, fk := range t.ForeignKeys {
+			if parent := byName[strings.ToLower(fk.Table)]; parent != nil {
+				visit(parent)
+			}
+		}
+		ordered = append(ordered, t)
+	}
+	for _, t := range tables {
+		visit(t)
+	}
+	return ordered
+}
+
+// Schema writes the Schema constant holding the CREATE TABLE and CREATE INDEX statements of the
+// parsed tables, printed canonically (see parser.Format), CreateSchema, which runs them, SchemaHash,
+// which fingerprints them, and CheckSchema, which compares their text to a database's. tables are
+// every parsed table, including those not generated, in the order they were declared.
+func Schema(w *ShortWriter, tables []*parser.Table, cfg *config.Config) {
+	consts, stmts := []string{}, []string{}
+	add := func(constName, ddl string) {
+		consts = append(consts, "	"+constName+" = "+sqlLiteral(ddl))
+		stmts = append(stmts, constName)
+	}
+	for _, t := range schemaOrder(tables) {
+		add("schema"+t.GoName()+"Table", t.CreateSQL())
+		for i := range t.Indexes {
+			add("schema"+name.ToGo(t.Indexes[i].Name)+"Index", t.IndexSQL(&t.Indexes[i]))
+		}
+	}
+
+	if len(stmts) > 0 {
+		w.N("\nconst (")
+		w.N(strings.Join(consts, "\n"))
+		w.N(")")
+	}
+	schema := `""`
+	if len(stmts) > 0 {
+		schema = strings.Join(stmts, ` + ";\n\n" +`+"\n\t") + ` + ";\n"`
+	}
+	w.N("\n// Schema holds the CREATE TABLE and CREATE INDEX statements of the schema this package was")
+	w.N("// generated from, printed from the parsed tables the same way however they were written, with")
+	w.N("// each table after the tables it references and before its indexes.")
+	w.F("const Schema = %s\n\n", schema)
+	w.N("// schemaStatements are the statements in Schema, in order.")
+	w.N("var schemaStatements = []string{")
+	for _, stmt := range stmts {
+		w.F("	%s,\n", stmt)
+	}
+	w.N("}")
+
+	r := strings.NewReplacer("CREATE_WRAP", NewOp(cfg, "CreateSchema").Wrap("err"), "CHECK_WRAP", NewOp(cfg, "CheckSchema").Wrap("err"))
+	w.N(r.Replace(`
+// CreateSchema runs the statements in Schema in a transaction, or in a savepoint if db is a TX (see
+// WithTx). Tables and indexes declared IF NOT EXISTS are skipped if they exist, and any other that
+// exists is an error.
+func CreateSchema(ctx context.Context, db DB) error {
+	return WithTx(ctx, db, nil, func(tx TX) error {
+		for _, stmt := range schemaStatements {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return CREATE_WRAP
+			}
+		}
+		return nil
+	})
+}
+
+// SchemaHash is a SHA-256 fingerprint of the parsed schema: the statements in Schema, normalized as
+// CheckSchema does. Since Schema prints the parsed tables, schemas that declare the same tables hash
+// the same however they were written.
+var SchemaHash = schemaHash(schemaStatements)
+
+// ErrSchemaMismatch is returned by CheckSchema when a database's schema is not Schema.
+var ErrSchemaMismatch = errors.New("database schema does not match the generated code")
+
+// SchemaMismatchError lists how a database's tables and indexes differ from Schema's, as their
+// CREATE statements normalized the way SchemaHash does. It matches ErrSchemaMismatch with errors.Is.
+type SchemaMismatchError struct {
+	Missing    []string // Missing are the statements in Schema that the database does not have.
+	Unexpected []string // Unexpected are the statements the database has that Schema does not.
+}
+
+func (e *SchemaMismatchError) Error() string {
+	msg := ErrSchemaMismatch.Error()
+	for _, stmt := range e.Missing {
+		msg += "; missing: " + stmt
+	}
+	for _, stmt := range e.Unexpected {
+		msg += "; unexpected: " + stmt
+	}
+	return msg
+}
+
+func (e *SchemaMismatchError) Is(target error) bool { return target == ErrSchemaMismatch }
+
+// CheckSchema returns a *SchemaMismatchError if the tables and indexes in the database's
+// sqlite_schema differ from Schema's. A service can call it when it starts to refuse a database its
+// code was not generated for. It compares the text SQLite keeps of each CREATE statement, ignoring
+// comments, whitespace, the case of unquoted words, how identifiers are quoted, IF NOT EXISTS, and
+// the order of statements. So it accepts a database made by CreateSchema, but not one whose tables
+// were created by statements written another way, even if they declare the same tables.
+func CheckSchema(ctx context.Context, db DB) error {
+	rows, err := db.QueryContext(ctx, "SELECT sql FROM sqlite_schema WHERE type IN ('table', 'index') AND sql IS NOT NULL AND substr(name, 1, 7) != 'sqlite_'")
+	if err != nil {
+		return CHECK_WRAP
+	}
+	defer rows.Close()
+	live := []string{}
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return CHECK_WRAP
+		}
+		live = append(live, stmt)
+	}
+	if err := rows.Err(); err != nil {
+		return CHECK_WRAP
+	}
+	if schemaHash(live) == SchemaHash {
+		return nil
+	}
+	want, got := map[string]bool{}, map[string]bool{}
+	for _, stmt := range schemaStatements {
+		want[normalizeSQL(stmt)] = true
+	}
+	for _, stmt := range live {
+		got[normalizeSQL(stmt)] = true
+	}
+	mismatch := &SchemaMismatchError{}
+	for _, stmt := range schemaStatements {
+		if stmt = normalizeSQL(stmt); !got[stmt] {
+			mismatch.Missing = append(mismatch.Missing, stmt)
+		}
+	}
+	for _, stmt := range live {
+		if stmt = normalizeSQL(stmt); !want[stmt] {
+			mismatch.Unexpected = append(mismatch.Unexpected, stmt)
+		}
+	}
+	err = mismatch
+	return CHECK_WRAP
+}
+
+// schemaHash returns the hex SHA-256 of the statements normalized and sorted.
+func schemaHash(stmts []string) string {
+	normalized := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		normalized[i] = normalizeSQL(stmt)
+	}
+	slices.Sort(normalized)
+	sum := sha256.Sum256([]byte(strings.Join(normalized, ";\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeSQL returns a CREATE statement without comments, a closing semicolon, or IF NOT EXISTS,
+// with unquoted words lowercased, identifiers double-quoted however they were quoted, and
+// whitespace collapsed to a single space between words, so the statement compares equal however
+// it was formatted. SQLite keeps CREATE statements in sqlite_schema as they were written, except
+// for IF NOT EXISTS.
+func normalizeSQL(stmt string) string {
+	var b strings.Builder
+	space := false
+	word := func() { // write a space between words, but not next to punctuation
+		if space && b.Len() > 0 && !strings.ContainsRune("(),", rune(b.String()[b.Len()-1])) {
+			b.WriteByte(' ')
+		}
+		space = false
+	}
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ';':
+			space = true
+		case c == '-' && strings.HasPrefix(stmt[i:], "--"):
+			if end := strings.IndexByte(stmt[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(stmt)
+			}
+			space = true
+		case c == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			if end := strings.Index(stmt[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(stmt)
+			}
+			space = true
+		case c == '(' || c == ')' || c == ',':
+			b.WriteByte(c)
+			space = false
+		case c == '\'' || c == '"' || c == '\x60' || c == '[': // \x60 is a backquote
+			q := c
+			if c == '[' {
+				q = ']'
+			}
+			j := i + 1
+			for ; j < len(stmt); j++ {
+				if stmt[j] == q {
+					if q == ']' || j+1 >= len(stmt) || stmt[j+1] != q {
+						break
+					}
+					j++ // a doubled quote
+				}
+			}
+			j = min(j, len(stmt)-1)
+			word()
+			if c == '\'' {
+				b.WriteString(stmt[i : j+1])
+			} else {
+				value := stmt[i+1 : j]
+				if q != ']' {
+					value = strings.ReplaceAll(value, string(q)+string(q), string(q))
+				}
+				b.WriteString("\"" + strings.ReplaceAll(value, "\"", "\"\"") + "\"")
+			}
+			i = j
+		default:
+			word()
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			b.WriteByte(c)
+		}
+	}
+	s := b.String()
+	for _, create := range []string{"create table ", "create index ", "create unique index "} {
+		if strings.HasPrefix(s, create+"if not exists ") {
+			s = create + strings.TrimPrefix(s, create+"if not exists ")
+		}
+	}
+	return s
+}
+`))
+}
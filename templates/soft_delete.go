@@ -0,0 +1,162 @@
+package templates
+
+import (
+	"fmt"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// SoftDeleteColumn returns the table's soft-delete column (see config.Config.SoftDelete), or nil if
+// it has none. The column must be nullable, since NULL marks a row that has not been deleted, and the
+// table must have a primary key to delete and restore rows by.
+func SoftDeleteColumn(t *parser.Table, cfg *config.Config) *parser.Column {
+	col := t.Column(cfg.SoftDeleteColumn(t.SQLName()))
+	if col == nil || !col.Nullable || len(t.PrimaryKeys()) < 1 {
+		return nil
+	}
+	return col
+}
+
+// ReadVariant is one version of a generated getter or list function, which differ only in whether
+// they return soft-deleted rows.
+type ReadVariant struct {
+	Suffix string // Suffix for the function name (e.g. WithDeleted).
+	Filter string // Filter is the condition on the soft-delete column, or "" to return every row.
+	Doc    string // Doc explains which rows are returned, or "" if the table has no soft deletes.
+}
+
+// ReadVariants returns the versions of a getter (or list function if list is true) to generate for
+// the table, where base is the function name without a suffix. Tables without soft deletes get a
+// single variant returning every row. Otherwise the default excludes soft-deleted rows, and a
+// WithDeleted variant includes them. Lists also get an OnlyDeleted variant.
+func ReadVariants(t *parser.Table, cfg *config.Config, base string, list bool) []ReadVariant {
+	col := SoftDeleteColumn(t, cfg)
+	if col == nil {
+		return []ReadVariant{{}}
+	}
+	variants := []ReadVariant{
+		{
+			Filter: col.SQLName() + " IS NULL",
+			Doc:    fmt.Sprintf("Soft-deleted rows are excluded; use %sWithDeleted to include them.", base),
+		},
+		{
+			Suffix: "WithDeleted",
+			Doc:    fmt.Sprintf("Includes soft-deleted rows; use %s to exclude them.", base),
+		},
+	}
+	if list {
+		variants = append(variants, ReadVariant{
+			Suffix: "OnlyDeleted",
+			Filter: col.SQLName() + " IS NOT NULL",
+			Doc:    "Only includes soft-deleted rows.",
+		})
+	}
+	return variants
+}
+
+// WriteDoc writes the comment line explaining which rows this variant returns, if any.
+func (v ReadVariant) WriteDoc(w *ShortWriter) {
+	if v.Doc != "" {
+		w.F("// %s\n", v.Doc)
+	}
+}
+
+// And returns cond combined with this variant's filter (e.g. "id=? AND deleted_at IS NULL").
+func (v ReadVariant) And(cond string) string {
+	if v.Filter == "" {
+		return cond
+	}
+	return cond + " AND " + v.Filter
+}
+
+// Where returns a WHERE clause for this variant's filter, with a leading space, or "" if it has none.
+func (v ReadVariant) Where() string {
+	if v.Filter == "" {
+		return ""
+	}
+	return " WHERE " + v.Filter
+}
+
+// SoftDelete writes a Delete method that sets the soft-delete column instead of removing the row.
+func SoftDelete(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	col := SoftDeleteColumn(t, cfg)
+	w.F("// Delete this row by setting %s, leaving it in the database, and update this struct with\n", col.SQLName())
+	w.N("// DB-generated values. Use Restore to undo this, or HardDelete to remove the row.")
+	if vcol := VersionColumn(t, cfg); vcol != nil {
+		w.F("// Returns ErrStaleVersion if %s no longer matches the row in the database, or it has already\n", vcol.SQLName())
+		w.N("// been deleted.")
+	} else {
+		w.N("// Returns ErrNotFound if the row has already been deleted.")
+	}
+	w.F("func (x *%s) Delete(ctx context.Context, db DB) error {\n", t.GoName())
+	w.N("	switch {")
+	w.N("	case !x._exists: // doesn't exist")
+	w.N("		return nil")
+	w.N("	case x._deleted:")
+	w.N("		return nil")
+	w.N("	}")
+	op := TableOp(cfg, t, "Delete").Classify(t).Fields(t.PrimaryKeys()...)
+	CallHook(w, op, "BeforeDelete", 1, "")
+	p := NewParams(cfg)
+	ScanReturning(w, t, rawSQL("\t\t\t",
+		fmt.Sprintf("UPDATE %s", t.SQLName()),
+		fmt.Sprintf("SET %s=%s%s", col.SQLName(), cfg.TimestampColumns(t.SQLName()).Format.Expr(), VersionSet(t, cfg)),
+		fmt.Sprintf("WHERE %s AND %s IS NULL", WhereRow(t, cfg, p), col.SQLName()),
+		fmt.Sprintf("RETURNING %s", SelectColumns(t))), p)
+	MissingOnNoRows(w, t, cfg, op, "")
+	w.N("	if err != nil {")
+	w.F("		return %s\n", op.Wrap("err"))
+	w.N("	}")
+	w.N("	x.loaded()")
+	CallHook(w, op, "AfterDelete", 1, "")
+	w.N("	return nil")
+	w.N("}\n\n")
+}
+
+// Restore writes a method that clears the soft-delete column, undoing a soft Delete.
+func Restore(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	col := SoftDeleteColumn(t, cfg)
+	if col == nil {
+		return
+	}
+	w.F("// Restore this soft-deleted row by clearing %s, and update this struct with DB-generated values.\n", col.SQLName())
+	w.F("func (x *%s) Restore(ctx context.Context, db DB) error {\n", t.GoName())
+	op := TableOp(cfg, t, "Restore").Classify(t).Fields(t.PrimaryKeys()...)
+	w.N("	if !x._exists {")
+	w.F("		return %s\n", op.Wrap("ErrRestoreDoesNotExist"))
+	w.N("	}")
+	p := NewParams(cfg)
+	ScanReturning(w, t, rawSQL("\t\t\t",
+		fmt.Sprintf("UPDATE %s", t.SQLName()),
+		fmt.Sprintf("SET %s=NULL%s", col.SQLName(), VersionSet(t, cfg)),
+		fmt.Sprintf("WHERE %s", WhereRow(t, cfg, p)),
+		fmt.Sprintf("RETURNING %s", SelectColumns(t))), p)
+	MissingOnNoRows(w, t, cfg, op, "")
+	w.N("	if err != nil {")
+	w.F("		return %s\n", op.Wrap("err"))
+	w.N("	}")
+	w.N("	x.loaded()")
+	w.N("	return nil")
+	w.N("}\n\n")
+}
+
+// HardDelete writes a method that removes a soft-deletable row from the database for good.
+func HardDelete(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	if SoftDeleteColumn(t, cfg) == nil {
+		return
+	}
+	w.N("// HardDelete removes this row from the database, whether or not it has been soft-deleted.")
+	w.F("func (x *%s) HardDelete(ctx context.Context, db DB) error {\n", t.GoName())
+	w.N("	if !x._exists {")
+	w.N("		return nil")
+	w.N("	}")
+	op := TableOp(cfg, t, "HardDelete").Classify(t).Fields(t.PrimaryKeys()...)
+	CallHook(w, op, "BeforeDelete", 1, "")
+	DeleteExec(w, t, cfg, op)
+	w.N("	x._deleted = true")
+	w.N("	x._exists = false")
+	CallHook(w, op, "AfterDelete", 1, "")
+	w.N("	return nil")
+	w.N("}\n\n")
+}
@@ -0,0 +1,664 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// storeGetter is one of the getters in a table's Store interface, such as GetByID.
+type storeGetter struct {
+	name   string           // name is the method's name (e.g. GetByID), which is the function's without the table.
+	params string           // params declares the method's arguments (e.g. "ID int64").
+	args   string           // args passes the arguments on (e.g. "ID").
+	cols   []*parser.Column // cols are the columns the arguments are compared to.
+}
+
+// storeGetters returns the getters of the table's Store: the same primary key and unique column
+// getters as GetByPk and GetByUnique generate.
+func storeGetters(t *parser.Table) []storeGetter {
+	pk := t.PrimaryKeys()
+	names, params := make([]string, len(pk)), make([]string, len(pk))
+	for i, col := range pk {
+		names[i] = col.GoName()
+		params[i] = col.GoName() + " " + col.GetGoType()
+	}
+	getters := []storeGetter{{
+		name:   "GetBy" + strings.Join(names, ""),
+		params: strings.Join(params, ", "),
+		args:   strings.Join(names, ", "),
+		cols:   pk,
+	}}
+	for i, col := range t.Columns {
+		if !t.SingleColumnUnique(col.SQLName()) || col.Nullable {
+			continue
+		}
+		getters = append(getters, storeGetter{
+			name:   "GetBy" + col.GoName(),
+			params: col.GoName() + " " + col.GetGoType(),
+			args:   col.GoName(),
+			cols:   []*parser.Column{&t.Columns[i]},
+		})
+	}
+	return getters
+}
+
+// storeWrite is one of the methods in a table's Store interface that writes the row it is given,
+// such as Insert or UpsertOnEmail.
+type storeWrite struct {
+	name    string // name is the row's method (e.g. Insert).
+	results string // results are what it returns (e.g. "error").
+}
+
+// storeWrites returns the write methods of the table's Store: those generated on the row for
+// inserts, updates, upserts, and deletes.
+func storeWrites(t *parser.Table, cfg *config.Config) []storeWrite {
+	writes := []storeWrite{{"Insert", "error"}, {"Update", "error"}, {"Upsert", "error"}}
+	for _, target := range ConflictTargets(t, cfg) {
+		if UpsertUpdateColumns(t, cfg, target.Columns...) != "" {
+			writes = append(writes, storeWrite{"UpsertOn" + target.Name, "error"})
+		}
+		writes = append(writes, storeWrite{"UpsertOn" + target.Name + "DoNothing", "(bool, error)"})
+	}
+	writes = append(writes, storeWrite{"Delete", "error"})
+	if SoftDeleteColumn(t, cfg) != nil {
+		writes = append(writes, storeWrite{"Restore", "error"}, storeWrite{"HardDelete", "error"})
+	}
+	return writes
+}
+
+// Store writes the table's Store interface if cfg.Stores is set, which holds the same getters and
+// write methods as the generated functions and methods, so the code using them can be tested
+// without a database. The DBStore implements it with the generated code, and the FakeStore in
+// memory (see FakeStore).
+func Store(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	if !cfg.Stores || len(t.PrimaryKeys()) < 1 {
+		return
+	}
+	T := t.GoName()
+	getters := storeGetters(t)
+	writes := storeWrites(t, cfg)
+	pk := getters[0] // the primary key's getter, whose arguments UpdateFields takes too
+	updateFields := len(UpdatableColumns(t, cfg)) > 0
+	w.F("// %sStore reads and writes rows of '%s'. %sDBStore implements it with the database, and\n", T, t.SQLName(), T)
+	w.F("// %sFakeStore in memory for tests.\n", T)
+	w.F("type %sStore interface {\n", T)
+	for _, g := range getters {
+		for _, v := range ReadVariants(t, cfg, "", false) {
+			w.F("	%s%s(ctx context.Context, %s) (*%s, error)\n", g.name, v.Suffix, g.params, T)
+			w.F("	%s%s(ctx context.Context, %s) (*%s, error)\n", findName(g.name), v.Suffix, g.params, T)
+		}
+	}
+	for _, v := range ReadVariants(t, cfg, "", true) {
+		w.F("	GetAll%s(ctx context.Context) ([]*%s, error)\n", v.Suffix, T)
+	}
+	for _, m := range writes {
+		w.F("	%s(ctx context.Context, x *%s) %s\n", m.name, T, m.results)
+	}
+	if updateFields {
+		w.F("	UpdateFields(ctx context.Context, %s, fields ...%sField) error\n", pk.params, T)
+	}
+	w.N("}\n")
+
+	w.F("// %sDBStore is the %sStore that reads and writes the database.\n", T, T)
+	w.F("type %sDBStore struct {\n", T)
+	w.N("	db DB")
+	w.N("}\n")
+	w.F("var _ %sStore = (*%sDBStore)(nil)\n\n", T, T)
+	w.F("// New%sDBStore returns the %sStore using db, which may also be a *Queries or a TX.\n", T, T)
+	w.F("func New%sDBStore(db DB) *%sDBStore {\n", T, T)
+	w.F("	return &%sDBStore{db: db}\n", T)
+	w.N("}\n")
+	for _, g := range getters {
+		for _, v := range ReadVariants(t, cfg, "", false) {
+			for _, name := range []string{g.name + v.Suffix, findName(g.name) + v.Suffix} {
+				w.F("func (s *%sDBStore) %s(ctx context.Context, %s) (*%s, error) {\n", T, name, g.params, T)
+				w.F("	return %s%s(ctx, s.db, %s)\n", T, name, g.args)
+				w.N("}\n")
+			}
+		}
+	}
+	for _, v := range ReadVariants(t, cfg, "", true) {
+		w.F("func (s *%sDBStore) GetAll%s(ctx context.Context) ([]*%s, error) {\n", T, v.Suffix, T)
+		w.F("	return %sGetAll%s(ctx, s.db)\n", T, v.Suffix)
+		w.N("}\n")
+	}
+	for _, m := range writes {
+		w.F("func (s *%sDBStore) %s(ctx context.Context, x *%s) %s {\n", T, m.name, T, m.results)
+		w.F("	return x.%s(ctx, s.db)\n", m.name)
+		w.N("}\n")
+	}
+	if updateFields {
+		w.F("func (s *%sDBStore) UpdateFields(ctx context.Context, %s, fields ...%sField) error {\n", T, pk.params, T)
+		w.F("	return %sUpdateFields(ctx, s.db, %s, fields...)\n", T, pk.args)
+		w.N("}\n")
+	}
+	w.N("")
+	FakeStore(w, t, cfg, getters)
+}
+
+// findName returns the name of the Find variant of the getter named getName (e.g. FindByID).
+func findName(getName string) string {
+	return "Find" + strings.TrimPrefix(getName, "Get")
+}
+
+// FakeStore writes the table's in-memory Store, which behaves like the DBStore for the same calls:
+// it fills the columns the database would on insert, update, and upsert, and rejects writes that
+// violate the table's PRIMARY KEY or UNIQUE constraints with the same *ConstraintError. It does not
+// enforce CHECK or FOREIGN KEY constraints, apply other column defaults, or call hooks.
+func FakeStore(w *ShortWriter, t *parser.Table, cfg *config.Config, getters []storeGetter) {
+	T := t.GoName()
+	ts := cfg.TimestampColumns(t.SQLName())
+	soft := SoftDeleteColumn(t, cfg)
+	version := VersionColumn(t, cfg)
+	var autoID *parser.Column
+	for _, col := range t.PrimaryKeys() {
+		if col.AutoIncrement() {
+			autoID = col
+		}
+	}
+	missing := "ErrNotFound"
+	if version != nil {
+		missing = "ErrStaleVersion"
+	}
+
+	w.F("// %sFakeStore is an in-memory %sStore for tests that should run without a database. Like\n", T, T)
+	w.N("// SQLite, it fills the columns the database generates on insert, update, and upsert, and returns")
+	w.N("// a *ConstraintError if a write would violate the table's PRIMARY KEY or a UNIQUE constraint. It")
+	w.N("// does not enforce CHECK or FOREIGN KEY constraints, apply other column defaults, or call hooks.")
+	w.F("type %sFakeStore struct {\n", T)
+	w.N("	mu     sync.Mutex")
+	w.F("	rows   []*%s // rows are copies of the rows written, in the order they were inserted.\n", T)
+	if autoID != nil {
+		w.F("	lastID int64 // lastID is the %s most recently assigned to an inserted row.\n", autoID.SQLName())
+	}
+	w.N("}\n")
+	w.F("var _ %sStore = (*%sFakeStore)(nil)\n\n", T, T)
+	w.F("// New%sFakeStore returns an empty %sFakeStore.\n", T, T)
+	w.F("func New%sFakeStore() *%sFakeStore {\n", T, T)
+	w.F("	return &%sFakeStore{}\n", T)
+	w.N("}\n")
+
+	w.N("// clone returns a copy of x that shares none of its memory, loaded as if read from the database.")
+	w.F("func (s *%sFakeStore) clone(x *%s) *%s {\n", T, T, T)
+	w.N("	c := *x")
+	for _, col := range t.Columns {
+		if col.Type == parser.BLOB {
+			w.F("	c.%s = append([]byte(nil), x.%s...)\n", col.GoName(), col.GoName())
+		}
+	}
+	w.N("	c.loaded()")
+	w.N("	return &c")
+	w.N("}\n")
+
+	w.N("// index returns the index of the stored row with x's primary key, or -1 if there is none.")
+	w.F("func (s *%sFakeStore) index(x *%s) int {\n", T, T)
+	w.N("	for i, row := range s.rows {")
+	w.F("		if %s {\n", fakeMatch(t.PrimaryKeys(), "row", "x."))
+	w.N("			return i")
+	w.N("		}")
+	w.N("	}")
+	w.N("	return -1")
+	w.N("}\n")
+
+	w.N("// conflict returns the *ConstraintError SQLite would return if x were stored alongside every")
+	w.N("// stored row except the one at index skip (or -1 to check them all), or nil if there is none.")
+	w.F("func (s *%sFakeStore) conflict(x *%s, skip int) error {\n", T, T)
+	unique := []constraintSentinel{}
+	for _, s := range constraintSentinels(t, cfg) {
+		if s.kind == "ErrUniqueViolation" {
+			unique = append(unique, s)
+		}
+	}
+	if len(unique) > 0 {
+		w.N("	for i, row := range s.rows {")
+		w.N("		switch {")
+		w.N("		case i == skip:")
+		for _, s := range unique {
+			w.F("		case %s:\n", fakeMatch(fakeColumns(t, s.columns), "row", "x."))
+			w.F("			return &ConstraintError{Kind: ErrUniqueViolation, Table: %q, Constraint: %q, Columns: %#v, Sentinel: %s,\n", t.SQLName(), s.constraint, s.columns, s.name)
+			w.F("				Err: errors.New(%q)}\n", "UNIQUE constraint failed: "+s.detail)
+		}
+		w.N("		}")
+		w.N("	}")
+	}
+	w.N("	return nil")
+	w.N("}\n")
+
+	for _, g := range getters {
+		for _, v := range ReadVariants(t, cfg, "", false) {
+			w.F("func (s *%sFakeStore) %s%s(ctx context.Context, %s) (*%s, error) {\n", T, g.name, v.Suffix, g.params, T)
+			w.N("	s.mu.Lock()")
+			w.N("	defer s.mu.Unlock()")
+			w.N("	for _, row := range s.rows {")
+			w.F("		if %s%s {\n", fakeMatch(g.cols, "row", ""), fakeFilter(v, soft, " && "))
+			w.N("			return s.clone(row), nil")
+			w.N("		}")
+			w.N("	}")
+			w.N("	return nil, ErrNotFound")
+			w.N("}\n")
+			w.F("func (s *%sFakeStore) %s%s(ctx context.Context, %s) (*%s, error) {\n", T, findName(g.name), v.Suffix, g.params, T)
+			w.F("	row, err := s.%s%s(ctx, %s)\n", g.name, v.Suffix, g.args)
+			w.N("	if errors.Is(err, ErrNotFound) {")
+			w.N("		return nil, nil")
+			w.N("	}")
+			w.N("	return row, err")
+			w.N("}\n")
+		}
+	}
+
+	for _, v := range ReadVariants(t, cfg, "", true) {
+		w.F("func (s *%sFakeStore) GetAll%s(ctx context.Context) ([]*%s, error) {\n", T, v.Suffix, T)
+		w.N("	s.mu.Lock()")
+		w.N("	defer s.mu.Unlock()")
+		w.F("	all := []*%s{}\n", T)
+		w.N("	for _, row := range s.rows {")
+		if filter := fakeFilter(v, soft, ""); filter != "" {
+			w.F("		if %s {\n", filter)
+			w.N("			all = append(all, s.clone(row))")
+			w.N("		}")
+		} else {
+			w.N("		all = append(all, s.clone(row))")
+		}
+		w.N("	}")
+		w.N("	return all, nil")
+		w.N("}\n")
+	}
+
+	// Insert
+	stamps := []string{}
+	for _, col := range t.Columns {
+		if col.DefaultsToNow() || isColumn(col, ts.Created) || isColumn(col, ts.Updated) {
+			if now := fakeNow(&col, ts.Format); now != "" {
+				stamps = append(stamps, fmt.Sprintf("	row.%s = %s\n", col.GoName(), now))
+			}
+		}
+	}
+	w.F("func (s *%sFakeStore) Insert(ctx context.Context, x *%s) error {\n", T, T)
+	w.N("	switch {")
+	w.N("	case x._exists:")
+	w.N("		return ErrInsertAlreadyExists")
+	w.N("	case x._deleted:")
+	w.N("		return ErrInsertMarkedForDeletion")
+	w.N("	}")
+	w.N("	s.mu.Lock()")
+	w.N("	defer s.mu.Unlock()")
+	w.N("	return s.insert(x)")
+	w.N("}\n")
+
+	w.N("// insert stores a copy of x as a new row, as Insert and the upserts do, while s.mu is held.")
+	w.F("func (s *%sFakeStore) insert(x *%s) error {\n", T, T)
+	w.N("	row := s.clone(x)")
+	if autoID != nil {
+		w.F("	row.%s = s.lastID + 1\n", autoID.GoName())
+	}
+	if len(stamps) > 0 {
+		w.F("	now := %s\n", fakeTime(ts.Format))
+		for _, stamp := range stamps {
+			w.N(strings.TrimSuffix(stamp, "\n"))
+		}
+	}
+	for _, col := range t.Columns {
+		switch {
+		case fakeNulled(col, soft, ts):
+			w.F("	row.%s = %s{}\n", col.GoName(), col.GetGoType())
+		case version != nil && col.SQLName() == version.SQLName() && col.HasDefault():
+			w.F("	row.%s = %d\n", col.GoName(), col.DefaultInt.Int64)
+		}
+	}
+	w.N("	if err := s.conflict(row, -1); err != nil {")
+	w.N("		return err")
+	w.N("	}")
+	if autoID != nil {
+		w.F("	s.lastID = row.%s\n", autoID.GoName())
+	}
+	w.N("	s.rows = append(s.rows, row)")
+	w.N("	*x = *s.clone(row)")
+	w.N("	return nil")
+	w.N("}\n")
+
+	// Update
+	w.F("func (s *%sFakeStore) Update(ctx context.Context, x *%s) error {\n", T, T)
+	w.N("	switch {")
+	w.N("	case !x._exists:")
+	w.N("		return ErrUpdateDoesNotExist")
+	w.N("	case x._deleted:")
+	w.N("		return ErrUpdateMarkedForDeletion")
+	w.N("	}")
+	w.N("	changed := x.Changed()")
+	w.N("	if len(changed) == 0 {")
+	w.N("		return nil // nothing to write")
+	w.N("	}")
+	w.N("	s.mu.Lock()")
+	w.N("	defer s.mu.Unlock()")
+	fakeFind(w, version, missing)
+	w.N("	row := s.clone(s.rows[i])")
+	w.N("	for _, col := range changed { // like Update, only write the changed fields")
+	w.N("		switch col {")
+	for _, col := range UpdatableColumns(t, cfg) {
+		w.F("		case %q:\n", col.SQLName())
+		fakeCopy(w, "\t\t\t", col, "x."+col.GoName())
+	}
+	w.N("		}")
+	w.N("	}")
+	fakeUpdated(w, t, cfg)
+	w.N("	if err := s.conflict(row, i); err != nil {")
+	w.N("		return err")
+	w.N("	}")
+	w.N("	s.rows[i] = row")
+	w.N("	*x = *s.clone(row)")
+	w.N("	return nil")
+	w.N("}\n")
+
+	// UpdateFields
+	if cols := UpdatableColumns(t, cfg); len(cols) > 0 {
+		pk := getters[0]
+		keys := make([]string, len(pk.cols))
+		for i, col := range pk.cols {
+			keys[i] = col.GoName() + ": " + col.GoName()
+		}
+		w.F("func (s *%sFakeStore) UpdateFields(ctx context.Context, %s, fields ...%sField) error {\n", T, pk.params, T)
+		w.N("	if len(fields) == 0 {")
+		w.N("		return nil // nothing to write")
+		w.N("	}")
+		w.N("	s.mu.Lock()")
+		w.N("	defer s.mu.Unlock()")
+		w.F("	i := s.index(&%s{%s})\n", T, strings.Join(keys, ", "))
+		w.N("	if i < 0 {")
+		w.N("		return ErrNotFound")
+		w.N("	}")
+		w.N("	row := s.clone(s.rows[i])")
+		w.N("	for _, f := range fields {")
+		w.N("		switch f.column {")
+		for _, col := range cols {
+			w.F("		case %q:\n", col.SQLName())
+			fakeCopy(w, "\t\t\t", col, fmt.Sprintf("f.value.(%s)", col.GetGoType()))
+		}
+		w.N("		}")
+		w.N("	}")
+		fakeUpdated(w, t, cfg)
+		w.N("	if err := s.conflict(row, i); err != nil {")
+		w.N("		return err")
+		w.N("	}")
+		w.N("	s.rows[i] = row")
+		w.N("	return nil")
+		w.N("}\n")
+	}
+
+	// Upsert and UpsertOn
+	targets := ConflictTargets(t, cfg)
+	conflicts := make([]string, len(targets)) // Upsert updates the row x conflicts with on any target
+	for i, target := range targets {
+		conflicts[i] = fakeMatch(fakeColumns(t, target.Columns), "old", "x.")
+		if len(targets) > 1 {
+			conflicts[i] = "(" + conflicts[i] + ")"
+		}
+	}
+	fakeUpsert(w, t, cfg, "Upsert", strings.Join(conflicts, " || "), nil)
+	for _, target := range targets {
+		if UpsertUpdateColumns(t, cfg, target.Columns...) != "" {
+			fakeUpsert(w, t, cfg, "UpsertOn"+target.Name, fakeMatch(fakeColumns(t, target.Columns), "old", "x."), target.Columns)
+		}
+		w.F("func (s *%sFakeStore) UpsertOn%sDoNothing(ctx context.Context, x *%s) (bool, error) {\n", T, target.Name, T)
+		w.N("	if x._deleted {")
+		w.N("		return false, ErrUpsertMarkedForDeletion")
+		w.N("	}")
+		w.N("	s.mu.Lock()")
+		w.N("	defer s.mu.Unlock()")
+		w.N("	for _, row := range s.rows {")
+		w.F("		if %s {\n", fakeMatch(fakeColumns(t, target.Columns), "row", "x."))
+		w.N("			return false, nil")
+		w.N("		}")
+		w.N("	}")
+		w.N("	if err := s.insert(x); err != nil {")
+		w.N("		return false, err")
+		w.N("	}")
+		w.N("	return true, nil")
+		w.N("}\n")
+	}
+
+	// Delete
+	w.F("func (s *%sFakeStore) Delete(ctx context.Context, x *%s) error {\n", T, T)
+	w.N("	switch {")
+	w.N("	case !x._exists:")
+	w.N("		return nil")
+	w.N("	case x._deleted:")
+	w.N("		return nil")
+	w.N("	}")
+	w.N("	s.mu.Lock()")
+	w.N("	defer s.mu.Unlock()")
+	fakeFind(w, version, missing)
+	if soft != nil {
+		w.N("	row := s.clone(s.rows[i])")
+		if now := fakeNow(soft, ts.Format); now != "" {
+			w.F("	now := %s\n", fakeTime(ts.Format))
+			w.F("	row.%s = %s\n", soft.GoName(), now)
+		}
+		if version != nil {
+			w.F("	row.%s++\n", version.GoName())
+		}
+		w.N("	s.rows[i] = row")
+		w.N("	*x = *s.clone(row)")
+	} else {
+		w.N("	s.rows = append(s.rows[:i], s.rows[i+1:]...)")
+		w.N("	x._deleted = true")
+	}
+	w.N("	return nil")
+	w.N("}\n")
+	if soft == nil {
+		w.N("")
+		return
+	}
+
+	// Restore and HardDelete
+	w.F("func (s *%sFakeStore) Restore(ctx context.Context, x *%s) error {\n", T, T)
+	w.N("	if !x._exists {")
+	w.N("		return ErrRestoreDoesNotExist")
+	w.N("	}")
+	w.N("	s.mu.Lock()")
+	w.N("	defer s.mu.Unlock()")
+	fakeFind(w, version, missing)
+	w.N("	row := s.clone(s.rows[i])")
+	w.F("	row.%s = %s{}\n", soft.GoName(), soft.GetGoType())
+	if version != nil {
+		w.F("	row.%s++\n", version.GoName())
+	}
+	w.N("	s.rows[i] = row")
+	w.N("	*x = *s.clone(row)")
+	w.N("	return nil")
+	w.N("}\n")
+	w.F("func (s *%sFakeStore) HardDelete(ctx context.Context, x *%s) error {\n", T, T)
+	w.N("	if !x._exists {")
+	w.N("		return nil")
+	w.N("	}")
+	w.N("	s.mu.Lock()")
+	w.N("	defer s.mu.Unlock()")
+	fakeFind(w, version, missing)
+	w.N("	s.rows = append(s.rows[:i], s.rows[i+1:]...)")
+	w.N("	x._deleted = true")
+	w.N("	x._exists = false")
+	w.N("	return nil")
+	w.N("}\n\n")
+}
+
+// fakeUpsert writes the fake's upsert method named method, which updates the first stored row that
+// old matches (a condition on old and x), or inserts x if there is none. exclude are the columns of
+// the conflict target, which are not updated (see UpsertUpdateColumns).
+func fakeUpsert(w *ShortWriter, t *parser.Table, cfg *config.Config, method, match string, exclude []string) {
+	ts := cfg.TimestampColumns(t.SQLName())
+	version := VersionColumn(t, cfg)
+	w.F("func (s *%sFakeStore) %s(ctx context.Context, x *%s) error {\n", t.GoName(), method, t.GoName())
+	w.N("	if x._deleted {")
+	w.N("		return ErrUpsertMarkedForDeletion")
+	w.N("	}")
+	w.N("	s.mu.Lock()")
+	w.N("	defer s.mu.Unlock()")
+	if match != "" {
+		w.N("	for i, old := range s.rows {")
+		w.F("		if !(%s) {\n", match)
+		w.N("			continue")
+		w.N("		}")
+		if version != nil {
+			w.F("		if old.%s != x.%s { // the row was changed since it was read\n", version.GoName(), version.GoName())
+			w.N("			return ErrStaleVersion")
+			w.N("		}")
+		}
+		w.N("		row := s.clone(old)")
+		now := false
+		for _, col := range upsertSetColumns(t, cfg, exclude...) {
+			switch {
+			case col == version:
+				w.F("		row.%s++\n", col.GoName())
+			case isColumn(*col, ts.Updated):
+				if v := fakeNow(col, ts.Format); v != "" {
+					if !now {
+						w.F("		now := %s\n", fakeTime(ts.Format))
+						now = true
+					}
+					w.F("		row.%s = %s\n", col.GoName(), v)
+				}
+			case fakeNulled(*col, nil, ts): // not inserted, so the conflicting row's is its default
+				w.F("		row.%s = %s{}\n", col.GoName(), col.GetGoType())
+			default:
+				fakeCopy(w, "\t\t", col, "x."+col.GoName())
+			}
+		}
+		w.N("		if err := s.conflict(row, i); err != nil {")
+		w.N("			return err")
+		w.N("		}")
+		w.N("		s.rows[i] = row")
+		w.N("		*x = *s.clone(row)")
+		w.N("		return nil")
+		w.N("	}")
+	}
+	w.N("	return s.insert(x)")
+	w.N("}\n")
+}
+
+// fakeColumns returns the columns of t with the given SQL names.
+func fakeColumns(t *parser.Table, names []string) []*parser.Column {
+	cols := make([]*parser.Column, len(names))
+	for i, name := range names {
+		cols[i] = t.Column(name)
+	}
+	return cols
+}
+
+// fakeFilter returns the condition on a stored row for the rows the read variant v returns, preceded
+// by and if it is not "", or "" if it returns every row.
+func fakeFilter(v ReadVariant, soft *parser.Column, and string) string {
+	switch {
+	case v.Filter == "":
+		return ""
+	case v.Suffix == "OnlyDeleted":
+		return fmt.Sprintf("%srow.%s.Valid", and, soft.GoName())
+	default:
+		return fmt.Sprintf("%s!row.%s.Valid", and, soft.GoName())
+	}
+}
+
+// fakeNulled returns true if col is left NULL on insert because new rows are never deleted.
+func fakeNulled(col parser.Column, soft *parser.Column, ts config.Timestamps) bool {
+	return col.Nullable && strings.HasPrefix(col.GetGoType(), "sql.Null") &&
+		(isColumn(col, ts.Deleted) || (soft != nil && col.SQLName() == soft.SQLName()))
+}
+
+// fakeCopy writes the assignment of src to row's field for col, at the given indent, copying
+// BLOBs so the stored row shares no memory with the caller's.
+func fakeCopy(w *ShortWriter, indent string, col *parser.Column, src string) {
+	if col.Type == parser.BLOB {
+		w.F("%srow.%s = append([]byte(nil), %s...)\n", indent, col.GoName(), src)
+	} else {
+		w.F("%srow.%s = %s\n", indent, col.GoName(), src)
+	}
+}
+
+// fakeUpdated writes the changes SQLite makes to row when it is updated: stamping its updated
+// timestamp column (see config.Timestamps) and incrementing its version.
+func fakeUpdated(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	ts := cfg.TimestampColumns(t.SQLName())
+	if col := t.Column(ts.Updated); col != nil {
+		if now := fakeNow(col, ts.Format); now != "" {
+			w.F("	now := %s\n", fakeTime(ts.Format))
+			w.F("	row.%s = %s\n", col.GoName(), now)
+		}
+	}
+	if version := VersionColumn(t, cfg); version != nil {
+		w.F("	row.%s++\n", version.GoName())
+	}
+}
+
+// fakeFind writes the lookup of x's stored row into i, returning missing (e.g. ErrNotFound) if there
+// is none, or if its version differs from x's.
+func fakeFind(w *ShortWriter, version *parser.Column, missing string) {
+	w.N("	i := s.index(x)")
+	if version != nil {
+		w.F("	if i < 0 || s.rows[i].%s != x.%s { // the row was changed or deleted since it was read\n", version.GoName(), version.GoName())
+	} else {
+		w.N("	if i < 0 {")
+	}
+	w.F("		return %s\n", missing)
+	w.N("	}")
+}
+
+// fakeMatch returns the condition that the fields of row hold the same values for cols as the
+// prefixed Go names (e.g. "x." for x's fields, or "" for arguments named after the columns). As in a
+// UNIQUE index, NULL never matches.
+func fakeMatch(cols []*parser.Column, row, prefix string) string {
+	conds := make([]string, len(cols))
+	for i, col := range cols {
+		a, b := row+"."+col.GoName(), prefix+col.GoName()
+		switch col.GetGoType() {
+		case "time.Time":
+			conds[i] = fmt.Sprintf("%s.Equal(%s)", a, b)
+		case "sql.NullTime":
+			conds[i] = fmt.Sprintf("%s.Valid && %s.Valid && %s.Time.Equal(%s.Time)", a, b, a, b)
+		case "[]byte":
+			conds[i] = fmt.Sprintf("string(%s) == string(%s)", a, b)
+			if col.Nullable {
+				conds[i] = fmt.Sprintf("%s != nil && %s != nil && %s", a, b, conds[i])
+			}
+		default:
+			conds[i] = fmt.Sprintf("%s == %s", a, b)
+			if col.Nullable {
+				conds[i] = fmt.Sprintf("%s.Valid && %s", a, conds[i])
+			}
+		}
+	}
+	return strings.Join(conds, " && ")
+}
+
+// fakeTime returns the Go expression for the current time at the precision the database stores it
+// in the given format.
+func fakeTime(format config.TimestampFormat) string {
+	if format == config.Subsec {
+		return "time.Now().UTC().Truncate(time.Millisecond)"
+	}
+	return "time.Now().UTC().Truncate(time.Second)"
+}
+
+// fakeNow returns the Go expression converting now (see fakeTime) to col's type as the database
+// would write it in the given format, or "" if col cannot hold a time.
+func fakeNow(col *parser.Column, format config.TimestampFormat) string {
+	var v, field string
+	switch col.Type {
+	case parser.DATETIME:
+		v, field = "now", "Time"
+	case parser.INT:
+		v, field = "now.Unix()", "Int64"
+	case parser.TEXT:
+		layout := "2006-01-02 15:04:05"
+		if format == config.Subsec {
+			layout += ".000"
+		}
+		v, field = fmt.Sprintf("now.Format(%q)", layout), "String"
+	default:
+		return ""
+	}
+	if col.Nullable {
+		return fmt.Sprintf("%s{%s: %s, Valid: true}", col.GetGoType(), field, v)
+	}
+	return v
+}
@@ -7,44 +7,107 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/joshsziegler/squirrel/config"
 	"github.com/joshsziegler/squirrel/parser"
 )
 
-// Write the SQL-Go access layer to f, using pkgName, except for the tables in ignoredTables.
-// Table sorted alphabetically (A to Z), so the resulting code will not change due to input orger.
-// Otherwise, the resulting git diffs can be noisy.  If ctxOnly is true, only the context versions
-// will be used for the DB interface (e.g.  ExecContext()).
-func Write(f io.Writer, pkgName string, tables []*parser.Table, ignoreTables []string, ctxOnly bool) {
+// Write the SQL-Go access layer to f, using the package name and settings from cfg, except for the
+// tables in cfg.IgnoreTables. Table sorted alphabetically (A to Z), so the resulting code will not
+// change due to input orger. Otherwise, the resulting git diffs can be noisy. If cfg.CtxOnly is
+// true, only the context versions will be used for the DB interface (e.g.  ExecContext()).
+func Write(f io.Writer, tables []*parser.Table, cfg *config.Config) {
+	declared := slices.Clone(tables)
 	// Sort the tables alphabetically (A to Z)
 	sort.Slice(tables, func(i, j int) bool {
 		return tables[i].GoName() < tables[j].GoName()
 	})
 	// Write to f
-	w := NewShortWriter(f)
-	Header(w, pkgName, ctxOnly)
+	generated := []*parser.Table{}
 	for _, table := range tables {
-		if table.InternalUse() || slices.Contains(ignoreTables, table.SQLName()) {
+		if table.InternalUse() || slices.Contains(cfg.IgnoreTables, table.SQLName()) {
 			continue // skip this table
 		}
-		Table(w, table)
+		generated = append(generated, table)
+	}
+	w := NewShortWriter(f)
+	Header(w, generated, cfg)
+	Schema(w, declared, cfg)
+	for _, table := range generated {
+		Table(w, table, tables, cfg)
+		Fixture(w, table, generated, cfg)
 	}
 }
 
-func Header(w *ShortWriter, pkgName string, ctxOnly bool) {
-	w.F("// Code generated by squirrel; DO NOT EDIT.\n\n")
-	w.F("package %s\n\n", pkgName)
-	w.N(`
-import (
-	"context"
-	"database/sql"
-	"errors"
-	"time"
+// Imports writes the generated file's imports, which depend on the runtime and error strategy, and
+// on whether any tables are generated, as only their iterators (see All) use iter. With the hook
+// strategy, it also documents the wrapErr function the package must define.
+func Imports(w *ShortWriter, tables []*parser.Table, cfg *config.Config) {
+	std := []string{"context", "crypto/sha256", "database/sql", "encoding/hex", "errors", "slices", "strconv", "strings", "sync", "time"}
+	if len(tables) > 0 {
+		std = slices.Insert(std, slices.Index(std, "errors")+1, "iter")
+	}
+	if cfg.ErrorWrapping() == config.ErrorsStdlib {
+		std = slices.Insert(std, slices.Index(std, "errors")+1, "fmt")
+	}
+	w.N("\nimport (")
+	for _, pkg := range std {
+		w.F("\t%q\n", pkg)
+	}
+	third := []string{}
+	if cfg.Runtime != config.DatabaseSQL {
+		third = append(third, "github.com/jmoiron/sqlx")
+	}
+	if cfg.ErrorWrapping() == config.ErrorsMerry {
+		third = append(third, "github.com/ansel1/merry/v2")
+	}
+	if len(third) > 0 {
+		w.N("")
+		for _, pkg := range third {
+			w.F("\t%q\n", pkg)
+		}
+	}
+	w.N(")\n")
+	if cfg.ErrorWrapping() == config.ErrorsHook {
+		w.N(`// Every error returned by this package is passed to wrapErr, which must be written by hand in
+// this package:
+//
+//	func wrapErr(err error, op string, keys ...any) error
+//
+// op names the function or method (e.g. "users.Update"), and keys alternate the name and value of
+// each key column identifying the row (e.g. "id", 1).
+`)
+	}
+}
 
-	"github.com/jmoiron/sqlx"
-	"github.com/ansel1/merry/v2"
-)
+// Header writes the package clause, imports, and the declarations shared by every table, such as
+// the DB interface for the configured runtime. tables are those that will be generated.
+func Header(w *ShortWriter, tables []*parser.Table, cfg *config.Config) {
+	w.F("// Code generated by squirrel; DO NOT EDIT.\n\n")
+	w.F("package %s\n\n", cfg.Package)
+	Imports(w, tables, cfg)
+	if cfg.Runtime == config.DatabaseSQL {
+		w.N(`
+// DB is the common interface for database operations and works with *sql.DB, *sql.Tx, and (if
+// ctx_only is true) *sql.Conn.
+// Use this IFF the function or method only performs one SQL operation to provide flexibility to the caller.
+// If the function or method performs two or more SQL operations, use TX instead.
+// This forces the caller to use a transaction and indicates that it's necessary to maintain data consistency.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 `)
-	w.N(`
+		if !cfg.CtxOnly {
+			w.N(`
+	Exec(query string, args ...any) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+`)
+		}
+	} else {
+		w.N(`
 // DB is the common interface for database operations and works with sqlx.DB, and sqlx.Tx.
 // Use this IFF the function or method only performs one SQL operation to provide flexibility to the caller.
 // If the function or method performs two or more SQL operations, use TX instead.
@@ -60,8 +123,8 @@ type DB interface {
 	Rebind(query string) string
 	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 `)
-	if !ctxOnly {
-		w.N(`
+		if !cfg.CtxOnly {
+			w.N(`
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Get(dest interface{}, query string, args ...interface{}) error
 	NamedExec(query string, arg interface{}) (sql.Result, error)
@@ -71,13 +134,18 @@ type DB interface {
 	QueryRow(query string, args ...any) *sql.Row
 	Select(dest interface{}, query string, args ...interface{}) error
 `)
+		}
 	}
 
 	w.N(`
 }
-
-// TX is the common interface for database operations requiring a transaction and works with sqlx.Tx.
-// Use this IFF the function or method performs two or more SQL operations.
+`)
+	if cfg.Runtime == config.DatabaseSQL {
+		w.N("// TX is the common interface for database operations requiring a transaction and works with *sql.Tx.")
+	} else {
+		w.N("// TX is the common interface for database operations requiring a transaction and works with sqlx.Tx.")
+	}
+	w.N(`// Use this IFF the function or method performs two or more SQL operations.
 // This forces the caller to use a transaction and indicates that it's necessary to maintain data consistency.
 // If the function or method performs only one SQL operation, use DB instead.
 type TX interface {
@@ -105,18 +173,97 @@ type Stored interface {
 	Delete(context.Context, DB) error
 }
 
+// BeforeInserter is implemented by rows that need to run code before Insert writes them, such as
+// validation or normalization. Returning an error stops the insert.
+type BeforeInserter interface {
+	BeforeInsert(context.Context, DB) error
+}
+
+// AfterInserter is implemented by rows that need to run code after Insert writes them.
+type AfterInserter interface {
+	AfterInsert(context.Context, DB) error
+}
+
+// BeforeUpdater is implemented by rows that need to run code before Update writes them. Returning
+// an error stops the update.
+type BeforeUpdater interface {
+	BeforeUpdate(context.Context, DB) error
+}
+
+// AfterUpdater is implemented by rows that need to run code after Update writes them.
+type AfterUpdater interface {
+	AfterUpdate(context.Context, DB) error
+}
+
+// BeforeUpserter is implemented by rows that need to run code before an Upsert writes them.
+// Returning an error stops the upsert.
+type BeforeUpserter interface {
+	BeforeUpsert(context.Context, DB) error
+}
+
+// AfterUpserter is implemented by rows that need to run code after an Upsert writes them.
+type AfterUpserter interface {
+	AfterUpsert(context.Context, DB) error
+}
+
+// BeforeDeleter is implemented by rows that need to run code before Delete removes them. Returning
+// an error stops the delete.
+type BeforeDeleter interface {
+	BeforeDelete(context.Context, DB) error
+}
+
+// AfterDeleter is implemented by rows that need to run code after Delete removes them, such as
+// invalidating a cache.
+type AfterDeleter interface {
+	AfterDelete(context.Context, DB) error
+}
+
 var (
 	ErrInsertAlreadyExists		= errors.New("cannot insert because the row already exists")
 	ErrInsertMarkedForDeletion	= errors.New("cannot insert because the row has been deleted")
 	ErrUpdateDoesNotExist		= errors.New("cannot update because the row does not exist")
 	ErrUpdateMarkedForDeletion	= errors.New("cannot update because the row has been deleted")
 	ErrUpsertMarkedForDeletion	= errors.New("cannot upsert because the row has been deleted")
+	ErrRestoreDoesNotExist		= errors.New("cannot restore because the row does not exist")
+	ErrStaleVersion			= errors.New("row was changed or deleted since it was read")
+	ErrBatchMismatch		= errors.New("rows returned by a batch do not match the rows written")
 )
+
+// ErrNotFound is returned when no row has the key a getter was given, or the row an Update or
+// Delete was for has been deleted. It also matches sql.ErrNoRows with errors.Is.
+var ErrNotFound error = notFoundError{}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string		{ return "row not found" }
+func (notFoundError) Is(target error) bool	{ return target == sql.ErrNoRows }
+
+// SQLiteMaxVariableNumber is the most bound parameters a single statement may use, which limits how
+// many rows InsertMany and UpsertMany write per statement. It matches SQLITE_MAX_VARIABLE_NUMBER's
+// default since SQLite 3.32.0; lower it (e.g. to 999) if your SQLite was built with a smaller limit.
+var SQLiteMaxVariableNumber = 32766
+
+// valuesList returns the placeholders for a multi-row VALUES clause (e.g. "(?, ?), (?, ?)").
+func valuesList(rows, cols int) string {
+	row := "(" + strings.Repeat("?, ", cols-1) + "?)"
+	return strings.Repeat(row+", ", rows-1) + row
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows, so each table's scan function can read a single
+// row or walk many.
+type scanner interface {
+	Scan(dest ...any) error
+}
 `)
+	ConstraintTypes(w)
+	PreparedQueries(w, cfg)
+	Transactions(w, cfg)
+	FixtureSeq(w, cfg)
 }
 
-// Table converts a Table to its Go-access-layer.
-func Table(w *ShortWriter, t *parser.Table) {
+// Table converts a Table to its Go-access-layer. tables are all of the schema's tables, which
+// may reference it.
+func Table(w *ShortWriter, t *parser.Table, tables []*parser.Table, cfg *config.Config) {
 	w.F("// %s represents a row from '%s'\n", t.GoName(), t.SQLName())
 	if t.Comment != "" {
 		w.F("// Schema Comment: %s\n", t.Comment)
@@ -157,6 +304,12 @@ func Table(w *ShortWriter, t *parser.Table) {
 			w.F("// Composite FK: (%s) -> %s (%s)\n", local, fk.Table, referenced)
 		}
 	}
+	if col := SoftDeleteColumn(t, cfg); col != nil {
+		w.F("// Soft Delete: %s\n", col.SQLName())
+	}
+	if col := VersionColumn(t, cfg); col != nil {
+		w.F("// Version: %s\n", col.SQLName())
+	}
 	if len(t.PrimaryKeys()) < 1 {
 		w.N("//")
 		w.N("// Update, Save, Upsert, and Delete methods not provided because we were unable to determine a PK")
@@ -166,6 +319,7 @@ func Table(w *ShortWriter, t *parser.Table) {
 		columnToGo(w, &c, t)
 	}
 	w.N(`	_exists, _deleted bool // In-memory-only metadata on this row's status in the DB`)
+	w.F("	_snapshot *%s // Values as of the last read from or write to the DB, used to find changed fields\n", t.GoName())
 	w.F("}\n\n")
 
 	w.N("// Exists in the database.")
@@ -178,14 +332,28 @@ func Table(w *ShortWriter, t *parser.Table) {
 	w.N("	return x._deleted")
 	w.N("}\n\n")
 
-	Insert(w, t)
-	Update(w, t)
-	Save(w, t)
-	Upsert(w, t)
-	Delete(w, t)
-	GetByPk(w, t)
-	GetByUnique(w, t)
-	GetAll(w, t)
+	Scan(w, t)
+	Loaded(w, t, cfg)
+	Changed(w, t, cfg)
+	Value(w, t, cfg)
+	Constraints(w, t, tables, cfg)
+
+	Insert(w, t, cfg)
+	Update(w, t, cfg)
+	UpdateFields(w, t, cfg)
+	Save(w, t, cfg)
+	Upsert(w, t, cfg)
+	UpsertOn(w, t, cfg)
+	InsertMany(w, t, cfg)
+	UpsertMany(w, t, cfg)
+	Delete(w, t, cfg)
+	Restore(w, t, cfg)
+	HardDelete(w, t, cfg)
+	GetByPk(w, t, cfg)
+	GetByUnique(w, t, cfg)
+	GetAll(w, t, cfg)
+	All(w, t, cfg)
+	Store(w, t, cfg)
 }
 
 // columnToGo converts a Column to its Go-ORM layer.
@@ -235,67 +403,224 @@ func columnToGo(w *ShortWriter, c *parser.Column, t *parser.Table) {
 }
 
 // Insert
-func Insert(w *ShortWriter, t *parser.Table) {
+func Insert(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	op := TableOp(cfg, t, "Insert").Classify(t).Fields(insertKeys(t)...)
 	w.N("// Insert this row into the database and update this struct with DB-generated values.")
 	w.N("// Return an error on conflicts.")
 	w.N("// Use Upsert if a conflict should not result in an error.")
 	w.F("func (x *%s) Insert(ctx context.Context, db DB) error {\n", t.GoName())
 	w.N(`	switch {`)
 	w.N(`	case x._exists:`)
-	w.N(`		return merry.Wrap(ErrInsertAlreadyExists)`)
+	w.F("		return %s\n", op.Wrap("ErrInsertAlreadyExists"))
 	w.N(`	case x._deleted:`)
-	w.N(`		return merry.Wrap(ErrInsertMarkedForDeletion)`)
+	w.F("		return %s\n", op.Wrap("ErrInsertMarkedForDeletion"))
 	w.N(`	}`)
-	w.N("	stmt, err := db.PrepareNamedContext(ctx,`")
-	w.F("		INSERT INTO %s (%s)\n", t.SQLName(), InsertColumns(t, false))
-	w.F("		VALUES (%s)\n", InsertColumns(t, true))
-	w.N("		RETURNING *`)")
+	CallHook(w, op, "BeforeInsert", 1, "")
+	p := NewParams(cfg)
+	ScanReturning(w, t, rawSQL("\t\t",
+		fmt.Sprintf("INSERT INTO %s (%s)", t.SQLName(), InsertColumns(t, cfg, nil)),
+		fmt.Sprintf("VALUES (%s)", InsertColumns(t, cfg, p)),
+		fmt.Sprintf("RETURNING %s", SelectColumns(t))), p)
 	w.N("	if err != nil {")
-	w.N("		return merry.Wrap(err)")
+	w.F("		return %s\n", op.Wrap("err"))
 	w.N("	}")
-	w.N("	defer stmt.Close()")
-	w.N("	err = stmt.GetContext(ctx, x, x)")
-	w.N("	if err != nil {")
-	w.N("		return merry.Wrap(err)")
-	w.N("	}")
-	w.N("	x._exists = true")
+	w.N("	x.loaded()")
+	CallHook(w, op, "AfterInsert", 1, "")
 	w.N("	return nil")
 	w.N("}\n\n")
 }
 
 // Update
-func Update(w *ShortWriter, t *parser.Table) {
+func Update(w *ShortWriter, t *parser.Table, cfg *config.Config) {
 	if len(t.PrimaryKeys()) < 1 {
 		return
 	}
+	op := TableOp(cfg, t, "Update").Classify(t).Fields(t.PrimaryKeys()...)
 	w.N("// Update this row in the database and update this struct with DB-generated values.")
+	w.N("// Only the fields changed since this row was last read from or written to the database are SET,")
+	w.N("// so concurrent edits to other fields are kept. Does nothing if no fields have changed.")
+	if col := VersionColumn(t, cfg); col != nil {
+		w.F("// Returns ErrStaleVersion if %s no longer matches the row in the database.\n", col.SQLName())
+	} else {
+		w.N("// Returns ErrNotFound if the row has been deleted.")
+	}
 	w.F("func (x *%s) Update(ctx context.Context, db DB) error {\n", t.GoName())
 	w.N("	switch {")
 	w.N("	case !x._exists: // doesn't exist")
-	w.N("		return merry.Wrap(ErrUpdateDoesNotExist)")
+	w.F("		return %s\n", op.Wrap("ErrUpdateDoesNotExist"))
 	w.N("	case x._deleted: // deleted")
-	w.N("		return merry.Wrap(ErrUpdateMarkedForDeletion)")
+	w.F("		return %s\n", op.Wrap("ErrUpdateMarkedForDeletion"))
 	w.N("	}")
-	w.N("	// update with primary key")
-	w.N("	stmt, err := db.PrepareNamedContext(ctx,`")
-	w.F("			UPDATE %s\n", t.SQLName())
-	w.F("			SET %s\n", UpdateColumns(t))
-	w.F("			WHERE %s\n", WherePKs(t))
-	w.N("			RETURNING *`)")
+	CallHook(w, op, "BeforeUpdate", 1, "")
+	w.N("	changed := x.Changed()")
+	w.N("	if len(changed) == 0 {")
+	w.N("		return nil // nothing to write")
+	w.N("	}")
+	p := NewParams(cfg)
+	where := WhereRow(t, cfg, p)
+	w.N("	set := make([]string, 0, len(changed)+1)")
+	if !p.named {
+		w.F("	args := make([]any, 0, len(changed)+%d)\n", len(p.Args))
+	}
+	w.N("	for _, col := range changed {")
+	if p.named {
+		w.N("		set = append(set, col+\"=:\"+col)")
+	} else {
+		w.N("		set = append(set, col+\"=?\")")
+		w.N("		args = append(args, x.value(col))")
+	}
+	w.N("	}")
+	if set := UpdatedSet(t, cfg); set != "" {
+		w.F("	set = append(set, \"%s\") // Use SQLite to update this column\n", set)
+	}
+	if col := VersionColumn(t, cfg); col != nil {
+		w.F("	set = append(set, \"%s=%s+1\")\n", col.SQLName(), col.SQLName())
+	}
+	if !p.named { // bind the WHERE fields after the SET values collected above
+		w.F("	args = append(args, %s)\n", strings.Join(p.Args, ", "))
+		p.Args = []string{"args..."}
+	}
+	w.N("	// update with primary key, uncached since the SET list varies with the changed fields")
+	scanReturningOn(w, t, "uncached(db)", fmt.Sprintf("\n\t\t`UPDATE %s SET `+strings.Join(set, \", \")+` WHERE %s RETURNING %s`",
+		t.SQLName(), where, SelectColumns(t)), p)
+	MissingOnNoRows(w, t, cfg, op, "")
 	w.N("	if err != nil {")
-	w.N("		return merry.Wrap(err)")
+	w.F("		return %s\n", op.Wrap("err"))
 	w.N("	}")
-	w.N("	defer stmt.Close()")
-	w.N("	err = stmt.GetContext(ctx, x, x)")
+	w.N("	x.loaded()")
+	CallHook(w, op, "AfterUpdate", 1, "")
+	w.N("	return nil")
+	w.N("}\n\n")
+}
+
+// UpdateFields writes a function that SETs only the given fields of the row with the given primary
+// key, for callers that do not hold the full struct, along with a constructor for each field.
+func UpdateFields(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	pk := t.PrimaryKeys()
+	cols := UpdatableColumns(t, cfg)
+	if len(pk) < 1 || len(cols) < 1 {
+		return
+	}
+	fieldType := t.GoName() + "Field"
+	w.F("// %s is a column and the value to SET it to with %sUpdateFields.\n", fieldType, t.GoName())
+	w.F("type %s struct {\n", fieldType)
+	w.N("	column string")
+	w.N("	value  any")
+	w.N("}\n")
+	for _, col := range cols {
+		w.F("// %sSet%s returns a %s that sets %s to v.\n", t.GoName(), col.GoName(), fieldType, col.SQLName())
+		w.F("func %sSet%s(v %s) %s {\n", t.GoName(), col.GoName(), col.GetGoType(), fieldType)
+		w.F("	return %s{column: \"%s\", value: v}\n", fieldType, col.SQLName())
+		w.N("}\n")
+	}
+
+	pkNames := make([]string, len(pk))
+	pkArgs := make([]string, len(pk))
+	pkWhere := make([]string, len(pk))
+	for i := range pk {
+		pkNames[i] = pk[i].GoName()
+		pkArgs[i] = fmt.Sprintf("%s %s", pk[i].GoName(), pk[i].GetGoType())
+		pkWhere[i] = fmt.Sprintf("%s=?", pk[i].SQLName())
+	}
+	funcName := fmt.Sprintf("%sUpdateFields", t.GoName())
+	op := TableOp(cfg, t, "UpdateFields").Classify(t).Args(pk...)
+	w.F("// %s sets only the given fields of the row with this primary key, leaving every other column\n", funcName)
+	w.F("// untouched. Use this instead of Update when you do not hold the full %s. Does nothing if no fields\n", t.GoName())
+	w.N("// are given. Returns ErrNotFound if no row has this primary key.")
+	if col := VersionColumn(t, cfg); col != nil {
+		w.F("// This does not check %s, but does increment it so other holders of this row see the change.\n", col.SQLName())
+	}
+	w.F("func %s(ctx context.Context, db DB, %s, fields ...%s) error {\n", funcName, strings.Join(pkArgs, ", "), fieldType)
+	w.N("	if len(fields) == 0 {")
+	w.N("		return nil // nothing to write")
+	w.N("	}")
+	w.N("	set := make([]string, 0, len(fields)+1)")
+	w.F("	args := make([]any, 0, len(fields)+%d)\n", len(pk))
+	w.N("	for _, f := range fields {")
+	w.N("		set = append(set, f.column+\"=?\")")
+	w.N("		args = append(args, f.value)")
+	w.N("	}")
+	if set := UpdatedSet(t, cfg); set != "" {
+		w.F("	set = append(set, \"%s\") // Use SQLite to update this column\n", set)
+	}
+	if col := VersionColumn(t, cfg); col != nil {
+		w.F("	set = append(set, \"%s=%s+1\")\n", col.SQLName(), col.SQLName())
+	}
+	w.F("	args = append(args, %s)\n", strings.Join(pkNames, ", "))
+	w.N("	res, err := uncached(db).ExecContext(ctx, // the SET list varies with the fields, so don't cache")
+	w.F("		`UPDATE %s SET `+strings.Join(set, \", \")+` WHERE %s`, args...)\n", t.SQLName(), strings.Join(pkWhere, " AND "))
+	w.N("	if err != nil {")
+	w.F("		return %s\n", op.Wrap("err"))
+	w.N("	}")
+	w.N("	n, err := res.RowsAffected()")
 	w.N("	if err != nil {")
-	w.N("		return merry.Wrap(err)")
+	w.F("		return %s\n", op.Wrap("err"))
+	w.N("	}")
+	w.N("	if n == 0 {")
+	w.F("		return %s\n", op.Wrap("ErrNotFound"))
 	w.N("	}")
 	w.N("	return nil")
 	w.N("}\n\n")
 }
 
+// Loaded writes the method called whenever this row is read from or written to the DB, which marks
+// it as existing and snapshots its values for Changed.
+func Loaded(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	w.N("// loaded marks this row as existing in the database and snapshots its values, so Changed can")
+	w.N("// report which fields were modified since.")
+	w.F("func (x *%s) loaded() {\n", t.GoName())
+	w.N("	x._exists = true")
+	w.N("	s := *x")
+	w.N("	s._snapshot = nil")
+	for _, col := range t.Columns {
+		if col.Type == parser.BLOB { // Copy so in-place edits to x are not mirrored in the snapshot.
+			w.F("	s.%s = append([]byte(nil), x.%s...)\n", col.GoName(), col.GoName())
+		}
+	}
+	w.N("	x._snapshot = &s")
+	if col := SoftDeleteColumn(t, cfg); col != nil {
+		w.F("	x._deleted = x.%s.Valid\n", col.GoName())
+	}
+	w.N("}\n\n")
+}
+
+// Changed writes a method that reports which updatable columns differ from the snapshot taken when
+// the row was last read from or written to the DB.
+func Changed(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	w.N("// Changed returns the SQL names of the columns modified since this row was last read from or")
+	w.N("// written to the database, or every updatable column if it never was. Primary keys and columns")
+	w.N("// the database maintains (e.g. created_at) are never included.")
+	w.F("func (x *%s) Changed() []string {\n", t.GoName())
+	cols := UpdatableColumns(t, cfg)
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = fmt.Sprintf("%q", col.SQLName())
+	}
+	w.N("	if x._snapshot == nil {")
+	w.F("		return []string{%s}\n", strings.Join(names, ", "))
+	w.N("	}")
+	w.N("	s, changed := x._snapshot, []string{}")
+	for _, col := range cols {
+		f := col.GoName()
+		switch col.GetGoType() {
+		case "time.Time":
+			w.F("	if !x.%s.Equal(s.%s) {\n", f, f)
+		case "sql.NullTime":
+			w.F("	if x.%s.Valid != s.%s.Valid || !x.%s.Time.Equal(s.%s.Time) {\n", f, f, f, f)
+		case "[]byte":
+			w.F("	if string(x.%s) != string(s.%s) {\n", f, f)
+		default:
+			w.F("	if x.%s != s.%s {\n", f, f)
+		}
+		w.F("		changed = append(changed, \"%s\")\n", col.SQLName())
+		w.N("	}")
+	}
+	w.N("	return changed")
+	w.N("}\n\n")
+}
+
 // Save
-func Save(w *ShortWriter, t *parser.Table) {
+func Save(w *ShortWriter, t *parser.Table, cfg *config.Config) {
 	if len(t.PrimaryKeys()) < 1 {
 		return
 	}
@@ -309,42 +634,401 @@ func Save(w *ShortWriter, t *parser.Table) {
 }
 
 // Upsert
-func Upsert(w *ShortWriter, t *parser.Table) {
+func Upsert(w *ShortWriter, t *parser.Table, cfg *config.Config) {
 	if len(t.PrimaryKeys()) < 1 {
 		return
 	}
+	op := TableOp(cfg, t, "Upsert").Classify(t).Fields(t.PrimaryKeys()...)
 	w.N("// Upsert this row to the database and update this struct with DB-generated values.")
 	w.N("// Note this does not specify a \"conflict target\": https://www.sqlite.org/lang_upsert.html")
+	if len(ConflictTargets(t, cfg)) > 0 {
+		w.N("// Use an UpsertOn method to name one.")
+	}
+	if col := VersionColumn(t, cfg); col != nil {
+		w.F("// Returns ErrStaleVersion if the existing row's %s does not match this struct's.\n", col.SQLName())
+	}
 	w.F("func (x *%s) Upsert(ctx context.Context, db DB) error {\n", t.GoName())
 	w.N("	switch {")
 	w.N("	case x._deleted: // deleted")
-	w.N("		return merry.Wrap(ErrUpsertMarkedForDeletion)")
+	w.F("		return %s\n", op.Wrap("ErrUpsertMarkedForDeletion"))
 	w.N("	}")
-	w.N("	stmt, err := db.PrepareNamedContext(ctx,`")
-	w.F("		INSERT INTO %s (%s)\n", t.SQLName(), InsertColumns(t, false))
-	w.F("		VALUES (%s)\n", InsertColumns(t, true))
-	w.F("		ON CONFLICT DO UPDATE SET %s\n", UpsertUpdateColumns(t))
-	w.N("		RETURNING *`)")
+	CallHook(w, op, "BeforeUpsert", 1, "")
+	p := NewParams(cfg)
+	ScanReturning(w, t, rawSQL("\t\t",
+		fmt.Sprintf("INSERT INTO %s (%s)", t.SQLName(), InsertColumns(t, cfg, nil)),
+		fmt.Sprintf("VALUES (%s)", InsertColumns(t, cfg, p)),
+		fmt.Sprintf("ON CONFLICT DO UPDATE SET %s%s", UpsertUpdateColumns(t, cfg), UpsertWhere(t, cfg, p)),
+		fmt.Sprintf("RETURNING %s", SelectColumns(t))), p)
+	StaleOnNoRows(w, t, cfg, op, "")
 	w.N("	if err != nil {")
-	w.N("		return merry.Wrap(err)")
+	w.F("		return %s\n", op.Wrap("err"))
 	w.N("	}")
-	w.N("	defer stmt.Close()")
-	w.N("	err = stmt.GetContext(ctx, x, x)")
+	w.N("	// set exists")
+	w.N("	x.loaded()")
+	CallHook(w, op, "AfterUpsert", 1, "")
+	w.N("	return nil")
+	w.N("}\n\n")
+}
+
+// UpsertOn writes an upsert for each of the table's conflict targets (see ConflictTargets), which
+// updates every column except those in the target on a conflict, plus a DO NOTHING variant that
+// reports whether the row was inserted.
+func UpsertOn(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	for _, target := range ConflictTargets(t, cfg) {
+		cols := strings.Join(target.Columns, ", ")
+		keys := make([]*parser.Column, len(target.Columns))
+		for i, name := range target.Columns {
+			keys[i] = t.Column(name)
+		}
+		if set := UpsertUpdateColumns(t, cfg, target.Columns...); set != "" {
+			w.F("// UpsertOn%s upserts this row to the database, updating the existing row on a conflict with\n", target.Name)
+			w.F("// %s (%s), and updates this struct with DB-generated values.\n", target.Source, cols)
+			w.F("func (x *%s) UpsertOn%s(ctx context.Context, db DB) error {\n", t.GoName(), target.Name)
+			op := TableOp(cfg, t, "UpsertOn"+target.Name).Classify(t).Fields(keys...)
+			w.N("	switch {")
+			w.N("	case x._deleted: // deleted")
+			w.F("		return %s\n", op.Wrap("ErrUpsertMarkedForDeletion"))
+			w.N("	}")
+			CallHook(w, op, "BeforeUpsert", 1, "")
+			p := NewParams(cfg)
+			ScanReturning(w, t, rawSQL("\t\t",
+				fmt.Sprintf("INSERT INTO %s (%s)", t.SQLName(), InsertColumns(t, cfg, nil)),
+				fmt.Sprintf("VALUES (%s)", InsertColumns(t, cfg, p)),
+				fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s%s", cols, set, UpsertWhere(t, cfg, p)),
+				fmt.Sprintf("RETURNING %s", SelectColumns(t))), p)
+			StaleOnNoRows(w, t, cfg, op, "")
+			w.N("	if err != nil {")
+			w.F("		return %s\n", op.Wrap("err"))
+			w.N("	}")
+			w.N("	x.loaded()")
+			CallHook(w, op, "AfterUpsert", 1, "")
+			w.N("	return nil")
+			w.N("}\n\n")
+		}
+
+		w.F("// UpsertOn%sDoNothing inserts this row into the database unless it conflicts with\n", target.Name)
+		w.F("// %s (%s), in which case the existing row is left unchanged.\n", target.Source, cols)
+		w.N("// Returns true if the row was inserted (and updates this struct with DB-generated values), or false")
+		w.N("// if it conflicted.")
+		w.F("func (x *%s) UpsertOn%sDoNothing(ctx context.Context, db DB) (bool, error) {\n", t.GoName(), target.Name)
+		op := TableOp(cfg, t, "UpsertOn"+target.Name+"DoNothing").Classify(t).Fields(keys...)
+		w.N("	switch {")
+		w.N("	case x._deleted: // deleted")
+		w.F("		return false, %s\n", op.Wrap("ErrUpsertMarkedForDeletion"))
+		w.N("	}")
+		CallHook(w, op, "BeforeUpsert", 1, "false, ")
+		p := NewParams(cfg)
+		ScanReturning(w, t, rawSQL("\t\t",
+			fmt.Sprintf("INSERT INTO %s (%s)", t.SQLName(), InsertColumns(t, cfg, nil)),
+			fmt.Sprintf("VALUES (%s)", InsertColumns(t, cfg, p)),
+			fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", cols),
+			fmt.Sprintf("RETURNING %s", SelectColumns(t))), p)
+		w.N("	if errors.Is(err, sql.ErrNoRows) { // conflicted, so nothing was inserted or returned")
+		w.N("		return false, nil")
+		w.N("	}")
+		w.N("	if err != nil {")
+		w.F("		return false, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	x.loaded()")
+		CallHook(w, op, "AfterUpsert", 1, "true, ")
+		w.N("	return true, nil")
+		w.N("}\n\n")
+	}
+}
+
+// InsertMany
+func InsertMany(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	funcName := fmt.Sprintf("%sInsertMany", t.GoName())
+	// Insert one at a time if there is nothing to put in a VALUES list, or nothing to match the
+	// batch's RETURNING rows to the rows written by.
+	oneByOne := len(InsertableColumns(t, cfg)) == 0 || batchKey(t, cfg) == nil && rowidColumn(t) == nil
+	if oneByOne {
+		w.F("// %s inserts rows into the database one at a time with Insert, and updates each struct with\n", funcName)
+		w.N("// DB-generated values.")
+	} else {
+		w.F("// %s inserts rows into the database using multi-row INSERT statements, batched to stay within\n", funcName)
+		w.N("// SQLiteMaxVariableNumber, and updates each struct with DB-generated values.")
+	}
+	w.N("// Return an error on conflicts.")
+	if oneByOne {
+		w.N("// The rows are not written atomically, so use a transaction if a partial insert is not acceptable.")
+	} else {
+		w.N("// Batches are not atomic as a whole, so use a transaction if a partial insert is not acceptable.")
+	}
+	op := TableOp(cfg, t, "InsertMany").Classify(t)
+	rowOp := TableOp(cfg, t, "InsertMany").Fields(insertKeys(t)...)
+	w.F("func %s(ctx context.Context, db DB, rows []*%s) error {\n", funcName, t.GoName())
+	w.N("	for _, x := range rows {")
+	w.N("		switch {")
+	w.N("		case x._exists:")
+	w.F("			return %s\n", rowOp.Wrap("ErrInsertAlreadyExists"))
+	w.N("		case x._deleted:")
+	w.F("			return %s\n", rowOp.Wrap("ErrInsertMarkedForDeletion"))
+	w.N("		}")
+	w.N("	}")
+	if oneByOne {
+		w.N("	for _, x := range rows {")
+		w.N("		if err := x.Insert(ctx, db); err != nil {")
+		w.N("			return err")
+		w.N("		}")
+		w.N("	}")
+		w.N("	return nil")
+		w.N("}\n\n")
+		return
+	}
+	CallHooks(w, rowOp, "BeforeInsert")
+	w.F("	err := write%sBatches(ctx, db, rows,\n", t.GoName())
+	w.F("		`INSERT INTO %s (%s) VALUES `,\n", t.SQLName(), InsertColumns(t, cfg, nil))
+	w.F("		` RETURNING %s`)\n", SelectColumns(t))
 	w.N("	if err != nil {")
-	w.N("		return merry.Wrap(err)")
+	w.F("		return %s\n", op.Wrap("err"))
 	w.N("	}")
-	w.N("	// set exists")
-	w.N("	x._exists = true")
+	CallHooks(w, rowOp, "AfterInsert")
 	w.N("	return nil")
 	w.N("}\n\n")
+	writeBatches(w, t, cfg)
+}
+
+// UpsertMany
+func UpsertMany(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	if len(t.PrimaryKeys()) < 1 {
+		return
+	}
+	funcName := fmt.Sprintf("%sUpsertMany", t.GoName())
+	// Upsert one at a time if there is nothing to put in a VALUES list, if a stale version would
+	// drop a row from the batch's RETURNING, or if there is no key to match the RETURNING rows to
+	// the rows written by (the rowid is not, as an update keeps the existing row's).
+	oneByOne := len(InsertableColumns(t, cfg)) == 0 || VersionColumn(t, cfg) != nil || batchKey(t, cfg) == nil
+	if oneByOne {
+		w.F("// %s upserts rows to the database one at a time with Upsert, and updates each struct with\n", funcName)
+		w.N("// DB-generated values.")
+	} else {
+		w.F("// %s upserts rows to the database using multi-row INSERT statements, batched to stay within\n", funcName)
+		w.N("// SQLiteMaxVariableNumber, and updates each struct with DB-generated values.")
+	}
+	if oneByOne {
+		w.N("// The rows are not written atomically, so use a transaction if a partial upsert is not acceptable.")
+	} else {
+		w.N("// Batches are not atomic as a whole, so use a transaction if a partial upsert is not acceptable.")
+	}
+	op := TableOp(cfg, t, "UpsertMany").Classify(t)
+	rowOp := TableOp(cfg, t, "UpsertMany").Fields(t.PrimaryKeys()...)
+	w.F("func %s(ctx context.Context, db DB, rows []*%s) error {\n", funcName, t.GoName())
+	w.N("	for _, x := range rows {")
+	w.N("		if x._deleted {")
+	w.F("			return %s\n", rowOp.Wrap("ErrUpsertMarkedForDeletion"))
+	w.N("		}")
+	w.N("	}")
+	if oneByOne {
+		w.N("	for _, x := range rows {")
+		w.N("		if err := x.Upsert(ctx, db); err != nil {")
+		w.N("			return err")
+		w.N("		}")
+		w.N("	}")
+		w.N("	return nil")
+		w.N("}\n\n")
+		return
+	}
+	CallHooks(w, rowOp, "BeforeUpsert")
+	w.F("	err := write%sBatches(ctx, db, rows,\n", t.GoName())
+	w.F("		`INSERT INTO %s (%s) VALUES `,\n", t.SQLName(), InsertColumns(t, cfg, nil))
+	w.F("		` ON CONFLICT DO UPDATE SET %s RETURNING %s`)\n", UpsertUpdateColumns(t, cfg), SelectColumns(t))
+	w.N("	if err != nil {")
+	w.F("		return %s\n", op.Wrap("err"))
+	w.N("	}")
+	CallHooks(w, rowOp, "AfterUpsert")
+	w.N("	return nil")
+	w.N("}\n\n")
+}
+
+// writeBatches writes the helpers InsertMany and UpsertMany use when batching: they split rows into
+// batches that fit within SQLiteMaxVariableNumber, run prefix + VALUES list + suffix for each, and
+// scan the RETURNING rows back into the structs. SQLite returns them in no particular order, so
+// they are matched to the structs by batchKey, or if the table has none, by the rowid SQLite
+// assigned each (only InsertMany batches then). Their errors are wrapped by the caller.
+func writeBatches(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	cols := InsertableColumns(t, cfg)
+	fields := make([]string, len(cols))
+	for i, col := range cols {
+		fields[i] = "x." + col.GoName()
+	}
+	key := batchKey(t, cfg)
+	w.F("// write%sBatches runs prefix + VALUES list + suffix for each batch of rows that fits within\n", t.GoName())
+	w.N("// SQLiteMaxVariableNumber.")
+	w.F("func write%sBatches(ctx context.Context, db DB, rows []*%s, prefix, suffix string) error {\n", t.GoName(), t.GoName())
+	w.F("	const numCols = %d\n", len(cols))
+	w.N("	size := max(1, SQLiteMaxVariableNumber/numCols)")
+	w.N("	for len(rows) > 0 {")
+	w.N("		batch := rows[:min(size, len(rows))]")
+	w.N("		rows = rows[len(batch):]")
+	w.F("		if err := write%sBatch(ctx, db, batch, prefix+valuesList(len(batch), numCols)+suffix); err != nil {\n", t.GoName())
+	w.N("			return err")
+	w.N("		}")
+	w.N("	}")
+	w.N("	return nil")
+	w.N("}\n\n")
+
+	w.F("// write%sBatch runs query with the insertable columns of every row in batch and scans the\n", t.GoName())
+	if len(key) > 0 {
+		keys := []string{}
+		for _, col := range key {
+			keys = append(keys, col.SQLName())
+		}
+		w.F("// RETURNING rows back into batch, matched by (%s) as SQLite returns them in no particular order.\n", strings.Join(keys, ", "))
+	} else {
+		w.F("// RETURNING rows back into batch, matched by %s as SQLite returns them in no particular order but\n", rowidColumn(t).SQLName())
+		w.N("// assigns rowids in VALUES order.")
+	}
+	w.N("// Returns ErrBatchMismatch if the rows returned are not the rows in batch.")
+	w.F("func write%sBatch(ctx context.Context, db DB, batch []*%s, query string) error {\n", t.GoName(), t.GoName())
+	w.F("	args := make([]any, 0, len(batch)*%d)\n", len(cols))
+	if len(key) > 0 {
+		xKey := []string{}
+		for _, col := range key {
+			xKey = append(xKey, "x."+col.GoName())
+		}
+		keyType := fmt.Sprintf("[%d]any", len(key))
+		w.F("	byKey := make(map[%s]*%s, len(batch))\n", keyType, t.GoName())
+		w.N("	for _, x := range batch {")
+		w.F("		args = append(args, %s)\n", strings.Join(fields, ", "))
+		w.F("		byKey[%s{%s}] = x\n", keyType, strings.Join(xKey, ", "))
+		w.N("	}")
+		w.N("	if len(byKey) < len(batch) { // a key written twice can't be told apart")
+		w.N("		return ErrBatchMismatch")
+		w.N("	}")
+	} else {
+		w.N("	for _, x := range batch {")
+		w.F("		args = append(args, %s)\n", strings.Join(fields, ", "))
+		w.N("	}")
+	}
+	w.N("	rows, err := uncached(db).QueryContext(ctx, query, args...) // batch sizes vary, so don't cache")
+	w.N("	if err != nil {")
+	w.N("		return err")
+	w.N("	}")
+	w.N("	defer rows.Close()")
+	if len(key) > 0 {
+		rowKey := []string{}
+		for _, col := range key {
+			rowKey = append(rowKey, "row."+col.GoName())
+		}
+		keyExpr := fmt.Sprintf("[%d]any{%s}", len(key), strings.Join(rowKey, ", "))
+		w.N("	for rows.Next() {")
+		w.F("		var row %s\n", t.GoName())
+		w.F("		if err := %s(rows, &row); err != nil {\n", ScanFunc(t))
+		w.N("			return err")
+		w.N("		}")
+		w.F("		x := byKey[%s]\n", keyExpr)
+		w.N("		if x == nil {")
+		w.N("			return ErrBatchMismatch")
+		w.N("		}")
+		w.F("		delete(byKey, %s)\n", keyExpr)
+		w.N("		*x = row")
+		w.N("		x.loaded()")
+		w.N("	}")
+		w.N("	if err := rows.Err(); err != nil {")
+		w.N("		return err")
+		w.N("	}")
+		w.N("	if len(byKey) > 0 {")
+		w.N("		return ErrBatchMismatch")
+		w.N("	}")
+	} else {
+		rowid := "row." + rowidColumn(t).GoName()
+		if rowidColumn(t).Nullable { // SQLite never leaves a rowid NULL
+			rowid += ".Int64"
+		}
+		w.F("	byRowid := make(map[int64]%s, len(batch))\n", t.GoName())
+		w.N("	rowids := make([]int64, 0, len(batch))")
+		w.N("	for rows.Next() {")
+		w.F("		var row %s\n", t.GoName())
+		w.F("		if err := %s(rows, &row); err != nil {\n", ScanFunc(t))
+		w.N("			return err")
+		w.N("		}")
+		w.F("		byRowid[%s] = row\n", rowid)
+		w.F("		rowids = append(rowids, %s)\n", rowid)
+		w.N("	}")
+		w.N("	if err := rows.Err(); err != nil {")
+		w.N("		return err")
+		w.N("	}")
+		w.N("	if len(byRowid) != len(batch) || len(rowids) != len(batch) {")
+		w.N("		return ErrBatchMismatch")
+		w.N("	}")
+		w.N("	slices.Sort(rowids)")
+		w.N("	for i, x := range batch {")
+		w.N("		*x = byRowid[rowids[i]]")
+		w.N("		x.loaded()")
+		w.N("	}")
+	}
+	w.N("	return nil")
+	w.N("}\n\n")
+}
+
+// rowidColumn returns the table's alias for the rowid (e.g. id INTEGER PRIMARY KEY), which SQLite
+// assigns to the rows of an INSERT in VALUES order, or nil if it has none.
+func rowidColumn(t *parser.Table) *parser.Column {
+	if pks := t.PrimaryKeys(); len(pks) == 1 && pks[0].AutoIncrement() {
+		return pks[0]
+	}
+	return nil
+}
+
+// batchKey returns the columns of a unique key that InsertMany and UpsertMany write and SQLite
+// returns unchanged, to match RETURNING rows to the rows written, or nil if there is none. Only
+// NOT NULL INTEGER and TEXT columns qualify, as NULLs are never equal and other types may not
+// read back as written. UNIQUE constraints and indexes come before the primary key, as an upsert
+// sets their columns to the values written, while a conflict on one of them keeps the old primary
+// key.
+func batchKey(t *parser.Table, cfg *config.Config) []*parser.Column {
+	insertable := InsertableColumns(t, cfg)
+	// key returns the named columns if they qualify, or nil if not.
+	key := func(names []string) []*parser.Column {
+		cols := []*parser.Column{}
+		for _, n := range names {
+			i := slices.IndexFunc(insertable, func(col *parser.Column) bool { return col.SQLName() == n })
+			if i < 0 || insertable[i].Nullable || (insertable[i].Type != parser.INT && insertable[i].Type != parser.TEXT) {
+				return nil
+			}
+			cols = append(cols, insertable[i])
+		}
+		return cols
+	}
+	candidates := [][]string{}
+	for _, uc := range t.UniqueConstraints {
+		candidates = append(candidates, uc.Columns)
+	}
+	for _, idx := range t.Indexes {
+		if idx.Unique && !idx.Partial() {
+			candidates = append(candidates, idx.Columns)
+		}
+	}
+	pk := []string{}
+	for _, col := range t.PrimaryKeys() {
+		pk = append(pk, col.SQLName())
+	}
+	candidates = append(candidates, pk)
+	for _, names := range candidates {
+		if cols := key(names); len(names) > 0 && cols != nil {
+			return cols
+		}
+	}
+	return nil
 }
 
 // Delete
-func Delete(w *ShortWriter, t *parser.Table) {
+func Delete(w *ShortWriter, t *parser.Table, cfg *config.Config) {
 	if len(t.PrimaryKeys()) < 1 {
 		return
 	}
+	if SoftDeleteColumn(t, cfg) != nil {
+		SoftDelete(w, t, cfg)
+		return
+	}
+	op := TableOp(cfg, t, "Delete").Classify(t).Fields(t.PrimaryKeys()...)
 	w.N("// Delete this row from the database.")
+	if col := VersionColumn(t, cfg); col != nil {
+		w.F("// Returns ErrStaleVersion if %s no longer matches the row in the database.\n", col.SQLName())
+	} else {
+		w.N("// Returns ErrNotFound if the row has already been deleted.")
+	}
 	w.F("func (x *%s) Delete(ctx context.Context, db DB) error {\n", t.GoName())
 	w.N("	switch {")
 	w.N("	case !x._exists: // doesn't exist")
@@ -352,19 +1036,16 @@ func Delete(w *ShortWriter, t *parser.Table) {
 	w.N("	case x._deleted:")
 	w.N("		return nil")
 	w.N("	}")
-	w.N("	_, err := db.NamedExecContext(ctx, `")
-	w.F("			DELETE FROM %s\n", t.SQLName())
-	w.F("			WHERE %s`, x)\n", WherePKs(t))
-	w.N("	if err != nil {")
-	w.N("		return merry.Wrap(err)")
-	w.N("	}")
+	CallHook(w, op, "BeforeDelete", 1, "")
+	DeleteExec(w, t, cfg, op)
 	w.N("	x._deleted = true")
+	CallHook(w, op, "AfterDelete", 1, "")
 	w.N("	return nil")
 	w.N("}\n\n")
 }
 
 // GetByPk
-func GetByPk(w *ShortWriter, t *parser.Table) {
+func GetByPk(w *ShortWriter, t *parser.Table, cfg *config.Config) {
 	pk := t.PrimaryKeys()
 	if len(pk) < 1 {
 		return
@@ -379,20 +1060,27 @@ func GetByPk(w *ShortWriter, t *parser.Table) {
 		pkArgs[i] = fmt.Sprintf("%s %s", pk[i].GoName(), pk[i].GetGoType())
 		pkWhere[i] = fmt.Sprintf("%s=?", pk[i].SQLName())
 	}
-	funcName := fmt.Sprintf("%sGetBy%s", t.GoName(), strings.Join(pkNames, ""))
-	w.F("// %s (Primary Key)\n", funcName)
-	w.F("func %s(ctx context.Context, db DB, %s) (*%s, error) {\n", funcName, strings.Join(pkArgs, ", "), t.GoName())
-	w.F("	row := %s{}\n", t.GoName())
-	w.F("	err := db.GetContext(ctx, &row, `\n")
-	w.N("		SELECT *")
-	w.F("		FROM %s\n", t.SQLName())
-	w.F("		WHERE %s`, %s)\n", strings.Join(pkWhere, " AND "), strings.Join(pkNames, ", "))
-	w.N("	if err != nil {")
-	w.N("		return nil, merry.Wrap(err)")
-	w.N("	}")
-	w.N("	row._exists = true")
-	w.N("	return &row, nil")
-	w.N("}\n\n")
+	base := fmt.Sprintf("%sGetBy%s", t.GoName(), strings.Join(pkNames, ""))
+	for _, v := range ReadVariants(t, cfg, base, false) {
+		funcName := base + v.Suffix
+		op := TableOp(cfg, t, strings.TrimPrefix(funcName, t.GoName())).Args(pk...)
+		w.F("// %s (Primary Key)\n", funcName)
+		v.WriteDoc(w)
+		w.F("func %s(ctx context.Context, db DB, %s) (*%s, error) {\n", funcName, strings.Join(pkArgs, ", "), t.GoName())
+		w.F("	row := %s{}\n", t.GoName())
+		w.F("	err := %s(db.QueryRowContext(ctx, `\n", ScanFunc(t))
+		w.F("		SELECT %s\n", SelectColumns(t))
+		w.F("		FROM %s\n", t.SQLName())
+		w.F("		WHERE %s`, %s), &row)\n", v.And(strings.Join(pkWhere, " AND ")), strings.Join(pkNames, ", "))
+		NotFoundOnNoRows(w, op, "nil, ")
+		w.N("	if err != nil {")
+		w.F("		return nil, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	row.loaded()")
+		w.N("	return &row, nil")
+		w.N("}\n\n")
+		Find(w, t, funcName, fmt.Sprintf("%sFindBy%s%s", t.GoName(), strings.Join(pkNames, ""), v.Suffix), strings.Join(pkArgs, ", "), strings.Join(pkNames, ", "))
+	}
 }
 
 // GetByUnique
@@ -401,7 +1089,7 @@ func GetByPk(w *ShortWriter, t *parser.Table) {
 // unique, meaning querying for NULL would return _multiple_ rows. Because this
 // is an ambigious situation, we do not gererate a getter for columns that are
 // both unique, and nullable.
-func GetByUnique(w *ShortWriter, t *parser.Table) {
+func GetByUnique(w *ShortWriter, t *parser.Table, cfg *config.Config) {
 	for _, col := range t.Columns {
 		switch {
 		case !t.SingleColumnUnique(col.SQLName()):
@@ -409,25 +1097,45 @@ func GetByUnique(w *ShortWriter, t *parser.Table) {
 		case col.Nullable:
 			continue
 		}
-		funcName := fmt.Sprintf("%sGetBy%s", t.GoName(), col.GoName())
-		w.F("// %s (Unique Column)\n", funcName)
-		w.F("func %s(ctx context.Context, db DB, %s %s) (*%s, error) {\n", funcName, col.GoName(), col.GetGoType(), t.GoName())
-		w.F("	row := %s{}\n", t.GoName())
-		w.F("	err := db.GetContext(ctx, &row, `\n")
-		w.N("		SELECT *")
-		w.F("		FROM %s\n", t.SQLName())
-		w.F("		WHERE %s=?`, %s)\n", col.SQLName(), col.GoName())
-		w.N("	if err != nil {")
-		w.N("		return nil, merry.Wrap(err)")
-		w.N("	}")
-		w.N("	row._exists = true")
-		w.N("	return &row, nil")
-		w.N("}\n\n")
+		base := fmt.Sprintf("%sGetBy%s", t.GoName(), col.GoName())
+		for _, v := range ReadVariants(t, cfg, base, false) {
+			funcName := base + v.Suffix
+			op := TableOp(cfg, t, strings.TrimPrefix(funcName, t.GoName())).Args(&col)
+			w.F("// %s (Unique Column)\n", funcName)
+			v.WriteDoc(w)
+			w.F("func %s(ctx context.Context, db DB, %s %s) (*%s, error) {\n", funcName, col.GoName(), col.GetGoType(), t.GoName())
+			w.F("	row := %s{}\n", t.GoName())
+			w.F("	err := %s(db.QueryRowContext(ctx, `\n", ScanFunc(t))
+			w.F("		SELECT %s\n", SelectColumns(t))
+			w.F("		FROM %s\n", t.SQLName())
+			w.F("		WHERE %s`, %s), &row)\n", v.And(col.SQLName()+"=?"), col.GoName())
+			NotFoundOnNoRows(w, op, "nil, ")
+			w.N("	if err != nil {")
+			w.F("		return nil, %s\n", op.Wrap("err"))
+			w.N("	}")
+			w.N("	row.loaded()")
+			w.N("	return &row, nil")
+			w.N("}\n\n")
+			Find(w, t, funcName, fmt.Sprintf("%sFindBy%s%s", t.GoName(), col.GoName(), v.Suffix), col.GoName()+" "+col.GetGoType(), col.GoName())
+		}
 	}
 }
 
+// Find writes findName, which calls the getter getName with args (declared as params) but returns
+// nil instead of ErrNotFound if no row matches.
+func Find(w *ShortWriter, t *parser.Table, getName, findName, params, args string) {
+	w.F("// %s is %s, but returns nil instead of ErrNotFound if no row matches.\n", findName, getName)
+	w.F("func %s(ctx context.Context, db DB, %s) (*%s, error) {\n", findName, params, t.GoName())
+	w.F("	row, err := %s(ctx, db, %s)\n", getName, args)
+	w.N("	if errors.Is(err, ErrNotFound) {")
+	w.N("		return nil, nil")
+	w.N("	}")
+	w.N("	return row, err")
+	w.N("}\n\n")
+}
+
 // GetAll
-func GetAll(w *ShortWriter, t *parser.Table) {
+func GetAll(w *ShortWriter, t *parser.Table, cfg *config.Config) {
 	pk := t.PrimaryKeys()
 	if len(pk) < 1 {
 		return
@@ -436,19 +1144,76 @@ func GetAll(w *ShortWriter, t *parser.Table) {
 	for i := range pk {
 		pkName = pkName + pk[i].GoName()
 	}
-	funcName := fmt.Sprintf("%sGetAll", t.GoName())
-	w.F("// %s\n", funcName)
-	w.F("func %s(ctx context.Context, db DB) ([]*%s, error) {\n", funcName, t.GoName())
-	w.F("	all := []*%s{}\n", t.GoName())
-	w.F("	err := db.SelectContext(ctx, &all, `\n")
-	w.N("		SELECT *")
-	w.F("		FROM %s`)\n", t.SQLName())
-	w.N("	if err != nil {")
-	w.N("		return nil, merry.Wrap(err)")
-	w.N("	}")
-	w.N("	for i := range all {")
-	w.F("		all[i]._exists = true")
+	base := t.GoName() + "GetAll"
+	for _, v := range ReadVariants(t, cfg, base, true) {
+		funcName := base + v.Suffix
+		op := TableOp(cfg, t, strings.TrimPrefix(funcName, t.GoName()))
+		w.F("// %s\n", funcName)
+		v.WriteDoc(w)
+		w.F("func %s(ctx context.Context, db DB) ([]*%s, error) {\n", funcName, t.GoName())
+		w.N("	rows, err := db.QueryContext(ctx, `")
+		w.F("		SELECT %s\n", SelectColumns(t))
+		w.F("		FROM %s%s`)\n", t.SQLName(), v.Where())
+		w.N("	if err != nil {")
+		w.F("		return nil, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	defer rows.Close()")
+		w.F("	all := []*%s{}\n", t.GoName())
+		w.N("	for rows.Next() {")
+		w.F("		row := &%s{}\n", t.GoName())
+		w.F("		if err := %s(rows, row); err != nil {\n", ScanFunc(t))
+		w.F("			return nil, %s\n", op.Wrap("err"))
+		w.N("		}")
+		w.N("		row.loaded()")
+		w.N("		all = append(all, row)")
+		w.N("	}")
+		w.N("	if err := rows.Err(); err != nil {")
+		w.F("		return nil, %s\n", op.Wrap("err"))
+		w.N("	}")
+		w.N("	return all, nil")
+		w.N("}\n\n")
+	}
+}
+
+// All writes an iterator over every row in the table. Unlike GetAll, rows are scanned one at a time
+// as the caller ranges over the result, so large tables can be walked in constant memory.
+func All(w *ShortWriter, t *parser.Table, cfg *config.Config) {
+	base := t.GoName() + "All"
+	for _, v := range ReadVariants(t, cfg, base, true) {
+		funcName := base + v.Suffix
+		w.F("// %s streams every row from '%s', closing the rows when the loop ends or breaks early.\n", funcName, t.SQLName())
+		w.F("// Use %sGetAll%s if the rows should be loaded into memory at once.\n", t.GoName(), v.Suffix)
+		v.WriteDoc(w)
+		IterRows(w, t, TableOp(cfg, t, strings.TrimPrefix(funcName, t.GoName())), funcName, fmt.Sprintf("SELECT %s FROM %s%s", SelectColumns(t), t.SQLName(), v.Where()))
+	}
+}
+
+// IterRows writes a function named funcName returning an iter.Seq2 over the rows of t matched by
+// query. The rows are closed when the caller stops ranging (e.g. break), and any query, scan, or
+// iteration error is wrapped by op and yielded as the final value.
+func IterRows(w *ShortWriter, t *parser.Table, op *Op, funcName, query string) {
+	w.F("func %s(ctx context.Context, db DB) iter.Seq2[*%s, error] {\n", funcName, t.GoName())
+	w.F("	return func(yield func(*%s, error) bool) {\n", t.GoName())
+	w.F("		rows, err := db.QueryContext(ctx, `%s`)\n", query)
+	w.N("		if err != nil {")
+	w.F("			yield(nil, %s)\n", op.Wrap("err"))
+	w.N("			return")
+	w.N("		}")
+	w.N("		defer rows.Close()")
+	w.N("		for rows.Next() {")
+	w.F("			row := %s{}\n", t.GoName())
+	w.F("			if err := %s(rows, &row); err != nil {\n", ScanFunc(t))
+	w.F("				yield(nil, %s)\n", op.Wrap("err"))
+	w.N("				return")
+	w.N("			}")
+	w.N("			row.loaded()")
+	w.N("			if !yield(&row, nil) {")
+	w.N("				return")
+	w.N("			}")
+	w.N("		}")
+	w.N("		if err := rows.Err(); err != nil {")
+	w.F("			yield(nil, %s)\n", op.Wrap("err"))
+	w.N("		}")
 	w.N("	}")
-	w.N("	return all, nil")
 	w.N("}\n\n")
 }
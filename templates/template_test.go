@@ -5,23 +5,38 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/joshsziegler/squirrel/config"
 	"github.com/joshsziegler/squirrel/name"
 	"github.com/joshsziegler/squirrel/parser"
+	"github.com/joshsziegler/squirrel/queries"
 )
 
 // generate parses schema SQL and returns the generated Go as a string. It mirrors the real
 // SQL-parsing-to-Go-generation pipeline used by GenerateGoFromSQL.
 func generate(t *testing.T, schema string) string {
+	t.Helper()
+	return generateWith(t, schema, testConfig())
+}
+
+// generateWith is generate using the given config.
+func generateWith(t *testing.T, schema string, cfg *config.Config) string {
 	t.Helper()
 	tables, err := parser.Parse(schema)
 	if err != nil {
 		t.Fatalf("parse: %v", err)
 	}
 	var buf bytes.Buffer
-	Write(&buf, "db", tables, nil, true)
+	Write(&buf, tables, cfg)
 	return buf.String()
 }
 
+// testConfig returns the default config, as if loaded from a file that only sets the package.
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Package = "db"
+	return &cfg
+}
+
 func assertContains(t *testing.T, out, want string) {
 	t.Helper()
 	if !strings.Contains(out, want) {
@@ -215,12 +230,884 @@ CREATE TABLE users (
 	}
 
 	var ctxOnly bytes.Buffer
-	Write(&ctxOnly, "db", tables, nil, true)
+	Write(&ctxOnly, tables, testConfig())
 	assertContains(t, ctxOnly.String(), "ExecContext(ctx context.Context")
 	assertNotContains(t, ctxOnly.String(), "Exec(query string")
 
+	cfg := testConfig()
+	cfg.CtxOnly = false
 	var both bytes.Buffer
-	Write(&both, "db", tables, nil, false)
+	Write(&both, tables, cfg)
 	assertContains(t, both.String(), "ExecContext(ctx context.Context")
 	assertContains(t, both.String(), "Exec(query string")
 }
+
+// TestGenerate_ScanFunctions verifies reads and RETURNING clauses name every column instead of
+// using *, and scan them through the table's scan function rather than sqlx reflection.
+func TestGenerate_ScanFunctions(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE users (
+	id    INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	name  TEXT
+);
+`)
+
+	for _, want := range []string{
+		"type scanner interface {\n\tScan(dest ...any) error\n}",
+		"// scanUser scans a row of the columns id, email, name into x, in order.\nfunc scanUser(row scanner, x *User) error {\n\treturn row.Scan(&x.ID, &x.Email, &x.Name)\n}",
+		"		RETURNING id, email, name`, x), x)",
+		"	err := scanUser(namedQueryRow(ctx, db,`\n\t\tINSERT INTO users (email, name)",
+		"	err := scanUser(db.QueryRowContext(ctx, `\n\t\tSELECT id, email, name\n\t\tFROM users\n\t\tWHERE id=?`, ID), &row)",
+		"	rows, err := db.QueryContext(ctx, `\n\t\tSELECT id, email, name\n\t\tFROM users`)",
+		"		if err := scanUser(rows, row); err != nil {",
+	} {
+		assertContains(t, out, want)
+	}
+	assertNotContains(t, out, "*`")
+	assertNotContains(t, out, "StructScan")
+	assertNotContains(t, out, "db.GetContext(ctx, ")
+	assertNotContains(t, out, "db.SelectContext(ctx, ")
+}
+
+// TestGenerate_AllIterator verifies each table gets an iter.Seq2 streaming variant of GetAll that
+// scans row by row and stops (closing the rows) when the caller breaks out of the loop.
+func TestGenerate_AllIterator(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE users (
+	id   INTEGER NOT NULL PRIMARY KEY,
+	name TEXT NOT NULL
+);
+`)
+
+	assertContains(t, out, `"iter"`)
+	assertNotContains(t, out, "QueryxContext")
+	assertContains(t, out, "func UserAll(ctx context.Context, db DB) iter.Seq2[*User, error] {")
+	assertContains(t, out, "rows, err := db.QueryContext(ctx, `SELECT id, name FROM users`)")
+	assertContains(t, out, "defer rows.Close()")
+	assertContains(t, out, "if !yield(&row, nil) {")
+
+	// Without a generated table, nothing uses iter, so importing it would not compile.
+	cfg := testConfig()
+	cfg.IgnoreTables = []string{"users"}
+	assertNotContains(t, generateWith(t, "CREATE TABLE users (id INTEGER NOT NULL PRIMARY KEY);", cfg), `"iter"`)
+	assertNotContains(t, generate(t, ""), `"iter"`)
+}
+
+// TestGenerate_InsertManyUpsertMany verifies the bulk writers build multi-row INSERT statements
+// (with and without a DO UPDATE clause) batched by SQLiteMaxVariableNumber, and scan the RETURNING
+// rows back by a unique key, as SQLite returns them in no particular order. Without such a key,
+// InsertMany matches them by rowid and UpsertMany upserts one row at a time, as do both without a
+// rowid either.
+func TestGenerate_InsertManyUpsertMany(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE users (
+	id    INTEGER NOT NULL PRIMARY KEY,
+	name  TEXT NOT NULL,
+	email TEXT NOT NULL UNIQUE
+);
+`)
+
+	assertContains(t, out, "var SQLiteMaxVariableNumber = 32766")
+	assertContains(t, out, "func UserInsertMany(ctx context.Context, db DB, rows []*User) error {")
+	assertContains(t, out, "func UserUpsertMany(ctx context.Context, db DB, rows []*User) error {")
+	assertContains(t, out, "`INSERT INTO users (name, email) VALUES `,")
+	assertContains(t, out, "` ON CONFLICT DO UPDATE SET name=EXCLUDED.name, email=EXCLUDED.email RETURNING id, name, email`)")
+	assertContains(t, out, "const numCols = 2")
+	assertContains(t, out, "args = append(args, x.Name, x.Email)")
+	assertContains(t, out, "size := max(1, SQLiteMaxVariableNumber/numCols)")
+	assertContains(t, out, "byKey[[1]any{x.Email}] = x")
+	assertContains(t, out, "x := byKey[[1]any{row.Email}]")
+	assertContains(t, out, "return ErrBatchMismatch")
+
+	out = generate(t, `
+CREATE TABLE events (
+	id   INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	note TEXT UNIQUE
+);
+`)
+	assertContains(t, out, "size := max(1, SQLiteMaxVariableNumber/numCols)")
+	assertContains(t, out, "byRowid[row.ID.Int64] = row")
+	assertContains(t, out, "	slices.Sort(rowids)\n	for i, x := range batch {\n		*x = byRowid[rowids[i]]")
+	assertContains(t, out, "// EventUpsertMany upserts rows to the database one at a time with Upsert")
+	assertContains(t, out, "		if err := x.Upsert(ctx, db); err != nil {")
+	assertNotContains(t, out, "byKey")
+
+	out = generate(t, `
+CREATE TABLE tags (
+	name TEXT PRIMARY KEY,
+	note TEXT
+);
+`)
+	assertContains(t, out, "// TagInsertMany inserts rows into the database one at a time with Insert")
+	assertContains(t, out, "		if err := x.Insert(ctx, db); err != nil {")
+	assertNotContains(t, out, "writeTagBatches")
+}
+
+// TestGenerate_UpsertOnConflictTargets verifies an UpsertOn method (and its DO NOTHING variant) is
+// generated for each PK, UNIQUE constraint, and unique index, naming the target columns in
+// ON CONFLICT and leaving them out of DO UPDATE SET. An auto-incrementing PK and partial unique
+// indexes are not targets.
+func TestGenerate_UpsertOnConflictTargets(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE subscriptions (
+	id            INTEGER NOT NULL PRIMARY KEY,
+	fk_owner_id   INTEGER NOT NULL,
+	fk_channel_id INTEGER NOT NULL,
+	email         TEXT NOT NULL UNIQUE,
+	slug          TEXT NOT NULL,
+	note          TEXT NOT NULL,
+	CONSTRAINT uc_owner_channel UNIQUE (fk_owner_id, fk_channel_id)
+);
+CREATE UNIQUE INDEX idx_subscriptions_slug ON subscriptions (slug);
+CREATE UNIQUE INDEX idx_subscriptions_note ON subscriptions (note) WHERE note != '';
+`)
+
+	assertContains(t, out, "// Use an UpsertOn method to name one.")
+	assertContains(t, out, "func (x *Subscription) UpsertOnEmail(ctx context.Context, db DB) error {")
+	assertContains(t, out, "ON CONFLICT (email) DO UPDATE SET fk_owner_id=EXCLUDED.fk_owner_id, fk_channel_id=EXCLUDED.fk_channel_id, slug=EXCLUDED.slug, note=EXCLUDED.note")
+	assertContains(t, out, "func (x *Subscription) UpsertOnOwnerChannel(ctx context.Context, db DB) error {")
+	assertContains(t, out, "ON CONFLICT (fk_owner_id, fk_channel_id) DO UPDATE SET email=EXCLUDED.email, slug=EXCLUDED.slug, note=EXCLUDED.note")
+	assertContains(t, out, "func (x *Subscription) UpsertOnSlug(ctx context.Context, db DB) error {")
+	assertContains(t, out, "func (x *Subscription) UpsertOnEmailDoNothing(ctx context.Context, db DB) (bool, error) {")
+	assertContains(t, out, "ON CONFLICT (email) DO NOTHING")
+	assertNotContains(t, out, "UpsertOnID")
+	assertNotContains(t, out, "UpsertOnNote")
+
+	// The table's name, singular or plural, is left out of constraint names wherever it is.
+	out = generate(t, `
+CREATE TABLE memberships (
+	user_id  INTEGER NOT NULL,
+	group_id INTEGER NOT NULL,
+	badge    TEXT NOT NULL,
+	CONSTRAINT pk_memberships PRIMARY KEY (user_id, group_id),
+	CONSTRAINT uc_membership_badge UNIQUE (badge)
+);
+`)
+	assertContains(t, out, "func (x *Membership) UpsertOnUserIDGroupID(ctx context.Context, db DB) error {")
+	assertContains(t, out, "ErrMembershipUserIDGroupIDTaken")
+	assertContains(t, out, "func (x *Membership) UpsertOnBadge(ctx context.Context, db DB) error {")
+	assertNotContains(t, out, "MembershipMembership")
+
+	// Names that still clash after falling back to the columns take the constraint name, and then a
+	// number.
+	out = generate(t, `
+CREATE TABLE handles (
+	id     INTEGER NOT NULL PRIMARY KEY,
+	email  TEXT NOT NULL,
+	name   TEXT NOT NULL,
+	code   TEXT NOT NULL,
+	alias  TEXT NOT NULL,
+	CONSTRAINT uc_uq_name_email UNIQUE (alias),
+	CONSTRAINT uc_name_email UNIQUE (code),
+	CONSTRAINT uq_name_email UNIQUE (name, email)
+);
+CREATE UNIQUE INDEX ux_email ON handles (email);
+CREATE UNIQUE INDEX ix_email ON handles (email, code);
+`)
+	assertContains(t, out, "func (x *Handle) UpsertOnUqNameEmail(ctx context.Context, db DB) error {")
+	assertContains(t, out, "func (x *Handle) UpsertOnNameEmail(ctx context.Context, db DB) error {")
+	assertContains(t, out, "func (x *Handle) UpsertOnUqNameEmail2(ctx context.Context, db DB) error {")
+	assertContains(t, out, "func (x *Handle) UpsertOnEmail(ctx context.Context, db DB) error {")
+	assertContains(t, out, "func (x *Handle) UpsertOnEmailCode(ctx context.Context, db DB) error {")
+}
+
+// TestGenerate_DirtyTracking verifies rows snapshot their values when loaded, Update only SETs the
+// fields changed since (skipping the round trip when none did), and UpdateFields is generated with a
+// constructor per updatable field.
+func TestGenerate_DirtyTracking(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE users (
+	id         INTEGER NOT NULL PRIMARY KEY,
+	name       TEXT NOT NULL,
+	avatar     BLOB,
+	seen_at    DATETIME,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`)
+
+	assertContains(t, out, "_snapshot *User")
+	assertContains(t, out, "s.Avatar = append([]byte(nil), x.Avatar...)")
+	assertContains(t, out, `return []string{"name", "avatar", "seen_at"}`)
+	assertContains(t, out, "if x.Name != s.Name {")
+	assertContains(t, out, "if string(x.Avatar) != string(s.Avatar) {")
+	assertContains(t, out, "if x.SeenAt.Valid != s.SeenAt.Valid || !x.SeenAt.Time.Equal(s.SeenAt.Time) {")
+	assertContains(t, out, "return nil // nothing to write")
+	assertContains(t, out, "err := scanUser(namedQueryRow(ctx, uncached(db),")
+	assertContains(t, out, "`UPDATE users SET `+strings.Join(set, \", \")+` WHERE id=:id RETURNING id, name, avatar, seen_at, created_at, updated_at`, x), x)")
+	assertContains(t, out, "func UserSetName(v string) UserField {")
+	assertNotContains(t, out, "func UserSetCreatedAt(")
+	assertContains(t, out, "func UserUpdateFields(ctx context.Context, db DB, ID int64, fields ...UserField) error {")
+	assertContains(t, out, "res, err := uncached(db).ExecContext(ctx,")
+	assertContains(t, out, "`UPDATE users SET `+strings.Join(set, \", \")+` WHERE id=?`, args...)")
+}
+
+func TestGenerate_SoftDelete(t *testing.T) {
+	off := ""
+	cfg := testConfig()
+	cfg.SoftDelete = "deleted_at"
+	cfg.Tables = map[string]config.TableConfig{"logs": {SoftDelete: &off}}
+	out := generateWith(t, `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	deleted_at DATETIME
+);
+CREATE TABLE logs (
+	id INTEGER PRIMARY KEY,
+	deleted_at DATETIME
+);`, cfg)
+
+	for _, want := range []string{
+		"// Soft Delete: deleted_at",
+		"SET deleted_at=datetime('now')\n\t\t\tWHERE id=:id AND deleted_at IS NULL\n\t\t\tRETURNING id, email, deleted_at",
+		"// Returns ErrNotFound if the row has already been deleted.\nfunc (x *User) Delete(",
+		"func (x *User) Restore(ctx context.Context, db DB) error {",
+		"SET deleted_at=NULL",
+		"func (x *User) HardDelete(ctx context.Context, db DB) error {",
+		"x._deleted = x.DeletedAt.Valid",
+		"WHERE id=? AND deleted_at IS NULL`, ID)",
+		"func UserGetByIDWithDeleted(ctx context.Context, db DB, ID int64) (*User, error) {",
+		"WHERE email=? AND deleted_at IS NULL`, Email)",
+		"func UserGetByEmailWithDeleted(",
+		"FROM users WHERE deleted_at IS NULL`)",
+		"func UserGetAllOnlyDeleted(ctx context.Context, db DB) ([]*User, error) {",
+		"SELECT id, email, deleted_at FROM users WHERE deleted_at IS NOT NULL",
+		"func UserAllWithDeleted(ctx context.Context, db DB) iter.Seq2[*User, error] {",
+		"INSERT INTO users (email)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected generated code to contain %q", want)
+		}
+	}
+	// The override turns soft deletes off for logs.
+	for _, unwanted := range []string{
+		"func (x *Log) Restore(",
+		"func LogGetAllWithDeleted(",
+	} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected generated code to not contain %q", unwanted)
+		}
+	}
+	if !strings.Contains(out, "DELETE FROM logs") {
+		t.Error("expected logs to keep a hard Delete")
+	}
+}
+
+func TestGenerate_VersionColumn(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE accounts (
+	id INTEGER NOT NULL PRIMARY KEY,
+	owner TEXT NOT NULL UNIQUE,
+	version INTEGER NOT NULL DEFAULT 1 -- squirrel:version
+);`)
+	for _, want := range []string{
+		"// Version: version",
+		`ErrStaleVersion			= errors.New("row was changed or deleted since it was read")`,
+		`set = append(set, "version=version+1")`,
+		"` WHERE id=:id AND version=:version RETURNING id, owner, version`",
+		"ON CONFLICT DO UPDATE SET owner=EXCLUDED.owner, version=version+1 WHERE accounts.version=:version",
+		"WHERE id=:id AND version=:version`, x)",
+		`return merry.Prependf(ErrStaleVersion, "accounts.Update (id=%v)", x.ID)`,
+		"INSERT INTO accounts (owner)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected generated code to contain %q", want)
+		}
+	}
+	if strings.Contains(out, "AccountSetVersion") {
+		t.Error("expected the version column to not be directly updatable")
+	}
+}
+
+func TestGenerate_TimestampConfig(t *testing.T) {
+	unixepoch := config.UnixEpoch
+	cfg := testConfig()
+	cfg.Timestamps.Updated = "modified_at"
+	cfg.Tables = map[string]config.TableConfig{"events": {Timestamps: config.TableTimestamps{Format: &unixepoch}}}
+	out := generateWith(t, `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	modified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL,
+	seen_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+CREATE TABLE events (
+	id INTEGER NOT NULL PRIMARY KEY,
+	name TEXT NOT NULL,
+	modified_at INTEGER NOT NULL DEFAULT (unixepoch())
+);`, cfg)
+
+	// seen_at defaults to the current time, so the DB generates it and upserts keep it; updated_at is
+	// an ordinary column.
+	assertContains(t, out, "INSERT INTO users (email, updated_at)")
+	assertContains(t, out, `set = append(set, "modified_at=datetime('now')")`)
+	assertContains(t, out, "ON CONFLICT DO UPDATE SET email=EXCLUDED.email, modified_at=datetime('now'), updated_at=EXCLUDED.updated_at\n")
+	assertContains(t, out, "INSERT INTO events (name)")
+	assertContains(t, out, `set = append(set, "modified_at=unixepoch()")`)
+	assertNotContains(t, out, "UserSetModifiedAt")
+	assertNotContains(t, out, "UserSetCreatedAt")
+}
+
+// TestGenerate_LifecycleHooks verifies the write methods call the optional hook interfaces, so
+// hand-written code can validate or react to writes without editing generated code.
+func TestGenerate_LifecycleHooks(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE
+);`)
+	for _, want := range []string{
+		"type BeforeInserter interface {\n\tBeforeInsert(context.Context, DB) error\n}",
+		"type AfterDeleter interface {\n\tAfterDelete(context.Context, DB) error\n}",
+		"	if h, ok := any(x).(BeforeInserter); ok {\n		if err := h.BeforeInsert(ctx, db); err != nil {\n			return merry.Prependf(err, \"users.Insert\")\n		}\n	}",
+		"any(x).(AfterInserter)",
+		"any(x).(BeforeUpdater)",
+		"any(x).(AfterUpdater)",
+		"any(x).(BeforeUpserter)",
+		"any(x).(AfterUpserter)",
+		"any(x).(BeforeDeleter)",
+		"any(x).(AfterDeleter)",
+		// The DO NOTHING upsert returns (bool, error).
+		"			return false, merry.Prependf(err, \"users.UpsertOnEmailDoNothing (email=%v)\", x.Email)\n",
+		// Batches call the hooks for every row.
+		"	for _, x := range rows {\n		if h, ok := any(x).(BeforeInserter); ok {",
+	} {
+		assertContains(t, out, want)
+	}
+}
+
+// TestGenerate_Queries verifies annotated queries compile to typed functions, scanning whole rows
+// into the table's struct and other results into a row struct or scalar.
+func TestGenerate_Queries(t *testing.T) {
+	tables, err := parser.Parse(`
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	name TEXT
+);`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	qs, err := queries.Parse("users.sql", `
+-- name: UserByEmail :one
+SELECT * FROM users WHERE email = :email;
+
+-- name: UserNames :many
+-- UserNames returns each user's email and name.
+SELECT email, name FROM users LIMIT ?;
+
+-- name: CountUsers :one
+SELECT count(*) FROM users;
+
+-- name: RenameUser :execrows
+UPDATE users SET name = ? WHERE id = ?;
+`)
+	if err != nil {
+		t.Fatalf("parse queries: %v", err)
+	}
+	for _, q := range qs {
+		if err := queries.Resolve(q, tables); err != nil {
+			t.Fatalf("resolve: %v", err)
+		}
+	}
+	var buf bytes.Buffer
+	WriteQueries(&buf, qs, testConfig())
+	out := buf.String()
+
+	for _, want := range []string{
+		"// UserByEmail runs the :one query from users.sql.\nfunc UserByEmail(ctx context.Context, db DB, email string) (*User, error) {",
+		"	err := scanUser(db.QueryRowContext(ctx, `SELECT id, email, name FROM users WHERE email = ?`, email), &row)",
+		"		if err := rows.Scan(&row.Email, &row.Name); err != nil {",
+		"	err := db.QueryRowContext(ctx, `SELECT count(*) FROM users`).Scan(&v)",
+		"	row.loaded()",
+		"type UserNamesRow struct {\n\tEmail string `db:\"email\"`\n\tName sql.NullString `db:\"name\"`\n}",
+		"// UserNames returns each user's email and name.\nfunc UserNames(ctx context.Context, db DB, limit int64) ([]*UserNamesRow, error) {",
+		"func CountUsers(ctx context.Context, db DB) (int64, error) {",
+		"func RenameUser(ctx context.Context, db DB, name sql.NullString, id int64) (int64, error) {",
+		"	n, err := res.RowsAffected()",
+	} {
+		assertContains(t, out, want)
+	}
+}
+
+// TestGenerate_DatabaseSQLRuntime verifies the database/sql runtime binds fields to positional
+// parameters, wraps errors with fmt.Errorf, and imports neither sqlx nor merry.
+func TestGenerate_DatabaseSQLRuntime(t *testing.T) {
+	cfg := testConfig()
+	cfg.Runtime = config.DatabaseSQL
+	cfg.CtxOnly = false
+	out := generateWith(t, `
+CREATE TABLE accounts (
+	id INTEGER NOT NULL PRIMARY KEY,
+	owner TEXT NOT NULL UNIQUE,
+	balance INTEGER NOT NULL,
+	version INTEGER NOT NULL DEFAULT 1 -- squirrel:version
+);`, cfg)
+
+	for _, want := range []string{
+		"	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row\n",
+		"	QueryRow(query string, args ...any) *sql.Row\n",
+		"works with *sql.Tx.",
+		"	err := scanAccount(db.QueryRowContext(ctx,`\n\t\tINSERT INTO accounts (owner, balance)\n\t\tVALUES (?, ?)\n\t\tRETURNING id, owner, balance, version`, x.Owner, x.Balance), x)",
+		"ON CONFLICT DO UPDATE SET owner=EXCLUDED.owner, balance=EXCLUDED.balance, version=version+1 WHERE accounts.version=?\n\t\tRETURNING id, owner, balance, version`, x.Owner, x.Balance, x.Version), x)",
+		"	args := make([]any, 0, len(changed)+2)",
+		"		args = append(args, x.value(col))",
+		"	args = append(args, x.ID, x.Version)",
+		"` WHERE id=? AND version=? RETURNING id, owner, balance, version`, args...), x)",
+		"func (x *Account) value(column string) any {\n\tswitch column {\n\tcase \"owner\":\n\t\treturn x.Owner",
+		"	res, err := db.ExecContext(ctx, `\n\t\t\tDELETE FROM accounts\n\t\t\tWHERE id=? AND version=?`, x.ID, x.Version)",
+		`		return fmt.Errorf("accounts.Update (id=%v): %w", x.ID, ErrStaleVersion)`,
+		`			return fmt.Errorf("accounts.Insert: %w", err)`,
+	} {
+		assertContains(t, out, want)
+	}
+	for _, notWant := range []string{"sqlx", "merry", "PrepareNamedContext", ":owner"} {
+		assertNotContains(t, out, notWant)
+	}
+}
+
+// TestGenerate_ErrorStrategies verifies each error strategy names the operation and the row's key
+// values, and imports only the packages it needs.
+func TestGenerate_ErrorStrategies(t *testing.T) {
+	schema := `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE
+);`
+	tests := []struct {
+		errors  config.ErrorStrategy
+		want    []string
+		notWant []string
+	}{
+		{
+			errors: config.ErrorsMerry,
+			want: []string{
+				`"github.com/ansel1/merry/v2"`,
+				`return merry.Prependf(ErrUpdateDoesNotExist, "users.Update (id=%v)", x.ID)`,
+				`return nil, merry.Prependf(err, "users.GetByEmail (email=%v)", Email)`,
+			},
+			notWant: []string{`"fmt"`, "wrapErr"},
+		},
+		{
+			errors: config.ErrorsStdlib,
+			want: []string{
+				`"fmt"`,
+				`return fmt.Errorf("users.Update (id=%v): %w", x.ID, ErrUpdateDoesNotExist)`,
+				`return nil, fmt.Errorf("users.GetByEmail (email=%v): %w", Email, err)`,
+				`return fmt.Errorf("users.InsertMany: %w", err)`,
+			},
+			notWant: []string{"merry", "wrapErr"},
+		},
+		{
+			errors: config.ErrorsHook,
+			want: []string{
+				"//\tfunc wrapErr(err error, op string, keys ...any) error",
+				`return wrapErr(ErrUpdateDoesNotExist, "users.Update", "id", x.ID)`,
+				`return nil, wrapErr(err, "users.GetByEmail", "email", Email)`,
+				`yield(nil, wrapErr(err, "users.All"))`,
+			},
+			notWant: []string{"merry", `"fmt"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.errors), func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Errors = tt.errors
+			out := generateWith(t, schema, cfg)
+			for _, want := range tt.want {
+				assertContains(t, out, want)
+			}
+			for _, notWant := range tt.notWant {
+				assertNotContains(t, out, notWant)
+			}
+		})
+	}
+}
+
+// TestGenerate_ConstraintErrors verifies write errors are classified into a *ConstraintError, with
+// a sentinel for each unique key and named CHECK constraint.
+func TestGenerate_ConstraintErrors(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	org TEXT NOT NULL,
+	handle TEXT NOT NULL,
+	age INTEGER NOT NULL CONSTRAINT chk_age CHECK (age >= 0),
+	CONSTRAINT uc_org_handle UNIQUE (org, handle)
+);`)
+	for _, want := range []string{
+		"type ConstraintError struct {",
+		"func constraintFailure(err error) (kind error, detail string) {",
+		`ErrUserEmailTaken = errors.New("users already has a row with this email")`,
+		`ErrUserOrgHandleTaken = errors.New("users already has a row with this org, handle")`,
+		`ErrUserAgeFailed = errors.New("users failed CHECK constraint chk_age")`,
+		"func classifyUserError(err error) error {",
+		"	case kind == ErrUniqueViolation && detail == \"users.email\":\n\t\te.Constraint, e.Columns, e.Sentinel = \"\", []string{\"email\"}, ErrUserEmailTaken",
+		"	case kind == ErrUniqueViolation && detail == \"users.org, users.handle\":\n\t\te.Constraint, e.Columns, e.Sentinel = \"uc_org_handle\", []string{\"org\", \"handle\"}, ErrUserOrgHandleTaken",
+		"	case kind == ErrCheckViolation && detail == \"chk_age\":\n\t\te.Constraint, e.Sentinel = \"chk_age\", ErrUserAgeFailed",
+		// Statements on the table are classified, but hooks' errors are not.
+		`return merry.Prependf(classifyUserError(err), "users.Insert")`,
+		`return merry.Prependf(classifyUserError(err), "users.InsertMany")`,
+		"		if err := h.BeforeInsert(ctx, db); err != nil {\n\t\t\treturn merry.Prependf(err, \"users.Insert\")",
+	} {
+		assertContains(t, out, want)
+	}
+	assertNotContains(t, out, `classifyUserError(err), "users.GetByID`)
+}
+
+// TestGenerate_ForeignKeyViolations verifies a FOREIGN KEY violation is attributed to the table's
+// foreign key only when no other could have failed.
+func TestGenerate_ForeignKeyViolations(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE teams (id INTEGER NOT NULL PRIMARY KEY);
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	team_id INTEGER NOT NULL,
+	CONSTRAINT fk_users_team FOREIGN KEY (team_id) REFERENCES teams(id)
+);
+CREATE TABLE posts (
+	id INTEGER NOT NULL PRIMARY KEY,
+	author_id INTEGER NOT NULL REFERENCES users(id)
+);
+CREATE TABLE nodes (
+	id INTEGER NOT NULL PRIMARY KEY,
+	parent_id INTEGER REFERENCES nodes(id)
+);
+CREATE TABLE memberships (
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	team_id INTEGER NOT NULL REFERENCES teams(id),
+	PRIMARY KEY (user_id, team_id)
+);`)
+	for _, want := range []string{
+		"	case kind == ErrForeignKeyViolation:\n\t\te.Constraint, e.Columns = \"\", []string{\"author_id\"}",
+		"	case kind == ErrForeignKeyViolation:\n\t\te.Constraint, e.Columns = \"\", []string{\"parent_id\"}",
+	} {
+		assertContains(t, out, want)
+	}
+	// users is referenced by posts and memberships, which has two foreign keys.
+	if n := strings.Count(out, "case kind == ErrForeignKeyViolation:"); n != 2 {
+		t.Errorf("got %d attributed foreign keys, want 2", n)
+	}
+	assertNotContains(t, out, `"fk_users_team"`)
+}
+
+// TestGenerate_NotFound verifies getters return ErrNotFound on a miss, Update and Delete report a
+// missing row, and the Find variants return nil instead.
+func TestGenerate_NotFound(t *testing.T) {
+	out := generate(t, `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE
+);`)
+	for _, want := range []string{
+		"var ErrNotFound error = notFoundError{}",
+		"func (notFoundError) Is(target error) bool\t{ return target == sql.ErrNoRows }",
+		// Getters
+		"WHERE id=?`, ID), &row)\n\tif errors.Is(err, sql.ErrNoRows) {\n\t\treturn nil, merry.Prependf(ErrNotFound, \"users.GetByID (id=%v)\", ID)\n\t}",
+		"func UserFindByID(ctx context.Context, db DB, ID int64) (*User, error) {\n\trow, err := UserGetByID(ctx, db, ID)\n\tif errors.Is(err, ErrNotFound) {\n\t\treturn nil, nil\n\t}\n\treturn row, err\n}",
+		"func UserFindByEmail(ctx context.Context, db DB, Email string) (*User, error) {",
+		// Update, UpdateFields, and Delete
+		"RETURNING id, email`, x), x)\n\tif errors.Is(err, sql.ErrNoRows) {\n\t\treturn merry.Prependf(ErrNotFound, \"users.Update (id=%v)\", x.ID)\n\t}",
+		"\tif n == 0 {\n\t\treturn merry.Prependf(ErrNotFound, \"users.UpdateFields (id=%v)\", ID)\n\t}",
+		"\tif n == 0 { // the row was deleted since it was read\n\t\treturn merry.Prependf(ErrNotFound, \"users.Delete (id=%v)\", x.ID)",
+	} {
+		assertContains(t, out, want)
+	}
+}
+
+// TestGenerate_PreparedQueries verifies both runtimes generate a Queries type that caches prepared
+// statements and can be rebound to a transaction.
+func TestGenerate_PreparedQueries(t *testing.T) {
+	schema := `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE
+);`
+	out := generate(t, schema)
+	for _, want := range []string{
+		"func NewQueries(db *sqlx.DB) *Queries {",
+		"func (q *Queries) WithTx(tx *sqlx.Tx) *Queries {",
+		"func (q *Queries) Close() error {",
+		"			q.stmts[query] = q.tx.StmtxContext(ctx, dbStmt)",
+		"		stmt, err = q.db.PreparexContext(ctx, query)",
+		"			q.named[query] = q.tx.NamedStmtContext(ctx, dbStmt)",
+		"func (q *Queries) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {",
+		"func namedQueryRow(ctx context.Context, db DB, query string, arg any) scanner {",
+		"	rows, err := uncached(db).QueryContext(ctx, query, args...)",
+	} {
+		assertContains(t, out, want)
+	}
+	assertNotContains(t, out, "defer stmt.Close()\n\terr = ")
+
+	cfg := testConfig()
+	cfg.Runtime = config.DatabaseSQL
+	out = generateWith(t, schema, cfg)
+	for _, want := range []string{
+		"func NewQueries(db *sql.DB) *Queries {",
+		"func (q *Queries) WithTx(tx *sql.Tx) *Queries {",
+		"			q.stmts[query] = q.tx.StmtContext(ctx, dbStmt)",
+		"		stmt, err = q.tx.PrepareContext(ctx, query)",
+	} {
+		assertContains(t, out, want)
+	}
+	assertNotContains(t, out, "namedStmt")
+}
+
+// TestGenerate_Transactions verifies both runtimes generate WithTx, which begins transactions on
+// the runtime's database, nests in savepoints, and retries when the database is busy.
+func TestGenerate_Transactions(t *testing.T) {
+	schema := `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE
+);`
+	out := generate(t, schema)
+	for _, want := range []string{
+		"func WithTx(ctx context.Context, db DB, opts *TxOptions, fn func(TX) error) error {",
+		"	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)",
+		"		tx = queriesTx{db.WithTx(t)}",
+		`	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+sp.name); err != nil {`,
+		`	_, err := s.ExecContext(s.ctx, "RELEASE SAVEPOINT "+s.name)`,
+		`			return merry.Prependf(err, "WithTx")`,
+		"		if err == nil || attempt >= opts.Retries || !isBusy(err) {",
+		"			_ = tx.Rollback()\n			panic(p)",
+		"func isBusy(err error) bool {",
+	} {
+		assertContains(t, out, want)
+	}
+
+	cfg := testConfig()
+	cfg.Runtime = config.DatabaseSQL
+	out = generateWith(t, schema, cfg)
+	assertContains(t, out, "	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)")
+	assertNotContains(t, out, "BeginTxx")
+}
+
+// TestGenerate_Stores verifies the stores option generates each table's Store interface, its
+// database implementation, and a fake that enforces the table's unique constraints.
+func TestGenerate_Stores(t *testing.T) {
+	schema := `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	avatar BLOB,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE logs (
+	msg TEXT NOT NULL
+);`
+	assertNotContains(t, generate(t, schema), "UserStore")
+
+	cfg := testConfig()
+	cfg.Stores = true
+	out := generateWith(t, schema, cfg)
+	for _, want := range []string{
+		"type UserStore interface {\n	GetByID(ctx context.Context, ID int64) (*User, error)\n	FindByID(ctx context.Context, ID int64) (*User, error)\n	GetByEmail(ctx context.Context, Email string) (*User, error)",
+		"	Insert(ctx context.Context, x *User) error\n	Update(ctx context.Context, x *User) error\n	Upsert(ctx context.Context, x *User) error\n	UpsertOnEmail(ctx context.Context, x *User) error\n	UpsertOnEmailDoNothing(ctx context.Context, x *User) (bool, error)\n	Delete(ctx context.Context, x *User) error\n	UpdateFields(ctx context.Context, ID int64, fields ...UserField) error\n}",
+		"func (s *UserDBStore) UpsertOnEmailDoNothing(ctx context.Context, x *User) (bool, error) {\n	return x.UpsertOnEmailDoNothing(ctx, s.db)",
+		"func (s *UserDBStore) UpdateFields(ctx context.Context, ID int64, fields ...UserField) error {\n	return UserUpdateFields(ctx, s.db, ID, fields...)",
+		"func (s *UserDBStore) GetByEmail(ctx context.Context, Email string) (*User, error) {\n	return UserGetByEmail(ctx, s.db, Email)",
+		"func (s *UserDBStore) Update(ctx context.Context, x *User) error {\n	return x.Update(ctx, s.db)",
+		"var _ UserStore = (*UserFakeStore)(nil)",
+		"	c.Avatar = append([]byte(nil), x.Avatar...)",
+		"		case row.Email == x.Email:",
+		`Sentinel: ErrUserEmailTaken,`,
+		`Err: errors.New("UNIQUE constraint failed: users.email")}`,
+		"	row.ID = s.lastID + 1",
+		"	row.CreatedAt = now",
+		"		case \"email\":\n			row.Email = x.Email",
+		"	s.rows = append(s.rows[:i], s.rows[i+1:]...)",
+		// Upserts update the conflicting row's columns, except the target's, or insert x.
+		"Upsert(ctx context.Context, x *User) error {\n	if x._deleted {\n		return ErrUpsertMarkedForDeletion\n	}\n	s.mu.Lock()\n	defer s.mu.Unlock()\n	for i, old := range s.rows {\n		if !(old.Email == x.Email) {\n			continue\n		}\n		row := s.clone(old)\n		row.Email = x.Email\n",
+		"	for i, old := range s.rows {\n		if !(old.Email == x.Email) {\n			continue\n		}\n		row := s.clone(old)\n		row.Avatar = append([]byte(nil), x.Avatar...)\n		if err := s.conflict(row, i); err != nil {",
+		"		if row.Email == x.Email {\n			return false, nil\n		}\n	}\n	if err := s.insert(x); err != nil {",
+		"	i := s.index(&User{ID: ID})",
+		"		case \"avatar\":\n			row.Avatar = append([]byte(nil), f.value.([]byte)...)",
+	} {
+		assertContains(t, out, want)
+	}
+	assertNotContains(t, out, "LogStore") // no primary key to get, update, or delete by
+	assertNotContains(t, out, "UserFakeStore) Restore")
+
+	cfg.SoftDelete = "deleted_at"
+	out = generateWith(t, `
+CREATE TABLE accounts (
+	id INTEGER NOT NULL PRIMARY KEY,
+	owner TEXT NOT NULL UNIQUE,
+	code TEXT NOT NULL UNIQUE,
+	version INTEGER NOT NULL DEFAULT 1, -- squirrel:version
+	deleted_at DATETIME
+);`, cfg)
+	for _, want := range []string{
+		"	GetByIDWithDeleted(ctx context.Context, ID int64) (*Account, error)\n	FindByIDWithDeleted(ctx context.Context, ID int64) (*Account, error)",
+		"	GetAllWithDeleted(ctx context.Context) ([]*Account, error)\n	GetAllOnlyDeleted(ctx context.Context) ([]*Account, error)",
+		"	Restore(ctx context.Context, x *Account) error\n	HardDelete(ctx context.Context, x *Account) error",
+		"		if row.Owner == Owner && !row.DeletedAt.Valid {",
+		"		if row.Owner == Owner {",
+		"		if row.DeletedAt.Valid {\n			all = append(all, s.clone(row))",
+		"		if old.Version != x.Version { // the row was changed since it was read\n			return ErrStaleVersion",
+		"		if !((old.Owner == x.Owner) || (old.Code == x.Code)) {",
+		"		row.Owner = x.Owner\n		row.Code = x.Code\n		row.Version++\n		if err",
+		"	row.DeletedAt = sql.NullTime{}\n	row.Version++",
+		"func (s *AccountFakeStore) HardDelete(ctx context.Context, x *Account) error {",
+	} {
+		assertContains(t, out, want)
+	}
+}
+
+// TestGenerate_Fixtures verifies fixtures fill NOT NULL columns, number unique ones, and insert the
+// rows they reference first, leaving keys in a cycle of NOT NULL foreign keys to overrides.
+func TestGenerate_Fixtures(t *testing.T) {
+	schema := `
+CREATE TABLE users (
+	id INTEGER NOT NULL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	age INTEGER NOT NULL CHECK (age >= 18),
+	bio TEXT
+);
+CREATE TABLE posts (
+	id INTEGER NOT NULL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id),
+	editor INTEGER REFERENCES users (id),
+	title TEXT NOT NULL
+);
+CREATE TABLE hens (
+	id INTEGER NOT NULL PRIMARY KEY,
+	egg_id INTEGER NOT NULL REFERENCES eggs (id)
+);
+CREATE TABLE eggs (
+	id INTEGER NOT NULL PRIMARY KEY,
+	hen_id INTEGER NOT NULL REFERENCES hens (id)
+);`
+	assertNotContains(t, generate(t, schema), "Fixture")
+
+	cfg := testConfig()
+	cfg.Fixtures = true
+	out := generateWith(t, schema, cfg)
+	for _, want := range []string{
+		"func nextFixtureSeq() int64 {",
+		"type PostFixture struct {\n	*Post\n	User *UserFixture // User is the row user_id references.\n	EditorUser *UserFixture // EditorUser is the row editor references.\n}",
+		"func NewUserFixture(overrides ...func(*User)) *UserFixture {\n	n := nextFixtureSeq()\n	x := &User{\n		Email: \"email \" + strconv.FormatInt(n, 10),\n		Age: 18,\n	}",
+		"func NewPostFixture(overrides ...func(*Post)) *PostFixture {\n	x := &Post{\n		Title: \"title 1\",\n	}",
+		"	if f.User == nil && f.Post.UserID == 0 {\n		f.User = NewUserFixture()\n	}",
+		"			if err := f.User.InsertFixture(ctx, db); err != nil {",
+		"		f.Post.UserID = f.User.User.ID",
+		"	if f.EditorUser != nil {",
+		"		f.Post.Editor = sql.NullInt64{Int64: f.EditorUser.User.ID, Valid: true}",
+		"	return f.Post.Insert(ctx, db)",
+		"	if f.Hen == nil && f.Egg.HenID == 0 {", // eggs are visited first, so hens.egg_id closes the cycle
+		"		EggID: 1,",
+	} {
+		assertContains(t, out, want)
+	}
+	assertNotContains(t, out, "f.EditorUser = NewUserFixture()")
+	assertNotContains(t, out, "f.Egg = NewEggFixture()")
+}
+
+// TestGenerate_Schema verifies the schema is embedded as printed from the parsed tables, with each
+// table after the tables it references, including tables that are not generated, and can be created
+// and checked.
+func TestGenerate_Schema(t *testing.T) {
+	schema := `
+CREATE TABLE posts (
+	id INTEGER NOT NULL PRIMARY KEY,
+	-- comments are dropped
+	user_id INTEGER NOT NULL REFERENCES users (id)
+);
+CREATE INDEX idx_posts_user ON posts (user_id);
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER NOT NULL PRIMARY KEY
+);
+CREATE TABLE goose_db_version (
+	id INTEGER NOT NULL PRIMARY KEY
+);`
+	cfg := testConfig()
+	cfg.IgnoreTables = []string{"goose_db_version"}
+	out := generateWith(t, schema, cfg)
+	for _, want := range []string{
+		"	schemaUserTable = `CREATE TABLE IF NOT EXISTS users (\n	id INTEGER PRIMARY KEY NOT NULL\n)`",
+		"	schemaPostTable = `CREATE TABLE posts (\n	id INTEGER PRIMARY KEY NOT NULL,\n	user_id INTEGER NOT NULL,\n	FOREIGN KEY (user_id) REFERENCES users (id)\n)`",
+		"	schemaIdxPostUserIndex = `CREATE INDEX idx_posts_user ON posts (user_id)`",
+		"const Schema = schemaUserTable + \";\\n\\n\" +\n	schemaPostTable + \";\\n\\n\" +\n	schemaIdxPostUserIndex + \";\\n\\n\" +\n	schemaGooseDbVersionTable + \";\\n\"",
+		"func CreateSchema(ctx context.Context, db DB) error {\n	return WithTx(ctx, db, nil, func(tx TX) error {",
+		"var SchemaHash = schemaHash(schemaStatements)",
+		"func CheckSchema(ctx context.Context, db DB) error {",
+		`return merry.Prependf(err, "CheckSchema")`,
+		"\t\"crypto/sha256\"\n",
+	} {
+		assertContains(t, out, want)
+	}
+	assertNotContains(t, out, "comments are dropped")
+
+	// The same tables written another way embed the same statements, so they hash the same.
+	again := generateWith(t, `
+CREATE TABLE posts (
+	id INTEGER NOT NULL PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES "users" (id)
+);
+CREATE INDEX idx_posts_user ON posts(user_id);
+CREATE TABLE IF NOT EXISTS users (id INTEGER NOT NULL, PRIMARY KEY (id));
+CREATE TABLE "goose_db_version" (id integer primary key not null);`, cfg)
+	const start, end = "\nconst (", "\n// CreateSchema"
+	if a, b := out[strings.Index(out, start):strings.Index(out, end)], again[strings.Index(again, start):strings.Index(again, end)]; a != b {
+		t.Errorf("equivalent schemas embed different statements:\n%s\n\n%s", a, b)
+	}
+}
+
+// TestRoundTrip verifies the round-trip test creates the original schema and exercises each
+// table's getters and writes with values its CHECK constraints accept.
+func TestRoundTrip(t *testing.T) {
+	schema := `
+CREATE TABLE items (
+	id INTEGER NOT NULL PRIMARY KEY,
+	sku TEXT NOT NULL UNIQUE CHECK (length(sku) >= 8),
+	status TEXT NOT NULL DEFAULT 'new' CHECK (status IN ('new', 'sold')),
+	qty INTEGER NOT NULL CHECK (qty BETWEEN 10 AND 20),
+	rank INTEGER NOT NULL CHECK (0 < rank),
+	temp REAL CHECK (temp > -5.5 AND temp < 0)
+);
+CREATE TABLE notes (
+	body TEXT NOT NULL
+);`
+	tables, err := parser.Parse(schema)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var buf bytes.Buffer
+	RoundTrip(&buf, schema, tables, testConfig())
+	out := buf.String()
+	for _, want := range []string{
+		"	_ \"github.com/mattn/go-sqlite3\"",
+		"const roundTripSchema = `\nCREATE TABLE items (",
+		"	db, err := sqlx.Open(\"sqlite3\", \":memory:\")",
+		"func TestRoundTripItem(t *testing.T) {",
+		"		Sku: \"sku 1xxx\",\n		Status: \"new\",\n		Qty: 10,\n		Rank: 1,\n		Temp: sql.NullFloat64{Float64: -5.499, Valid: true},",
+		"	got, err := ItemGetByID(ctx, db, x.ID)",
+		"	got, err = ItemGetBySku(ctx, db, x.Sku)",
+		"	x.Sku = \"sku 2xxx\"\n	if err := x.Update(ctx, db); err != nil {",
+		"	if err := x.Upsert(ctx, db); err != nil {",
+		"	if _, err := ItemGetByID(ctx, db, x.ID); !errors.Is(err, ErrNotFound) {",
+		"	if got.Temp != want.Temp {",
+		"	for got, err := range NoteAll(ctx, db) {",
+	} {
+		assertContains(t, out, want)
+	}
+	assertNotContains(t, out, "NoteGetBy")
+
+	cfg := testConfig()
+	cfg.Runtime = config.DatabaseSQL
+	cfg.TestDriver = "modernc.org/sqlite"
+	buf.Reset()
+	RoundTrip(&buf, "CREATE TABLE `notes` (body TEXT NOT NULL);", tables[1:], cfg)
+	out = buf.String()
+	assertContains(t, out, "	db, err := sql.Open(\"sqlite\", \":memory:\")")
+	assertContains(t, out, "const roundTripSchema = \"CREATE TABLE `notes` (body TEXT NOT NULL);\"")
+	assertNotContains(t, out, "sqlx")
+}
@@ -0,0 +1,184 @@
+package templates
+
+import (
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+)
+
+// Transactions writes WithTx, which runs a function in a transaction (or, nested in one, a
+// savepoint) and retries it while SQLite reports the database busy or locked.
+func Transactions(w *ShortWriter, cfg *config.Config) {
+	pkg, begin, beginners := "sql", "BeginTx", "*sql.DB and *sql.Conn"
+	if cfg.Runtime != config.DatabaseSQL {
+		pkg, begin, beginners = "sqlx", "BeginTxx", "*sqlx.DB and *sqlx.Conn"
+	}
+	op := NewOp(cfg, "WithTx")
+	r := strings.NewReplacer("PKG", pkg, "BEGINNERS", beginners, "BEGIN", begin, "WRAP", op.Wrap("err"))
+
+	w.N(r.Replace(`
+// TxOptions configures WithTx. The zero value begins transactions with the driver's defaults and
+// does not retry them.
+type TxOptions struct {
+	Isolation  sql.IsolationLevel
+	ReadOnly   bool
+	Retries    int           // Retries is how many times to retry a transaction the database was too busy or locked to run.
+	Backoff    time.Duration // Backoff is the delay before the first retry, which doubles after each. Defaults to 10ms.
+	MaxBackoff time.Duration // MaxBackoff is the longest delay between retries. Defaults to 1s.
+}
+
+// WithTx runs fn in a transaction, committing it if fn returns nil, and rolling it back if fn
+// returns an error or panics. fn must not commit or roll back the TX itself. db may be a *PKG.DB, a
+// *PKG.Conn, or a *Queries, whose prepared statements the TX then reuses. If db is a TX, such as one
+// passed to an enclosing fn, fn runs in a savepoint instead, so its changes can be rolled back
+// without aborting the enclosing transaction. opts may be nil.
+//
+// If SQLite reports the database busy or locked (SQLITE_BUSY or SQLITE_LOCKED), WithTx rolls back
+// and retries the transaction up to opts.Retries times with exponential backoff, so fn may run more
+// than once. Savepoints are never retried, since their enclosing transaction holds its locks.
+func WithTx(ctx context.Context, db DB, opts *TxOptions, fn func(TX) error) error {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+	if q, ok := db.(*Queries); ok && q.tx != nil {
+		db = queriesTx{q}
+	}
+	if tx, ok := db.(TX); ok {
+		return runSavepoint(ctx, tx, fn)
+	}
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = 10 * time.Millisecond
+	}
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		err := runTx(ctx, db, opts, fn)
+		if err == nil || attempt >= opts.Retries || !isBusy(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// beginner is implemented by BEGINNERS, which WithTx begins transactions on.
+type beginner interface {
+	BEGIN(ctx context.Context, opts *sql.TxOptions) (*PKG.Tx, error)
+}
+
+// runTx runs fn in a new transaction on db.
+func runTx(ctx context.Context, db DB, opts *TxOptions, fn func(TX) error) error {
+	txOpts := &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+	var tx TX
+	switch db := db.(type) {
+	case *Queries:
+		t, err := db.db.BEGIN(ctx, txOpts)
+		if err != nil {
+			return WRAP
+		}
+		tx = queriesTx{db.WithTx(t)}
+	case beginner:
+		t, err := db.BEGIN(ctx, txOpts)
+		if err != nil {
+			return WRAP
+		}
+		tx = t
+	default:
+		err := errors.New("db cannot begin a transaction")
+		return WRAP
+	}
+	return finish(tx, fn)
+}
+
+// runSavepoint runs fn in a new savepoint in tx.
+func runSavepoint(ctx context.Context, tx TX, fn func(TX) error) error {
+	sp := savepoint{TX: tx, ctx: ctx, depth: 1}
+	if parent, ok := tx.(savepoint); ok {
+		sp.depth = parent.depth + 1
+	}
+	sp.name = "squirrel_sp" + strconv.Itoa(sp.depth)
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+sp.name); err != nil {
+		return WRAP
+	}
+	return finish(sp, fn)
+}
+
+// finish runs fn in tx, then commits tx if fn succeeded, or rolls it back if fn failed or panicked.
+// fn's error is returned unchanged, joined with Rollback's if that failed too.
+func finish(tx TX, fn func(TX) error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return WRAP
+	}
+	return nil
+}
+
+// savepoint is the TX WithTx passes to fn when it is nested in another transaction. Committing it
+// releases the savepoint, and rolling it back undoes only what was done since it was created.
+type savepoint struct {
+	TX
+	ctx   context.Context // ctx is the one WithTx was called with, since Commit and Rollback take none.
+	name  string
+	depth int // depth is 1, plus the number of savepoints it is nested in.
+}
+
+func (s savepoint) Commit() error {
+	_, err := s.ExecContext(s.ctx, "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+func (s savepoint) Rollback() error {
+	ctx := context.WithoutCancel(s.ctx) // still undo fn's changes if it failed because ctx was canceled
+	if _, err := s.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+s.name); err != nil {
+		return err
+	}
+	_, err := s.ExecContext(ctx, "RELEASE SAVEPOINT "+s.name) // ROLLBACK TO leaves the savepoint open
+	return err
+}
+
+// queriesTx is the TX WithTx passes to fn when db is a *Queries: Queries from WithTx, which commit
+// or roll back the transaction they run in.
+type queriesTx struct{ *Queries }
+
+func (q queriesTx) Commit() error   { return q.tx.Commit() }
+func (q queriesTx) Rollback() error { return q.tx.Rollback() }
+
+// isBusy reports whether err is SQLite's SQLITE_BUSY or SQLITE_LOCKED, which mean another
+// connection holds a lock the transaction needs, so it may succeed if retried. Drivers report these
+// through a Code method (e.g. modernc.org/sqlite) or only in their message (e.g.
+// github.com/mattn/go-sqlite3), so both are checked rather than importing a driver.
+func isBusy(err error) bool {
+	var coder interface{ Code() int }
+	if errors.As(err, &coder) {
+		if code := coder.Code() & 0xff; code == 5 || code == 6 { // SQLITE_BUSY, SQLITE_LOCKED, or their extended codes
+			return true
+		}
+	}
+	msg := err.Error()
+	for _, s := range []string{"SQLITE_BUSY", "SQLITE_LOCKED", "database is locked", "database table is locked"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+`))
+}
@@ -0,0 +1,122 @@
+package templates
+
+import (
+	"strings"
+
+	"github.com/joshsziegler/squirrel/config"
+	"github.com/joshsziegler/squirrel/parser"
+)
+
+// versionAnnotation marks a column as the table's version column in its schema comment, as an
+// alternative to naming it in the config (e.g. "version INTEGER NOT NULL DEFAULT 1 -- squirrel:version").
+const versionAnnotation = "squirrel:version"
+
+// VersionColumn returns the table's optimistic-concurrency version column (see config.Config.Version),
+// or nil if it has none. The column must be a NOT NULL integer, and the table must have a primary key
+// to match rows by.
+func VersionColumn(t *parser.Table, cfg *config.Config) *parser.Column {
+	if len(t.PrimaryKeys()) < 1 {
+		return nil
+	}
+	col := t.Column(cfg.VersionColumn(t.SQLName()))
+	if col == nil {
+		for i := range t.Columns {
+			if strings.Contains(t.Columns[i].Comment, versionAnnotation) {
+				col = &t.Columns[i]
+				break
+			}
+		}
+	}
+	if col == nil || col.Nullable || col.Type != parser.INT || col.PrimaryKey || col.CompositePrimaryKey {
+		return nil
+	}
+	return col
+}
+
+// WhereRow returns the where clause matching this struct's row, which is WherePKs plus its version
+// if the table has a version column (e.g. "id=:id AND version=:version").
+func WhereRow(t *parser.Table, cfg *config.Config, p *Params) string {
+	where := WherePKs(t, p)
+	if col := VersionColumn(t, cfg); col != nil {
+		where += " AND " + col.SQLName() + "=" + p.Bind(col)
+	}
+	return where
+}
+
+// VersionSet returns the SET clause that increments the version column, with a leading comma and
+// space, or "" if the table has none.
+func VersionSet(t *parser.Table, cfg *config.Config) string {
+	if col := VersionColumn(t, cfg); col != nil {
+		return ", " + col.SQLName() + "=" + col.SQLName() + "+1"
+	}
+	return ""
+}
+
+// UpsertWhere returns the WHERE clause for an upsert's DO UPDATE, with a leading space, which only
+// lets the update through if the existing row still has this struct's version. Returns "" if the
+// table has no version column.
+func UpsertWhere(t *parser.Table, cfg *config.Config, p *Params) string {
+	if col := VersionColumn(t, cfg); col != nil {
+		return " WHERE " + t.SQLName() + "." + col.SQLName() + "=" + p.Bind(col)
+	}
+	return ""
+}
+
+// StaleOnNoRows writes a check that returns ErrStaleVersion if a versioned write, which scans the
+// RETURNING row into err, matched no row. ret is returned alongside the error (e.g. "false, "), or
+// "" if the function only returns an error. Does nothing if the table has no version column.
+func StaleOnNoRows(w *ShortWriter, t *parser.Table, cfg *config.Config, op *Op, ret string) {
+	if VersionColumn(t, cfg) == nil {
+		return
+	}
+	w.N("	if errors.Is(err, sql.ErrNoRows) { // the row was changed or deleted since it was read")
+	w.F("		return %s%s\n", ret, op.Wrap("ErrStaleVersion"))
+	w.N("	}")
+}
+
+// MissingOnNoRows writes a check that returns ErrNotFound if a write to this struct's row, which
+// scans the RETURNING row into err, matched no row because it has been deleted. If the table has a
+// version column, it returns ErrStaleVersion instead (see StaleOnNoRows).
+func MissingOnNoRows(w *ShortWriter, t *parser.Table, cfg *config.Config, op *Op, ret string) {
+	if VersionColumn(t, cfg) != nil {
+		StaleOnNoRows(w, t, cfg, op, ret)
+		return
+	}
+	NotFoundOnNoRows(w, op, ret)
+}
+
+// NotFoundOnNoRows writes a check that returns ErrNotFound instead of sql.ErrNoRows in err. ret is
+// returned alongside the error (e.g. "nil, "), or "" if the function only returns an error.
+func NotFoundOnNoRows(w *ShortWriter, op *Op, ret string) {
+	w.N("	if errors.Is(err, sql.ErrNoRows) {")
+	w.F("		return %s%s\n", ret, op.Wrap("ErrNotFound"))
+	w.N("	}")
+}
+
+// DeleteExec writes the statement that deletes this struct's row, returning ErrNotFound if no row
+// matched it, or ErrStaleVersion if the table has a version column.
+func DeleteExec(w *ShortWriter, t *parser.Table, cfg *config.Config, op *Op) {
+	p := NewParams(cfg)
+	exec := "NamedExecContext"
+	if !p.named {
+		exec = "ExecContext"
+	}
+	w.F("	res, err := db.%s(ctx, `\n", exec)
+	w.F("			DELETE FROM %s\n", t.SQLName())
+	w.F("			WHERE %s`%s)\n", WhereRow(t, cfg, p), p.ArgList())
+	w.N("	if err != nil {")
+	w.F("		return %s\n", op.Wrap("err"))
+	w.N("	}")
+	w.N("	n, err := res.RowsAffected()")
+	w.N("	if err != nil {")
+	w.F("		return %s\n", op.Wrap("err"))
+	w.N("	}")
+	if VersionColumn(t, cfg) != nil {
+		w.N("	if n == 0 { // the row was changed or deleted since it was read")
+		w.F("		return %s\n", op.Wrap("ErrStaleVersion"))
+	} else {
+		w.N("	if n == 0 { // the row was deleted since it was read")
+		w.F("		return %s\n", op.Wrap("ErrNotFound"))
+	}
+	w.N("	}")
+}